@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/yourorg/tg-release-bot/internal/advisor"
+	"github.com/yourorg/tg-release-bot/internal/db"
+)
+
+// usageStore adapts db.Storage to advisor.UsageStore
+type usageStore struct {
+	store db.Storage
+}
+
+// RecordUsage implements advisor.UsageStore.RecordUsage
+func (a usageStore) RecordUsage(ctx context.Context, u advisor.Usage) error {
+	return a.store.RecordLLMUsage(ctx, db.LLMUsage{
+		Repo:             u.Repo,
+		Tag:              u.Tag,
+		Model:            u.Model,
+		PromptTokens:     u.PromptTokens,
+		CompletionTokens: u.CompletionTokens,
+		CostUSD:          u.CostUSD,
+	})
+}
+
+// CostSince implements advisor.UsageStore.CostSince
+func (a usageStore) CostSince(ctx context.Context, since time.Time) (float64, error) {
+	return a.store.LLMCostSince(ctx, since)
+}
+
+// promptStore adapts db.Storage to advisor.PromptStore
+type promptStore struct {
+	store db.Storage
+}
+
+// SavePrompt implements advisor.PromptStore.SavePrompt
+func (a promptStore) SavePrompt(ctx context.Context, name, system, user string) error {
+	return a.store.SavePrompt(ctx, name, system, user)
+}
+
+// GetPrompt implements advisor.PromptStore.GetPrompt
+func (a promptStore) GetPrompt(ctx context.Context, name string) (advisor.Prompt, bool, error) {
+	p, ok, err := a.store.GetPrompt(ctx, name)
+	if err != nil || !ok {
+		return advisor.Prompt{}, ok, err
+	}
+	return advisor.Prompt{Name: p.Name, System: p.System, User: p.User, Source: "db"}, true, nil
+}
+
+// ListPrompts implements advisor.PromptStore.ListPrompts
+func (a promptStore) ListPrompts(ctx context.Context) ([]advisor.Prompt, error) {
+	dbPrompts, err := a.store.ListPrompts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var prompts []advisor.Prompt
+	for _, p := range dbPrompts {
+		prompts = append(prompts, advisor.Prompt{Name: p.Name, System: p.System, User: p.User, Source: "db"})
+	}
+	return prompts, nil
+}
+
+// AssignPrompt implements advisor.PromptStore.AssignPrompt
+func (a promptStore) AssignPrompt(ctx context.Context, scope, target, name string) error {
+	return a.store.AssignPrompt(ctx, scope, target, name)
+}
+
+// UnassignPrompt implements advisor.PromptStore.UnassignPrompt
+func (a promptStore) UnassignPrompt(ctx context.Context, scope, target string) error {
+	return a.store.UnassignPrompt(ctx, scope, target)
+}
+
+// PromptAssignment implements advisor.PromptStore.PromptAssignment
+func (a promptStore) PromptAssignment(ctx context.Context, scope, target string) (string, error) {
+	return a.store.PromptAssignment(ctx, scope, target)
+}
+
+var (
+	_ advisor.UsageStore  = usageStore{}
+	_ advisor.PromptStore = promptStore{}
+)
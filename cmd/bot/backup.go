@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yourorg/tg-release-bot/internal/db"
+	"github.com/yourorg/tg-release-bot/internal/scheduler"
+)
+
+const (
+	backupPrefix = "releases-"
+	backupSuffix = ".db"
+)
+
+// backups writes timestamped database snapshots into a directory and keeps
+// only the newest ones
+type backups struct {
+	logger *slog.Logger
+	store  db.Storage
+	dir    string
+	keep   int
+}
+
+// Snapshot writes a new backup and rotates old ones, returning the backup path
+func (b *backups) Snapshot(ctx context.Context) (string, error) {
+	if err := os.MkdirAll(b.dir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	name := backupPrefix + time.Now().UTC().Format("20060102-150405") + backupSuffix
+	path := filepath.Join(b.dir, name)
+	if err := b.store.BackupInto(ctx, path); err != nil {
+		return "", err
+	}
+
+	if err := b.rotate(); err != nil {
+		b.logger.Warn("Failed to remove old backups", "error", err)
+	}
+	return path, nil
+}
+
+// rotate removes all but the newest keep backups; names sort by time
+func (b *backups) rotate() error {
+	entries, err := os.ReadDir(b.dir)
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && strings.HasPrefix(name, backupPrefix) && strings.HasSuffix(name, backupSuffix) {
+			names = append(names, name)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+
+	for _, name := range names[min(len(names), max(b.keep, 1)):] {
+		if err := os.Remove(filepath.Join(b.dir, name)); err != nil {
+			return err
+		}
+		b.logger.Info("Removed old backup", "file", name)
+	}
+	return nil
+}
+
+// createBackupJob creates the job that writes scheduled backups
+func createBackupJob(logger *slog.Logger, b *backups) scheduler.Job {
+	return func(ctx context.Context, run scheduler.Run) error {
+		start := time.Now()
+		path, err := b.Snapshot(ctx)
+		if err != nil {
+			logger.Error("Backup failed", "error", err)
+			return err
+		}
+
+		var size int64
+		if info, err := os.Stat(path); err == nil {
+			size = info.Size()
+		}
+		logger.Info("Backup completed", "file", path, "size_bytes", size, "duration", time.Since(start))
+		return nil
+	}
+}
+
+// backupDir returns BACKUP_DIR or a backups directory next to the SQLite database
+func backupDir(configured string) string {
+	if configured != "" {
+		return configured
+	}
+	return filepath.Join(filepath.Dir(getEnv("DB_PATH", "./releases.db")), "backups")
+}
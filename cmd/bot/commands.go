@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/yourorg/tg-release-bot/internal/config"
+	"github.com/yourorg/tg-release-bot/internal/db"
+)
+
+const commandUsage = `Usage: tg-release-bot [command]
+
+Without a command the bot is started.
+
+Commands:
+  config check [file]
+                   Validate the configuration from the environment and
+                   CONFIG_FILE (or the given file) and list every problem
+  health [url]     Query /readyz of the running bot (at HTTP_ADDR, or the
+                   given URL) and exit non-zero unless it is ready; for
+                   container health checks
+  migrate status   Show applied and pending schema migrations
+  migrate up       Apply pending schema migrations
+  restore <backup>  Validate a backup and replace the SQLite database at
+                   DB_PATH with it; stop the bot first. The current
+                   database is kept with a .before-restore suffix
+`
+
+// runCommand runs a maintenance subcommand and returns the process exit code
+func runCommand(args []string) int {
+	switch args[0] {
+	case "config":
+		return runConfig(args[1:])
+	case "health":
+		return runHealth(args[1:])
+	case "migrate":
+		return runMigrate(args[1:])
+	case "restore":
+		return runRestore(args[1:])
+	case "help", "-h", "--help":
+		fmt.Print(commandUsage)
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", args[0], commandUsage)
+		return 2
+	}
+}
+
+func runConfig(args []string) int {
+	if len(args) < 1 || len(args) > 2 || args[0] != "check" {
+		fmt.Fprint(os.Stderr, commandUsage)
+		return 2
+	}
+	if len(args) == 2 {
+		os.Setenv("CONFIG_FILE", args[1])
+	}
+
+	cfg, err := config.Load(configChecks)
+	if err != nil {
+		var invalid *config.ValidationError
+		if !errors.As(err, &invalid) {
+			fmt.Fprintf(os.Stderr, "failed to load configuration: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(os.Stderr, "Configuration has %d problem(s):\n", len(invalid.Problems))
+		for _, problem := range invalid.Problems {
+			fmt.Fprintf(os.Stderr, "  - %s\n", problem)
+		}
+		return 1
+	}
+
+	source := "environment"
+	if cfg.ConfigFile != "" {
+		source = "environment and " + cfg.ConfigFile
+	}
+	fmt.Printf("Configuration from %s is valid: %d repositories, %d chats\n", source, len(cfg.InitialRepositories), len(cfg.Chats))
+	return 0
+}
+
+func runHealth(args []string) int {
+	if len(args) > 1 {
+		fmt.Fprint(os.Stderr, commandUsage)
+		return 2
+	}
+
+	url := ""
+	if len(args) == 1 {
+		url = args[0]
+	} else {
+		cfg, err := config.Load(configChecks)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to load configuration: %v\n", err)
+			return 1
+		}
+		if cfg.HTTPAddr == "" {
+			fmt.Fprintln(os.Stderr, "HTTP_ADDR is not set, the health endpoints are disabled")
+			return 1
+		}
+		host, port, _ := net.SplitHostPort(cfg.HTTPAddr)
+		if host == "" || host == "0.0.0.0" || host == "::" {
+			host = "127.0.0.1"
+		}
+		url = "http://" + net.JoinHostPort(host, port) + "/readyz"
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "health check failed: %v\n", err)
+		return 1
+	}
+	defer resp.Body.Close()
+
+	io.Copy(os.Stdout, resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		fmt.Fprintf(os.Stderr, "not ready: %s\n", resp.Status)
+		return 1
+	}
+	return 0
+}
+
+func runMigrate(args []string) int {
+	if len(args) != 1 || (args[0] != "status" && args[0] != "up") {
+		fmt.Fprint(os.Stderr, commandUsage)
+		return 2
+	}
+
+	ctx := context.Background()
+	dsn, err := databaseDSN()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to read database settings: %v\n", err)
+		return 1
+	}
+	database, err := db.OpenWithoutMigrations(dsn)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to open database: %v\n", err)
+		return 1
+	}
+	defer database.Close()
+
+	if args[0] == "up" {
+		applied, err := database.Migrate(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "migration failed: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Applied %d migration(s)\n", applied)
+		return 0
+	}
+
+	states, err := database.MigrationStatus(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to read migration status: %v\n", err)
+		return 1
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	for _, state := range states {
+		status, appliedAt := "pending", "-"
+		if state.Applied {
+			status = "applied"
+			appliedAt = state.AppliedAt.Local().Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", state.Version, state.Name, status, appliedAt)
+	}
+	if err := w.Flush(); err != nil {
+		return 1
+	}
+	return 0
+}
+
+func runRestore(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprint(os.Stderr, commandUsage)
+		return 2
+	}
+	if os.Getenv("DATABASE_URL") != "" || os.Getenv("DATABASE_URL_FILE") != "" {
+		fmt.Fprintln(os.Stderr, "restore only supports SQLite; unset DATABASE_URL and set DB_PATH")
+		return 2
+	}
+
+	dbPath := getEnv("DB_PATH", "./releases.db")
+	if err := db.RestoreBackup(context.Background(), args[0], dbPath); err != nil {
+		fmt.Fprintf(os.Stderr, "restore failed: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("Restored %s from %s; previous database kept as %s.before-restore\n", dbPath, args[0], dbPath)
+	return 0
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/yourorg/tg-release-bot/internal/db"
+	"github.com/yourorg/tg-release-bot/internal/github"
+	"github.com/yourorg/tg-release-bot/internal/scheduler"
+	"github.com/yourorg/tg-release-bot/internal/telegram"
+)
+
+const (
+	// readyTimeout bounds all readiness checks of one request
+	readyTimeout = 5 * time.Second
+	// telegramCheckTTL is how long a getMe result is reused, so frequent
+	// probes do not hit the Bot API every time
+	telegramCheckTTL = time.Minute
+)
+
+// health serves /healthz and /readyz
+type health struct {
+	logger   *slog.Logger
+	store    db.Storage
+	sender   *telegram.Sender
+	github   *github.Client
+	releases *scheduler.Scheduler
+	live     *liveSettings
+	started  time.Time
+
+	mu           sync.Mutex
+	telegramAt   time.Time
+	telegramErr  error
+	telegramDone chan struct{} // closed when the getMe call in flight returns
+}
+
+// checkResult is the outcome of one readiness check
+type checkResult struct {
+	Status  string         `json:"status"` // "ok" or "fail"
+	Error   string         `json:"error,omitempty"`
+	Details map[string]any `json:"details,omitempty"`
+}
+
+// readyResponse is the /readyz body
+type readyResponse struct {
+	Status string                 `json:"status"` // "ready" or "unavailable"
+	Checks map[string]checkResult `json:"checks"`
+}
+
+// handleHealth reports that the process is up and serving requests
+func (h *health) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"status": "ok",
+		"uptime": time.Since(h.started).Round(time.Second).String(),
+	})
+}
+
+// handleReady reports whether the bot can do its work: the database answers,
+// Telegram accepts the token, release checks run on time and GitHub requests
+// are not blocked by an exhausted rate limit. It answers 503 when any check fails.
+func (h *health) handleReady(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readyTimeout)
+	defer cancel()
+
+	checks := map[string]func(context.Context) (map[string]any, error){
+		"database":  h.checkDatabase,
+		"telegram":  h.checkTelegram,
+		"scheduler": h.checkScheduler,
+		"github":    h.checkGithub,
+	}
+
+	resp := readyResponse{Status: "ready", Checks: make(map[string]checkResult, len(checks))}
+	for name, check := range checks {
+		details, err := check(ctx)
+		result := checkResult{Status: "ok", Details: details}
+		if err != nil {
+			result.Status, result.Error = "fail", err.Error()
+			resp.Status = "unavailable"
+			h.logger.Debug("Readiness check failed", "check", name, "error", err)
+		}
+		resp.Checks[name] = result
+	}
+
+	status := http.StatusOK
+	if resp.Status != "ready" {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, resp)
+}
+
+func (h *health) checkDatabase(ctx context.Context) (map[string]any, error) {
+	start := time.Now()
+	if err := h.store.Ping(ctx); err != nil {
+		return nil, fmt.Errorf("database is unreachable: %w", err)
+	}
+	return map[string]any{"latency": time.Since(start).String()}, nil
+}
+
+// checkTelegram calls getMe, reusing a recent result. The call cannot be
+// cancelled, so a request that times out leaves it to finish in the background.
+func (h *health) checkTelegram(ctx context.Context) (map[string]any, error) {
+	h.mu.Lock()
+	if h.telegramDone == nil && time.Since(h.telegramAt) >= telegramCheckTTL {
+		done := make(chan struct{})
+		h.telegramDone = done
+		go func() {
+			err := h.sender.Ping()
+			h.mu.Lock()
+			h.telegramAt, h.telegramErr, h.telegramDone = time.Now(), err, nil
+			h.mu.Unlock()
+			close(done)
+		}()
+	}
+	done := h.telegramDone
+	h.mu.Unlock()
+
+	if done != nil {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("getMe did not answer: %w", ctx.Err())
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	details := map[string]any{"checked_at": h.telegramAt.UTC().Format(time.RFC3339)}
+	return details, h.telegramErr
+}
+
+// checkScheduler fails when the last release check started more than
+// READY_MAX_MISSED_RUNS poll intervals ago. With POLL_CRON or adaptive polling
+// the gap between runs may be longer (e.g. overnight, or for repositories that
+// release rarely), so it only fails when the next run is also overdue.
+func (h *health) checkScheduler(ctx context.Context) (map[string]any, error) {
+	cfg, _ := h.live.Load()
+	_, pollCron, err := pollSchedule(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	status := h.releases.Status()
+	now := time.Now()
+	age := now.Sub(status.LastStart)
+	limit := time.Duration(cfg.ReadyMaxMissedRuns*cfg.IntervalMinutes) * time.Minute
+	details := map[string]any{
+		"last_run":    status.LastStart.UTC().Format(time.RFC3339),
+		"last_result": status.LastResult,
+		"next_run":    status.NextRun.UTC().Format(time.RFC3339),
+		"running":     status.Running,
+		"age":         age.Round(time.Second).String(),
+		"max_age":     limit.String(),
+	}
+
+	sparse := pollCron != nil || cfg.AdaptivePolling
+	if age <= limit || (sparse && !status.Running && status.NextRun.After(now)) {
+		return details, nil
+	}
+	if status.Running {
+		return details, fmt.Errorf("release check has been running for %s", age.Round(time.Second))
+	}
+	return details, fmt.Errorf("last release check started %s ago, more than %d intervals", age.Round(time.Second), cfg.ReadyMaxMissedRuns)
+}
+
+// checkGithub fails while the rate limit is exhausted and not yet reset
+func (h *health) checkGithub(ctx context.Context) (map[string]any, error) {
+	remaining, reset := h.github.RateLimit()
+	if remaining < 0 {
+		return map[string]any{"rate_limit_remaining": "unknown"}, nil
+	}
+
+	details := map[string]any{
+		"rate_limit_remaining": remaining,
+		"rate_limit_reset":     reset.UTC().Format(time.RFC3339),
+	}
+	if remaining == 0 && time.Now().Before(reset) {
+		return details, fmt.Errorf("rate limit exhausted until %s", reset.UTC().Format(time.RFC3339))
+	}
+	return details, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
@@ -4,33 +4,52 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/yourorg/tg-release-bot/internal/advisor"
-	"github.com/yourorg/tg-release-bot/internal/compose"
+	"github.com/yourorg/tg-release-bot/internal/api"
 	"github.com/yourorg/tg-release-bot/internal/config"
+	"github.com/yourorg/tg-release-bot/internal/dashboard"
 	"github.com/yourorg/tg-release-bot/internal/db"
 	"github.com/yourorg/tg-release-bot/internal/github"
 	"github.com/yourorg/tg-release-bot/internal/logging"
 	"github.com/yourorg/tg-release-bot/internal/scheduler"
 	"github.com/yourorg/tg-release-bot/internal/telegram"
+	"github.com/yourorg/tg-release-bot/internal/tracing"
 )
 
+var tracer = otel.Tracer("github.com/yourorg/tg-release-bot/cmd/bot")
+
 func main() {
 	// Setup logging
 	logger := logging.Setup()
+
+	// Maintenance subcommands run instead of the bot
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1:]))
+	}
+
 	logger.Info("Starting Telegram Release Bot")
 
 	// Load configuration
-	cfg, err := config.Load()
+	cfg, err := config.Load(configChecks)
 	if err != nil {
 		logger.Error("Failed to load configuration", "error", err)
 		os.Exit(1)
 	}
+	logger.Debug("Effective configuration", "config", cfg)
 
 	// Create context with cancellation
 	ctx, cancel := context.WithCancel(context.Background())
@@ -45,9 +64,33 @@ func main() {
 		cancel()
 	}()
 
+	// Optional tracing of release checks; spans still buffered are flushed on exit
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Options{
+		Exporter:    cfg.TracingExporter,
+		SampleRatio: cfg.TracingSampleRatio,
+	})
+	if err != nil {
+		logger.Error("Failed to set up tracing", "error", err)
+		os.Exit(1)
+	}
+	defer func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(flushCtx); err != nil {
+			logger.Warn("Failed to flush traces", "error", err)
+		}
+	}()
+	if cfg.TracingExporter != tracing.ExporterNone {
+		logger.Info("Tracing enabled", "exporter", cfg.TracingExporter, "sample_ratio", cfg.TracingSampleRatio)
+	}
+
 	// Initialize database
-	dbPath := getEnv("DB_PATH", "./releases.db")
-	database, err := db.Open(dbPath)
+	dsn, err := databaseDSN()
+	if err != nil {
+		logger.Error("Failed to read database settings", "error", err)
+		os.Exit(1)
+	}
+	database, err := db.Open(dsn)
 	if err != nil {
 		logger.Error("Failed to open database", "error", err)
 		os.Exit(1)
@@ -57,7 +100,9 @@ func main() {
 	store := db.NewStore(database)
 
 	// Initialize GitHub client
-	githubClient := github.New(cfg.GithubToken)
+	githubClient := github.New(cfg.GithubToken, github.Options{
+		RequestsPerSecond: cfg.GithubRequestsPerSecond,
+	})
 
 	// Initialize Telegram sender
 	telegramSender, err := telegram.NewSender(cfg.TelegramToken)
@@ -66,32 +111,168 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Load advisor prompt templates
+	prompts, err := advisor.NewPromptLibrary(promptStore{store}, cfg.AdvisorPromptsDir)
+	if err != nil {
+		logger.Error("Failed to load advisor prompts", "error", err)
+		os.Exit(1)
+	}
+
 	// Initialize LLM advisor (optional)
-	var advisorClient *advisor.Client
-	if cfg.AdvisorEnabled {
-		advisorClient = advisor.New(cfg.OpenRouterAPIKey, cfg.OpenRouterModel)
-		logger.Info("LLM advisor enabled", "model", cfg.OpenRouterModel)
+	advisorClient := newAdvisorClient(cfg, store, prompts)
+	if advisorClient != nil {
+		logger.Info("LLM advisor enabled", "models", advisorClient.Models())
 	}
 
+	// Settings replaced by configuration reloads
+	live := newLiveSettings(cfg, advisorClient)
+
 	// Create the main job and start scheduler
-	job := createReleaseCheckJob(logger, store, githubClient, telegramSender, advisorClient, cfg)
-	interval := time.Duration(cfg.IntervalMinutes) * time.Minute
-	releaseScheduler := scheduler.New(logger, interval, job)
+	job := createReleaseCheckJob(logger, store, githubClient, telegramSender, live)
+	interval, pollCron, err := pollSchedule(cfg)
+	if err != nil {
+		logger.Error("Invalid polling schedule", "error", err)
+		os.Exit(1)
+	}
+
+	releaseScheduler := scheduler.New(logger, interval, job, scheduler.Options{
+		Name:    "release_check",
+		Overlap: scheduler.OverlapPolicy(cfg.SchedulerOverlap),
+		Cron:    pollCron,
+		Jitter:  time.Duration(cfg.PollJitterSecs) * time.Second,
+		NextDue: func(ctx context.Context) time.Time {
+			due, err := store.NextDueAt(ctx)
+			if err != nil {
+				logger.Warn("Failed to get next due repository", "error", err)
+			}
+			return due
+		},
+	})
 	releaseScheduler.Start(ctx)
 
+	maintenanceLogger := logger.With("job", "maintenance")
+	maintenanceScheduler := scheduler.New(maintenanceLogger, time.Duration(max(cfg.MaintenanceHours, 1))*time.Hour,
+		createMaintenanceJob(maintenanceLogger, store, live), scheduler.Options{
+			Name:    "maintenance",
+			Overlap: scheduler.OverlapSkip,
+			// Restarts should not each vacuum the database
+			SkipInitialRun: true,
+		})
+	maintenanceScheduler.Start(ctx)
+
+	// Backups are SQLite snapshots; PostgreSQL is backed up with pg_dump
+	var botBackups telegram.Backups
+	var backupScheduler *scheduler.Scheduler
+	if database.Dialect() == db.DialectSQLite {
+		backupLogger := logger.With("job", "backup")
+		snapshots := &backups{logger: backupLogger, store: store, dir: backupDir(cfg.BackupDir), keep: cfg.BackupKeep}
+		botBackups = snapshots
+		if cfg.BackupHours > 0 {
+			backupScheduler = scheduler.New(backupLogger, time.Duration(cfg.BackupHours)*time.Hour,
+				createBackupJob(backupLogger, snapshots), scheduler.Options{Name: "backup", Overlap: scheduler.OverlapSkip})
+			backupScheduler.Start(ctx)
+		}
+	}
+
+	// Optional HTTP server for Prometheus metrics, health checks, the admin API
+	// and the dashboard
+	if cfg.HTTPAddr != "" {
+		checks := &health{
+			logger:   logger.With("component", "health"),
+			store:    store,
+			sender:   telegramSender,
+			github:   githubClient,
+			releases: releaseScheduler,
+			live:     live,
+			started:  time.Now(),
+			// NewSender already called getMe successfully
+			telegramAt: time.Now(),
+		}
+		mux := http.NewServeMux()
+		mux.Handle("GET /metrics", promhttp.Handler())
+		mux.HandleFunc("GET /healthz", checks.handleHealth)
+		mux.HandleFunc("GET /readyz", checks.handleReady)
+		mux.Handle(api.Prefix+"/", api.New(store, releaseScheduler, api.Options{
+			Token: func() string {
+				cfg, _ := live.Load()
+				return cfg.AdminAPIToken
+			},
+			ConfiguredSubscriptions: func(chatID int64) []string {
+				cfg, _ := live.Load()
+				chat, _ := cfg.ChatConfig(chatID)
+				return chat.Repositories
+			},
+			InternalSetting: func(key string) bool {
+				return key == appliedReposSetting
+			},
+		}, logger.With("component", "api")))
+		if cfg.DashboardEnabled {
+			jobs := []dashboard.Job{
+				{Name: "Release check", Status: releaseScheduler.Status},
+				{Name: "Maintenance", Status: maintenanceScheduler.Status},
+			}
+			if backupScheduler != nil {
+				jobs = append(jobs, dashboard.Job{Name: "Backup", Status: backupScheduler.Status})
+			}
+			mux.Handle("/", dashboard.New(store, dashboard.Options{
+				Config: func() *config.Config {
+					cfg, _ := live.Load()
+					return cfg
+				},
+				Jobs:      jobs,
+				RateLimit: githubClient.RateLimit,
+			}, logger.With("component", "dashboard")))
+		}
+		go serveHTTP(ctx, logger.With("component", "http"), cfg.HTTPAddr, mux)
+	}
+
+	reloads := &reloader{
+		logger:      logger.With("component", "reload"),
+		store:       store,
+		prompts:     prompts,
+		sender:      telegramSender,
+		live:        live,
+		releases:    releaseScheduler,
+		maintenance: maintenanceScheduler,
+	}
+
 	// Initialize bot for commands (optional)
-	var botCommands *telegram.Bot
 	if len(cfg.AllowedUserIDs) > 0 {
 		storeAdapter := telegram.NewStoreAdapter(store)
-		botCommands, err = telegram.NewBot(cfg.TelegramToken, storeAdapter, releaseScheduler, advisorClient, cfg.AllowedUserIDs, logger)
+		// The bot asks for the advisor per command so /reload reaches /testllm
+		botAdvisor := func() telegram.LLMAdvisor {
+			if _, advisorClient := live.Load(); advisorClient != nil {
+				return advisorClient
+			}
+			return nil
+		}
+		botCommands, err := telegram.NewBot(cfg.TelegramToken, storeAdapter, releaseScheduler, botAdvisor, telegram.NewPromptAdapter(prompts), botBackups, reloads, cfg.AllowedUserIDs, logger)
 		if err != nil {
 			logger.Error("Failed to create bot", "error", err)
 		} else {
+			reloads.bot = botCommands
 			logger.Info("Bot commands enabled", "allowed_users", cfg.AllowedUserIDs)
 			go botCommands.StartPolling(ctx)
 		}
 	}
 
+	// SIGHUP re-reads the configuration
+	go func() {
+		c := make(chan os.Signal, 1)
+		signal.Notify(c, syscall.SIGHUP)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-c:
+				logger.Info("Received SIGHUP, reloading configuration")
+				if _, err := reloads.Reload(ctx); err != nil {
+					logger.Error("Failed to reload configuration, keeping the current one", "error", err)
+				}
+			}
+		}
+	}()
+
 	// Add default chat if specified
 	if cfg.DefaultChatID != 0 {
 		err = store.AddChat(ctx, cfg.DefaultChatID, "Default Chat", "ru")
@@ -100,10 +281,14 @@ func main() {
 		}
 	}
 
-	// Initialize repositories from environment configuration
-	if err := initializeRepositories(ctx, logger, store, cfg); err != nil {
-		logger.Error("Failed to initialize repositories", "error", err)
+	initializeChats(ctx, logger, store, prompts, cfg)
+
+	// Bring tracked repositories in line with the configured list
+	if plan, err := syncRepositories(ctx, logger, store, cfg); err != nil {
+		logger.Error("Failed to sync repositories", "error", err)
 		// Don't exit - this is not critical, repositories can be added later via bot commands
+	} else {
+		notifyDryRun(ctx, logger, telegramSender, cfg, plan)
 	}
 
 	logger.Info("Bot started successfully",
@@ -116,74 +301,93 @@ func main() {
 	logger.Info("Shutting down...")
 
 	releaseScheduler.Stop()
+	maintenanceScheduler.Stop()
+	if backupScheduler != nil {
+		backupScheduler.Stop()
+	}
 	logger.Info("Bot stopped")
 }
 
 // createReleaseCheckJob creates the main job function for checking releases
 func createReleaseCheckJob(
 	logger *slog.Logger,
-	store *db.Store,
+	store db.Storage,
 	githubClient *github.Client,
 	telegramSender *telegram.Sender,
-	advisorClient *advisor.Client,
-	cfg *config.Config,
+	live *liveSettings,
 ) scheduler.Job {
-	return func(ctx context.Context) {
-		logger.Info("Starting release check job")
+	return func(ctx context.Context, run scheduler.Run) (err error) {
+		ctx, span := tracer.Start(ctx, "release check", trace.WithAttributes(attribute.Bool("manual", run.Manual)))
+		defer func() { tracing.End(span, err) }()
+		runLogger := tracing.Logger(ctx, logger)
 
-		// Get all tracked repositories
-		repos, err := store.ListRepositories(ctx)
+		runLogger.Info("Starting release check job", "manual", run.Manual)
+
+		// The whole run uses the settings in effect when it started
+		cfg, advisorClient := live.Load()
+
+		// A manual check covers all repositories, a scheduled one only those due
+		var repos []db.Repository
+		if run.Manual {
+			repos, err = store.ListRepositories(ctx)
+		} else {
+			repos, err = store.ListDueRepositories(ctx, time.Now())
+		}
 		if err != nil {
-			logger.Error("Failed to get repositories", "error", err)
-			return
+			return fmt.Errorf("failed to get repositories: %w", err)
 		}
 
 		if len(repos) == 0 {
-			logger.Info("No repositories to check")
-			return
+			runLogger.Info("No repositories due for a check")
+			return nil
 		}
 
-		logger.Info("Checking releases for repositories", "count", len(repos))
-
-		// Process repositories in batches to avoid rate limiting
-		batchSize := 20
-		for i := 0; i < len(repos); i += batchSize {
-			end := i + batchSize
-			if end > len(repos) {
-				end = len(repos)
-			}
-
-			batch := repos[i:end]
-			processBatch(ctx, logger, store, githubClient, telegramSender, advisorClient, cfg, batch)
+		span.SetAttributes(attribute.Int("repositories", len(repos)))
+		workers := max(cfg.Workers, 1)
+		runLogger.Info("Checking releases for repositories", "count", len(repos), "workers", workers)
+
+		// Each repository is handled by a single worker, so its releases are
+		// still processed oldest first; GitHub requests share the client's rate limiter
+		queue := make(chan db.Repository)
+		var wg sync.WaitGroup
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for repo := range queue {
+					processRepository(ctx, logger, store, githubClient, telegramSender, advisorClient, cfg, repo)
+				}
+			}()
+		}
 
-			// Small delay between batches
-			if end < len(repos) {
-				time.Sleep(1 * time.Second)
+	feed:
+		for _, repo := range repos {
+			select {
+			case queue <- repo:
+			case <-ctx.Done():
+				break feed
 			}
 		}
+		close(queue)
+		wg.Wait()
 
-		logger.Info("Release check job completed")
-	}
-}
+		if ctx.Err() != nil {
+			runLogger.Info("Release check job cancelled")
+			return ctx.Err()
+		}
 
-// processBatch processes a batch of repositories
-func processBatch(
-	ctx context.Context,
-	logger *slog.Logger,
-	store *db.Store,
-	githubClient *github.Client,
-	telegramSender *telegram.Sender,
-	advisorClient *advisor.Client,
-	cfg *config.Config,
-	repos []db.Repository,
-) {
-	for i, repo := range repos {
-		// Add small delay between requests to be nice to GitHub API
-		if i > 0 {
-			time.Sleep(200 * time.Millisecond)
+		for _, st := range advisorClient.Stats() {
+			runLogger.Info("Advisor model stats",
+				"model", st.Model,
+				"state", st.State,
+				"successes", st.Successes,
+				"failures", st.Failures,
+				"skipped", st.Skipped,
+				"last_error", st.LastError)
 		}
 
-		processRepository(ctx, logger, store, githubClient, telegramSender, advisorClient, cfg, repo)
+		runLogger.Info("Release check job completed")
+		return nil
 	}
 }
 
@@ -191,7 +395,7 @@ func processBatch(
 func processRepository(
 	ctx context.Context,
 	logger *slog.Logger,
-	store *db.Store,
+	store db.Storage,
 	githubClient *github.Client,
 	telegramSender *telegram.Sender,
 	advisorClient *advisor.Client,
@@ -199,7 +403,18 @@ func processRepository(
 	repo db.Repository,
 ) {
 	repoName := fmt.Sprintf("%s/%s", repo.Owner, repo.Name)
-	logger = logger.With("repo", repoName)
+	ctx, span := tracer.Start(ctx, "process repository", trace.WithAttributes(attribute.String("repo", repoName)))
+	defer span.End()
+	logger = tracing.Logger(ctx, logger.With("repo", repoName))
+
+	// Schedule the next check once this one is done; releases stay nil unless
+	// GitHub returned a fresh list
+	var releases []github.Release
+	defer func() {
+		if ctx.Err() == nil {
+			scheduleNextCheck(ctx, logger, store, cfg, repo, releases)
+		}
+	}()
 
 	// Get stored ETag
 	etag, err := store.GetETag(ctx, repo.Owner, repo.Name)
@@ -207,49 +422,92 @@ func processRepository(
 		logger.Warn("Failed to get ETag", "error", err)
 	}
 
-	// Fetch releases from GitHub
-	resp, err := githubClient.ListReleases(ctx, repo.Owner, repo.Name, etag)
+	// Fetch releases, or tags for repositories configured with the tags source
+	repoCfg, _ := cfg.RepositoryConfig(repo.Owner, repo.Name)
+	listReleases := githubClient.ListReleases
+	if repoCfg.Source == config.SourceTags {
+		listReleases = githubClient.ListTagReleases
+	}
+	resp, err := listReleases(ctx, repo.Owner, repo.Name, etag)
 	if err != nil {
+		if ctx.Err() != nil {
+			return
+		}
 		logger.Error("Failed to fetch releases", "error", err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to fetch releases")
 		return
 	}
 
 	// Handle 304 Not Modified
+	span.SetAttributes(attribute.Bool("not_modified", resp.StatusCode == 304))
 	if resp.StatusCode == 304 {
 		logger.Debug("No new releases (304 Not Modified)")
 		return
 	}
 
-	// Update ETag
-	if resp.ETag != "" {
-		if err := store.PutETag(ctx, repo.Owner, repo.Name, resp.ETag); err != nil {
-			logger.Warn("Failed to store ETag", "error", err)
+	// Filter and sort releases, keeping tags that pass the configured filters
+	releases = githubClient.FilterAndSortReleases(resp.Releases, repo.TrackPrereleases)
+	if len(repoCfg.Include) > 0 || len(repoCfg.Exclude) > 0 {
+		matching := releases[:0]
+		for _, release := range releases {
+			if repoCfg.MatchesTag(release.TagName) {
+				matching = append(matching, release)
+			}
 		}
+		releases = matching
 	}
 
-	// Filter and sort releases
-	releases := githubClient.FilterAndSortReleases(resp.Releases, repo.TrackPrereleases)
-
 	logger.Debug("Processed releases", "total", len(resp.Releases), "filtered", len(releases))
+	span.SetAttributes(attribute.Int("releases", len(releases)))
 
 	// Process each release (limit to recent releases to avoid spam)
 	now := time.Now()
 	cutoffDate := now.AddDate(0, 0, -cfg.MaxReleaseAgeDays)
-	
+
+	// The ETag is stored once every release is done; a release left for the
+	// next run would otherwise hide behind a 304 Not Modified
+	done := true
+	previousTag := ""
 	for _, release := range releases {
+		if ctx.Err() != nil {
+			return
+		}
+
+		prevTag := previousTag
+		previousTag = release.TagName
+
 		// Skip releases older than configured age to avoid processing too many old releases
 		if release.PublishedAt.Before(cutoffDate) {
 			releaseLogger := logger.With("release_id", release.ID, "tag", release.TagName)
 			releaseLogger.Debug("Skipping old release", "published_at", release.PublishedAt, "max_age_days", cfg.MaxReleaseAgeDays)
-			
-			// Mark old releases as processed to avoid future processing
-			if err := store.MarkProcessed(ctx, repo.Owner, repo.Name, release.ID, release.TagName, release.PublishedAt); err != nil {
-				releaseLogger.Warn("Failed to mark old release as processed", "error", err)
+
+			// Mark old releases as processed to avoid future processing, keeping
+			// the history of releases that were already recorded
+			processed, err := store.IsProcessed(ctx, repo.Owner, repo.Name, release.ID)
+			if err != nil {
+				releaseLogger.Warn("Failed to check if old release is processed", "error", err)
+				done = false
+				continue
+			}
+			if !processed {
+				if err := store.RecordRelease(ctx, releaseRecord(repo, release, plainReleaseMessage(cfg, repo, release))); err != nil {
+					releaseLogger.Warn("Failed to mark old release as processed", "error", err)
+					done = false
+				}
 			}
 			continue
 		}
-		
-		processRelease(ctx, logger, store, telegramSender, advisorClient, cfg, repo, release)
+
+		if !processRelease(ctx, logger, store, githubClient, telegramSender, advisorClient, cfg, repo, release, prevTag) {
+			done = false
+		}
+	}
+
+	if done && resp.ETag != "" && ctx.Err() == nil {
+		if err := store.PutETag(ctx, repo.Owner, repo.Name, resp.ETag); err != nil {
+			logger.Warn("Failed to store ETag", "error", err)
+		}
 	}
 }
 
@@ -278,112 +536,276 @@ func isPermanentTelegramError(err error) bool {
 	return false
 }
 
-// processRelease processes a single release
+// maxDeliveryAttempts is how many times a release is sent to a chat before
+// the chat is given up on
+const maxDeliveryAttempts = 5
+
+// deliveryLease is how long a claimed release-chat pair stays reserved
+// before another run may take it over. It covers composing the message,
+// which may call the advisor, and sending it.
+const deliveryLease = 5 * time.Minute
+
+// processRelease processes a single release and reports whether it is done.
+// Every subscribed chat gets the release once: a chat is claimed before the
+// message is sent, so overlapping jobs and restarts skip chats already
+// delivered or claimed elsewhere. The release stays unprocessed while any
+// subscribed chat is not delivered, so the next check retries chats that
+// failed, up to maxDeliveryAttempts, and chats still claimed by another job.
 func processRelease(
 	ctx context.Context,
 	logger *slog.Logger,
-	store *db.Store,
+	store db.Storage,
+	githubClient *github.Client,
 	telegramSender *telegram.Sender,
 	advisorClient *advisor.Client,
 	cfg *config.Config,
 	repo db.Repository,
 	release github.Release,
-) {
+	prevTag string,
+) bool {
+	ctx, span := tracer.Start(ctx, "process release", trace.WithAttributes(
+		attribute.String("repo", repo.Owner+"/"+repo.Name),
+		attribute.String("tag", release.TagName),
+		attribute.Int64("release_id", release.ID),
+	))
+	defer span.End()
 	releaseLogger := logger.With("release_id", release.ID, "tag", release.TagName)
 
 	// Check if already processed
 	processed, err := store.IsProcessed(ctx, repo.Owner, repo.Name, release.ID)
 	if err != nil {
 		releaseLogger.Error("Failed to check if release is processed", "error", err)
-		return
+		return false
 	}
 
 	if processed {
 		releaseLogger.Debug("Release already processed, skipping")
-		return
+		return true
 	}
 
 	releaseLogger.Info("Processing new release")
-
-	// Extract bullets from changelog
-	bullets := compose.TakeBullets(release.Body, cfg.MaxBullets, cfg.MaxChangelogChars)
-
-	// Get LLM advice if enabled
-	var advice string
-	if advisorClient != nil {
-		repoName := fmt.Sprintf("%s/%s", repo.Owner, repo.Name)
-		
-		// Create a timeout context for LLM requests to avoid blocking the whole process
-		llmCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
-		defer cancel()
-		
-		advice, err = advisorClient.Advise(llmCtx, repoName, release.TagName, bullets)
-		if err != nil {
-			if isLLMTimeoutError(err) {
-				releaseLogger.Debug("LLM request timed out, continuing without advice", "error", err)
-			} else {
-				releaseLogger.Warn("Failed to get LLM advice", "error", err)
-			}
-			// Continue without advice - don't fail the whole process
-		}
-	}
-
-	// Compose message
-	msg := compose.BuildHTML(compose.Input{
-		RepoFull:  fmt.Sprintf("%s/%s", repo.Owner, repo.Name),
-		Tag:       release.TagName,
-		URL:       release.HTMLURL,
-		BodyMD:    release.Body,
-		Published: release.PublishedAt,
-		Advisor:   advice,
-	}, compose.Options{
-		MaxBullets: cfg.MaxBullets,
-		MaxChars:   cfg.MaxChangelogChars,
-		TimeZone:   cfg.TimeZone,
-	})
+	releasesDetected.WithLabelValues(repo.Owner + "/" + repo.Name).Inc()
 
 	// Get all chats
 	chats, err := store.ListChats(ctx)
 	if err != nil {
 		releaseLogger.Error("Failed to get chats", "error", err)
-		return
+		return false
 	}
 
+	message := plainReleaseMessage(cfg, repo, release)
+	done := true
 	if len(chats) == 0 {
 		releaseLogger.Warn("No chats configured for notifications")
 		// Still mark as processed to avoid reprocessing
 	} else {
+		messages := newReleaseMessages(ctx, releaseLogger, store, githubClient, advisorClient, cfg, repo, release, prevTag)
+
 		// Send to all chats
 		for _, chat := range chats {
 			chatLogger := releaseLogger.With("chat_id", chat.ID)
+			chatCfg, _ := cfg.ChatConfig(chat.ID)
+			subscribed, err := chatSubscribed(ctx, store, chat.ID, chatCfg, repo.Owner+"/"+repo.Name)
+			if err != nil {
+				chatLogger.Error("Failed to get chat subscriptions", "error", err)
+				done = false
+				continue
+			}
+			if !subscribed {
+				chatLogger.Debug("Chat is not subscribed to repository, skipping")
+				continue
+			}
+
+			// Claim the release-chat pair before composing the message so
+			// overlapping runs and restarts neither send it twice nor pay
+			// for its advice twice
+			attempt, err := store.ClaimDelivery(ctx, repo.Owner, repo.Name, release.ID, chat.ID, deliveryLease, maxDeliveryAttempts)
+			if err != nil {
+				chatLogger.Error("Failed to claim delivery", "error", err)
+				done = false
+				continue
+			}
+			if attempt == 0 {
+				if !deliverySettled(ctx, chatLogger, store, repo, release, chat.ID) {
+					done = false
+				}
+				continue
+			}
+			text := messages.For(ctx, chat)
+
+			opts := telegram.SendOptions{Silent: chatCfg.Delivery == config.DeliverySilent}
+			sendErr := telegramSender.SendHTMLWith(ctx, chat.ID, text, opts)
+
+			// Record the outcome even when the job is being stopped: an
+			// unrecorded send leaves the claim pending and the chat would
+			// get the release again once the lease expires
+			outcomeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+			if sendErr != nil {
+				chatLogger.Error("Failed to send message", "attempt", attempt, "error", sendErr)
+				if markErr := store.MarkDeliveryFailed(outcomeCtx, repo.Owner, repo.Name, release.ID, chat.ID, attempt, sendErr); markErr != nil {
+					chatLogger.Error("Failed to record failed delivery", "error", markErr)
+				}
 
-			if err := telegramSender.SendHTML(ctx, chat.ID, msg); err != nil {
-				chatLogger.Error("Failed to send message", "error", err)
-				
 				// Handle permanent errors by removing invalid chats
-				if isPermanentTelegramError(err) {
-					chatLogger.Warn("Removing chat due to permanent error", "error", err)
-					if removeErr := store.RemoveChat(ctx, chat.ID); removeErr != nil {
+				removed := false
+				if isPermanentTelegramError(sendErr) {
+					chatLogger.Warn("Removing chat due to permanent error", "error", sendErr)
+					if removeErr := store.RemoveChat(outcomeCtx, chat.ID); removeErr != nil {
 						chatLogger.Error("Failed to remove invalid chat", "remove_error", removeErr)
 					} else {
+						removed = true
+						chatRemovals.Inc()
 						chatLogger.Info("Invalid chat removed from database")
 					}
 				}
+				if attempt >= maxDeliveryAttempts {
+					chatLogger.Error("Giving up on delivering release to chat", "attempts", attempt)
+				} else if !removed {
+					done = false
+				}
 			} else {
 				chatLogger.Info("Message sent successfully")
+				if markErr := store.MarkDelivered(outcomeCtx, repo.Owner, repo.Name, release.ID, chat.ID, attempt); markErr != nil {
+					chatLogger.Error("Failed to record delivery", "error", markErr)
+					done = false
+				}
 			}
+			cancel()
 
 			// Small delay between messages to different chats
-			time.Sleep(100 * time.Millisecond)
+			select {
+			case <-ctx.Done():
+			case <-time.After(100 * time.Millisecond):
+			}
 		}
+		message = messages.Archive()
+	}
+
+	// Leave the release unprocessed if the job was stopped mid-way; chats
+	// already delivered are skipped by their claims on the next run
+	if ctx.Err() != nil {
+		releaseLogger.Warn("Release processing interrupted, will resume on next run")
+		return false
+	}
+	if !done {
+		releaseLogger.Warn("Release not delivered to every chat, will retry on next run")
+		return false
 	}
 
-	// Mark as processed
-	if err := store.MarkProcessed(ctx, repo.Owner, repo.Name, release.ID, release.TagName, release.PublishedAt); err != nil {
+	// Mark as processed and keep the release in the history
+	if err := store.RecordRelease(ctx, releaseRecord(repo, release, message)); err != nil {
 		releaseLogger.Error("Failed to mark release as processed", "error", err)
+		return false
+	}
+	releaseLogger.Info("Release marked as processed")
+	return true
+}
+
+// deliverySettled reports whether a release-chat pair that could not be
+// claimed needs no more work: it was delivered, or it failed
+// maxDeliveryAttempts times. A pair claimed by another job is not settled.
+func deliverySettled(ctx context.Context, logger *slog.Logger, store db.Storage, repo db.Repository, release github.Release, chatID int64) bool {
+	d, ok, err := store.GetDelivery(ctx, repo.Owner, repo.Name, release.ID, chatID)
+	if err != nil {
+		logger.Error("Failed to get delivery state", "error", err)
+		return false
+	}
+	switch {
+	case !ok:
+		return false
+	case d.State == db.DeliveryDelivered:
+		logger.Debug("Release already delivered to chat, skipping")
+		return true
+	case d.State == db.DeliveryFailed:
+		logger.Debug("Release delivery to chat failed too often, skipping", "attempts", d.Attempts, "last_error", d.LastError)
+		return true
+	default:
+		logger.Debug("Release claimed for chat by another run, skipping")
+		return false
+	}
+}
+
+// chatSubscribed reports whether a chat receives releases of a repository.
+// Subscriptions from the config file and the admin API add up; a chat with
+// neither receives all repositories.
+func chatSubscribed(ctx context.Context, store db.Storage, chatID int64, chatCfg config.Chat, repo string) (bool, error) {
+	subs, err := store.ListSubscriptions(ctx, chatID)
+	if err != nil {
+		return false, err
+	}
+	if len(subs) == 0 {
+		return chatCfg.Subscribed(repo), nil
+	}
+	for _, sub := range subs {
+		if strings.EqualFold(sub.RepoOwner+"/"+sub.RepoName, repo) {
+			return true, nil
+		}
+	}
+	return len(chatCfg.Repositories) > 0 && chatCfg.Subscribed(repo), nil
+}
+
+// releaseRecord converts a release into its history record
+func releaseRecord(repo db.Repository, release github.Release, message string) db.ProcessedRelease {
+	return db.ProcessedRelease{
+		RepoOwner:   repo.Owner,
+		RepoName:    repo.Name,
+		ReleaseID:   release.ID,
+		TagName:     release.TagName,
+		Name:        release.Name,
+		Body:        release.Body,
+		URL:         release.HTMLURL,
+		Prerelease:  release.Prerelease,
+		Message:     message,
+		PublishedAt: release.PublishedAt,
+	}
+}
+
+// buildReleaseContext gathers advisor context for a release: the full body,
+// the repository description and commits/files changed since the previous tag.
+// GitHub failures only reduce the context.
+func buildReleaseContext(
+	ctx context.Context,
+	logger *slog.Logger,
+	githubClient *github.Client,
+	repo db.Repository,
+	release github.Release,
+	prevTag string,
+	bullets []string,
+) advisor.ReleaseContext {
+	rc := advisor.ReleaseContext{
+		Repo:        fmt.Sprintf("%s/%s", repo.Owner, repo.Name),
+		Tag:         release.TagName,
+		PreviousTag: prevTag,
+		Body:        release.Body,
+		Bullets:     bullets,
+	}
+
+	if meta, err := githubClient.GetRepository(ctx, repo.Owner, repo.Name); err != nil {
+		logger.Debug("Failed to fetch repository description", "error", err)
 	} else {
-		releaseLogger.Info("Release marked as processed")
+		rc.Description = meta.Description
+	}
+
+	if prevTag != "" {
+		if cmp, err := githubClient.CompareTags(ctx, repo.Owner, repo.Name, prevTag, release.TagName); err != nil {
+			logger.Debug("Failed to compare tags", "base", prevTag, "error", err)
+		} else {
+			rc.Commits = cmp.CommitTitles()
+			rc.ChangedFiles = cmp.FileNames()
+		}
+	}
+
+	return rc
+}
+
+// databaseDSN returns DATABASE_URL (or the file named by DATABASE_URL_FILE)
+// for PostgreSQL, or the SQLite DB_PATH
+func databaseDSN() (string, error) {
+	dsn, err := config.Getenv("DATABASE_URL")
+	if err != nil || dsn != "" {
+		return dsn, err
 	}
+	return getEnv("DB_PATH", "./releases.db"), nil
 }
 
 // getEnv returns environment variable or default value
@@ -394,32 +816,33 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
-// initializeRepositories adds repositories from environment configuration to the store
-func initializeRepositories(ctx context.Context, logger *slog.Logger, store *db.Store, cfg *config.Config) error {
-	if len(cfg.InitialRepositories) == 0 {
-		logger.Debug("No initial repositories configured in environment")
-		return nil
-	}
-
-	logger.Info("Initializing repositories from environment configuration", "count", len(cfg.InitialRepositories))
+// initializeChats adds chats from the config file to the store and assigns
+// their advisor prompts
+func initializeChats(ctx context.Context, logger *slog.Logger, store db.Storage, prompts *advisor.PromptLibrary, cfg *config.Config) {
+	for _, chat := range cfg.Chats {
+		chatLogger := logger.With("chat_id", chat.ID)
 
-	for _, repo := range cfg.InitialRepositories {
-		repoLogger := logger.With("repo", fmt.Sprintf("%s/%s", repo.Owner, repo.Name))
-		
-		err := store.AddRepository(ctx, repo.Owner, repo.Name, repo.TrackPrereleases)
-		if err != nil {
-			repoLogger.Warn("Failed to add repository from environment", "error", err)
+		if err := store.AddChat(ctx, chat.ID, chat.Title, chat.Language); err != nil {
+			chatLogger.Warn("Failed to add chat from configuration", "error", err)
 			continue
 		}
-		
-		prereText := ""
-		if repo.TrackPrereleases {
-			prereText = " (including prereleases)"
+		chatLogger.Info("Chat added from configuration")
+
+		if chat.Prompt != "" {
+			if err := prompts.Assign(ctx, advisor.ScopeChat, fmt.Sprint(chat.ID), chat.Prompt); err != nil {
+				chatLogger.Warn("Failed to assign advisor prompt", "prompt", chat.Prompt, "error", err)
+			}
 		}
-		repoLogger.Info("Repository added from environment configuration" + prereText)
 	}
+}
 
-	return nil
+// advisorPrices converts the configured price table to advisor prices
+func advisorPrices(prices map[string]config.ModelPrice) map[string]advisor.Price {
+	result := make(map[string]advisor.Price, len(prices))
+	for model, p := range prices {
+		result[model] = advisor.Price{Prompt: p.Prompt, Completion: p.Completion}
+	}
+	return result
 }
 
 // isLLMTimeoutError checks if an error is related to LLM timeout
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/yourorg/tg-release-bot/internal/db"
+	"github.com/yourorg/tg-release-bot/internal/scheduler"
+)
+
+// createMaintenanceJob creates the job that prunes old data and optimizes the database
+func createMaintenanceJob(logger *slog.Logger, store db.Storage, live *liveSettings) scheduler.Job {
+	return func(ctx context.Context, run scheduler.Run) error {
+		start := time.Now()
+		cfg, _ := live.Load()
+
+		if cfg.RetentionDays > 0 {
+			// A pruned release still listed by GitHub is only skipped as old,
+			// never re-sent, as long as retention exceeds the release age limit
+			days := max(cfg.RetentionDays, cfg.MaxReleaseAgeDays+1)
+			stats, err := store.Prune(ctx, start.AddDate(0, 0, -days), max(cfg.RetentionKeepPerRepo, 1))
+			if err != nil {
+				logger.Error("Failed to prune old data", "error", err)
+				return err
+			}
+			logger.Info("Pruned old data",
+				"retention_days", days,
+				"releases", stats.Releases,
+				"deliveries", stats.Deliveries,
+				"orphans", stats.Orphans)
+		}
+
+		stats, err := store.Optimize(ctx)
+		if err != nil {
+			logger.Error("Failed to optimize database", "error", err)
+			return err
+		}
+
+		logger.Info("Maintenance job completed",
+			"size_before_bytes", stats.SizeBefore,
+			"size_after_bytes", stats.SizeAfter,
+			"duration", time.Since(start))
+		return nil
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/yourorg/tg-release-bot/internal/advisor"
+	"github.com/yourorg/tg-release-bot/internal/compose"
+	"github.com/yourorg/tg-release-bot/internal/config"
+	"github.com/yourorg/tg-release-bot/internal/db"
+	"github.com/yourorg/tg-release-bot/internal/github"
+)
+
+// releaseMessages composes the notification for each chat. A chat gets an
+// upgrade-path message when it pinned an older version, and advice rendered
+// with the prompt assigned to it. Chats sharing a pinned version, prompt and
+// language share a message so the advisor is asked once for them.
+type releaseMessages struct {
+	logger        *slog.Logger
+	githubClient  *github.Client
+	advisorClient *advisor.Client
+	cfg           *config.Config
+	repo          db.Repository
+	release       github.Release
+	prevTag       string
+	bullets       []string
+
+	pins     map[int64]string
+	history  []github.Release
+	messages map[string]string
+	archive  string
+}
+
+func newReleaseMessages(
+	ctx context.Context,
+	logger *slog.Logger,
+	store db.Storage,
+	githubClient *github.Client,
+	advisorClient *advisor.Client,
+	cfg *config.Config,
+	repo db.Repository,
+	release github.Release,
+	prevTag string,
+) *releaseMessages {
+	m := &releaseMessages{
+		logger:        logger,
+		githubClient:  githubClient,
+		advisorClient: advisorClient,
+		cfg:           cfg,
+		repo:          repo,
+		release:       release,
+		prevTag:       prevTag,
+		bullets:       compose.TakeBullets(release.Body, cfg.MaxBullets, cfg.MaxChangelogChars),
+		pins:          make(map[int64]string),
+		messages:      make(map[string]string),
+	}
+
+	pins, err := store.ListRepoPins(ctx, repo.Owner, repo.Name)
+	if err != nil {
+		logger.Warn("Failed to get pinned versions", "error", err)
+		return m
+	}
+	for _, pin := range pins {
+		m.pins[pin.ChatID] = pin.Version
+	}
+	return m
+}
+
+// For returns the message for a chat
+func (m *releaseMessages) For(ctx context.Context, chat db.Chat) string {
+	pinned := m.pins[chat.ID]
+	if pinned != "" && github.CompareVersions(pinned, m.release.TagName) >= 0 {
+		pinned = ""
+	}
+
+	prompt := advisor.DefaultPrompt()
+	if m.advisorClient != nil {
+		var err error
+		prompt, err = m.advisorClient.Prompts().Resolve(ctx, chat.ID, m.repoName())
+		if err != nil {
+			m.logger.Warn("Failed to resolve advisor prompt, using default", "chat_id", chat.ID, "error", err)
+		}
+	}
+
+	timeZone := m.cfg.TimeZone
+	if chatCfg, ok := m.cfg.ChatConfig(chat.ID); ok && chatCfg.TimeZone != "" {
+		timeZone = chatCfg.TimeZone
+	}
+
+	key := strings.Join([]string{pinned, prompt.Name, prompt.Version(), chat.Language, timeZone}, "\x00")
+	if msg, ok := m.messages[key]; ok {
+		return msg
+	}
+
+	var msg string
+	if pinned != "" {
+		msg = m.buildUpgrade(ctx, pinned, prompt, chat.Language, timeZone)
+	} else {
+		msg = m.build(ctx, prompt, chat.Language, timeZone)
+		if m.archive == "" {
+			m.archive = msg
+		}
+	}
+	m.messages[key] = msg
+	return msg
+}
+
+// Archive returns the message kept in the release history: the first regular
+// message composed for a chat, or the message without advice when every chat
+// got an upgrade summary
+func (m *releaseMessages) Archive() string {
+	if m.archive != "" {
+		return m.archive
+	}
+	return plainReleaseMessage(m.cfg, m.repo, m.release)
+}
+
+// plainReleaseMessage composes the release message without advisor input
+func plainReleaseMessage(cfg *config.Config, repo db.Repository, release github.Release) string {
+	return compose.BuildHTML(compose.Input{
+		RepoFull:  fmt.Sprintf("%s/%s", repo.Owner, repo.Name),
+		Tag:       release.TagName,
+		URL:       release.HTMLURL,
+		BodyMD:    release.Body,
+		Published: release.PublishedAt,
+	}, compose.Options{
+		MaxBullets: cfg.MaxBullets,
+		MaxChars:   cfg.MaxChangelogChars,
+		TimeZone:   cfg.TimeZone,
+	})
+}
+
+func (m *releaseMessages) repoName() string {
+	return fmt.Sprintf("%s/%s", m.repo.Owner, m.repo.Name)
+}
+
+func (m *releaseMessages) options(timeZone string) compose.Options {
+	return compose.Options{
+		MaxBullets: m.cfg.MaxBullets,
+		MaxChars:   m.cfg.MaxChangelogChars,
+		TimeZone:   timeZone,
+	}
+}
+
+// build composes the regular release message
+func (m *releaseMessages) build(ctx context.Context, prompt advisor.Prompt, language, timeZone string) string {
+	advice := m.advise(ctx, m.logger, m.prevTag, prompt, language, nil)
+
+	return compose.BuildHTML(compose.Input{
+		RepoFull:  m.repoName(),
+		Tag:       m.release.TagName,
+		URL:       m.release.HTMLURL,
+		BodyMD:    m.release.Body,
+		Published: m.release.PublishedAt,
+		Advisor:   advice,
+	}, m.options(timeZone))
+}
+
+// buildUpgrade composes a message summarising all releases since the pinned version
+func (m *releaseMessages) buildUpgrade(ctx context.Context, pinned string, prompt advisor.Prompt, language, timeZone string) string {
+	logger := m.logger.With("pinned", pinned)
+
+	if m.history == nil {
+		releases, err := m.githubClient.ListRecentReleases(ctx, m.repo.Owner, m.repo.Name, 100)
+		if err != nil {
+			logger.Warn("Failed to fetch release history for upgrade path", "error", err)
+			m.history = []github.Release{}
+		} else {
+			m.history = m.githubClient.FilterAndSortReleases(releases, m.repo.TrackPrereleases)
+		}
+	}
+
+	path := github.ReleasesBetween(m.history, pinned, m.release.TagName)
+	if len(path) == 0 || path[len(path)-1].ID != m.release.ID {
+		path = append(path, m.release)
+	}
+
+	var upgrade []compose.UpgradeRelease
+	for _, r := range path {
+		upgrade = append(upgrade, compose.UpgradeRelease{Tag: r.TagName, URL: r.HTMLURL, BodyMD: r.Body})
+	}
+
+	advice := m.advise(ctx, logger, pinned, prompt, language, path)
+	logger.Info("Composed upgrade-path message", "releases", len(path))
+
+	return compose.BuildUpgradeHTML(compose.UpgradeInput{
+		RepoFull:  m.repoName(),
+		From:      pinned,
+		Releases:  upgrade,
+		Published: m.release.PublishedAt,
+		Advisor:   advice,
+	}, m.options(timeZone))
+}
+
+// advise asks the advisor about the release. When path is set the advice
+// covers the upgrade from prevTag across all releases of the path.
+func (m *releaseMessages) advise(
+	ctx context.Context,
+	logger *slog.Logger,
+	prevTag string,
+	prompt advisor.Prompt,
+	language string,
+	path []github.Release,
+) string {
+	if m.advisorClient == nil {
+		return ""
+	}
+
+	// The GitHub lookups get their own deadline so a slow API or a rate
+	// limit wait does not eat into the LLM budget
+	ghCtx, cancelGitHub := context.WithTimeout(ctx, 10*time.Second)
+	rc := buildReleaseContext(ghCtx, logger, m.githubClient, m.repo, m.release, prevTag, m.bullets)
+	cancelGitHub()
+	rc.Prompt = prompt
+	rc.Language = language
+	if path != nil {
+		var bodies []string
+		for _, r := range path {
+			bodies = append(bodies, fmt.Sprintf("## %s\n\n%s", r.TagName, r.Body))
+		}
+		rc.Upgrade = true
+		rc.Body = strings.Join(bodies, "\n\n")
+	}
+
+	// Create a timeout context for LLM requests to avoid blocking the whole process
+	llmCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	advice, err := m.advisorClient.AdviseRelease(llmCtx, rc)
+	if err != nil {
+		if errors.Is(err, advisor.ErrNoModelAvailable) {
+			logger.Debug("All advisor models are cooling down, continuing without advice")
+		} else if errors.Is(err, advisor.ErrBudgetExceeded) {
+			logger.Info("LLM budget exceeded, continuing without advice", "error", err)
+		} else if isLLMTimeoutError(err) {
+			logger.Debug("LLM request timed out, continuing without advice", "error", err)
+		} else {
+			logger.Warn("Failed to get LLM advice", "prompt", prompt.Name, "error", err)
+		}
+		// Continue without advice - don't fail the whole process
+	}
+	return advice
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourorg/tg-release-bot/internal/config"
+	"github.com/yourorg/tg-release-bot/internal/db"
+	"github.com/yourorg/tg-release-bot/internal/github"
+)
+
+func TestReleaseMessagesUpgradePath(t *testing.T) {
+	published := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+	release := func(id int64, tag string, prerelease bool) github.Release {
+		return github.Release{ID: id, TagName: tag, Prerelease: prerelease, HTMLURL: "https://example.com/" + tag, PublishedAt: published}
+	}
+	// history as FilterAndSortReleases returns it, newest first
+	history := []github.Release{
+		release(5, "v1.2.0", false),
+		release(4, "v1.2.0-rc.2", true),
+		release(3, "v1.2.0-rc.1", true),
+		release(2, "v1.1.0", false),
+		release(1, "v1.0.0", false),
+	}
+
+	tests := []struct {
+		name    string
+		release github.Release
+		pin     string
+		history []github.Release
+		want    []string // tags on the upgrade path, nil for a regular message
+	}{
+		{name: "no pin", release: history[0]},
+		{name: "pin equal to the release", release: history[0], pin: "v1.2.0"},
+		{name: "pin ahead of the release", release: history[0], pin: "v1.3.0"},
+		{name: "pin ahead of a prerelease", release: history[1], pin: "v1.2.0"},
+		{name: "pin behind", release: history[0], pin: "v1.0.0", want: []string{"v1.1.0", "v1.2.0-rc.1", "v1.2.0-rc.2", "v1.2.0"}},
+		{name: "pin on a prerelease", release: history[0], pin: "v1.2.0-rc.1", want: []string{"v1.2.0-rc.2", "v1.2.0"}},
+		{name: "pin on an untracked version", release: history[0], pin: "v1.1.5", want: []string{"v1.2.0-rc.1", "v1.2.0-rc.2", "v1.2.0"}},
+		{name: "prerelease after a pin", release: history[1], pin: "v1.1.0", want: []string{"v1.2.0-rc.1", "v1.2.0-rc.2"}},
+		{name: "release missing from the history", release: release(6, "v1.3.0", false), pin: "v1.2.0", history: []github.Release{}, want: []string{"v1.3.0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &releaseMessages{
+				logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+				cfg:      &config.Config{MaxBullets: 10, TimeZone: "UTC"},
+				repo:     db.Repository{Owner: "o", Name: "r"},
+				release:  tt.release,
+				pins:     map[int64]string{1: tt.pin},
+				history:  history,
+				messages: map[string]string{},
+			}
+			if tt.history != nil {
+				m.history = tt.history
+			}
+
+			got := m.For(context.Background(), db.Chat{ID: 1})
+			if tt.want == nil {
+				if !strings.HasPrefix(got, "🔥") {
+					t.Errorf("want a regular release message, got:\n%s", got)
+				}
+				return
+			}
+			if !strings.HasPrefix(got, "⬆️") || !strings.Contains(got, "<code>"+tt.pin+"</code> → ") {
+				t.Fatalf("want an upgrade message from %s, got:\n%s", tt.pin, got)
+			}
+			var links []string
+			for _, tag := range tt.want {
+				links = append(links, `<a href="https://example.com/`+tag+`">`+tag+"</a>")
+			}
+			if len(tt.want) > 1 && !strings.Contains(got, strings.Join(links, ", ")) {
+				t.Errorf("want the path %v, got:\n%s", tt.want, got)
+			}
+			if len(tt.want) == 1 && strings.Contains(got, "Релизов с вашей версии") {
+				t.Errorf("want a single release on the path, got:\n%s", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	releasesDetected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "release_bot",
+		Name:      "releases_detected_total",
+		Help:      "New releases detected by repository.",
+	}, []string{"repo"})
+
+	chatRemovals = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "release_bot",
+		Name:      "chat_removals_total",
+		Help:      "Chats removed after a permanent Telegram error.",
+	})
+)
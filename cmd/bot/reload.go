@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/yourorg/tg-release-bot/internal/advisor"
+	"github.com/yourorg/tg-release-bot/internal/config"
+	"github.com/yourorg/tg-release-bot/internal/db"
+	"github.com/yourorg/tg-release-bot/internal/scheduler"
+	"github.com/yourorg/tg-release-bot/internal/telegram"
+)
+
+// settings is the configuration in effect together with the advisor client built from it
+type settings struct {
+	cfg     *config.Config
+	advisor *advisor.Client
+}
+
+// liveSettings holds the settings that can be replaced at runtime. Jobs take a
+// snapshot when they start, so a reload never changes work in flight.
+type liveSettings struct {
+	current atomic.Pointer[settings]
+}
+
+func newLiveSettings(cfg *config.Config, advisorClient *advisor.Client) *liveSettings {
+	l := &liveSettings{}
+	l.Store(cfg, advisorClient)
+	return l
+}
+
+// Load returns the current configuration and advisor client
+func (l *liveSettings) Load() (*config.Config, *advisor.Client) {
+	s := l.current.Load()
+	return s.cfg, s.advisor
+}
+
+// Store replaces the configuration and advisor client at once
+func (l *liveSettings) Store(cfg *config.Config, advisorClient *advisor.Client) {
+	l.current.Store(&settings{cfg: cfg, advisor: advisorClient})
+}
+
+// advisorSettings are the settings the advisor client is built from; the
+// client, with its circuit breaker state, is only replaced when they change
+var advisorSettings = []string{
+	"AdvisorEnabled", "OpenRouterAPIKey", "OpenRouterModel", "AdvisorFallbacks",
+	"AdvisorFailures", "AdvisorCooldownSecs", "AdvisorTimeoutSecs", "AdvisorPrices",
+	"AdvisorDailyBudget", "AdvisorMonthBudget", "AdvisorContextToks",
+}
+
+// restartSettings only take effect after a restart
+var restartSettings = []string{
+	"GithubToken", "TelegramToken", "GithubRequestsPerSecond", "SchedulerOverlap",
+	"AdvisorPromptsDir", "BackupDir", "BackupHours", "BackupKeep", "HTTPAddr",
+	"DashboardEnabled", "TracingExporter", "TracingSampleRatio",
+}
+
+// reloader re-reads the configuration on SIGHUP or /reload and applies it
+type reloader struct {
+	logger      *slog.Logger
+	store       db.Storage
+	prompts     *advisor.PromptLibrary
+	sender      *telegram.Sender
+	live        *liveSettings
+	releases    *scheduler.Scheduler
+	maintenance *scheduler.Scheduler
+	bot         *telegram.Bot // nil when commands are disabled
+
+	mu sync.Mutex
+}
+
+// Reload loads and validates the configuration and applies it to the
+// schedulers, message composition, allowed users and advisor. An invalid
+// configuration is rejected and the current one stays in effect.
+func (r *reloader) Reload(ctx context.Context) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cfg, err := config.Load(configChecks)
+	if err != nil {
+		return nil, err
+	}
+
+	current, advisorClient := r.live.Load()
+	changed := current.Changed(cfg)
+	if len(changed) == 0 {
+		r.logger.Info("Configuration reloaded, nothing changed")
+		return nil, nil
+	}
+
+	interval, pollCron, err := pollSchedule(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var restart []string
+	for _, name := range changed {
+		if slices.Contains(restartSettings, name) {
+			restart = append(restart, name)
+		}
+	}
+	if r.bot == nil && slices.Contains(changed, "AllowedUserIDs") {
+		restart = append(restart, "AllowedUserIDs")
+	}
+
+	if slices.ContainsFunc(changed, func(name string) bool { return slices.Contains(advisorSettings, name) }) {
+		advisorClient = newAdvisorClient(cfg, r.store, r.prompts)
+		if advisorClient != nil {
+			r.logger.Info("LLM advisor reconfigured", "models", advisorClient.Models())
+		} else {
+			r.logger.Info("LLM advisor disabled")
+		}
+	}
+
+	r.live.Store(cfg, advisorClient)
+	r.releases.Reschedule(interval, pollCron, time.Duration(cfg.PollJitterSecs)*time.Second)
+	r.maintenance.Reschedule(time.Duration(cfg.MaintenanceHours)*time.Hour, nil, 0)
+	if r.bot != nil {
+		r.bot.SetAllowedUsers(cfg.AllowedUserIDs)
+	}
+
+	initializeChats(ctx, r.logger, r.store, r.prompts, cfg)
+	if plan, err := syncRepositories(ctx, r.logger, r.store, cfg); err != nil {
+		r.logger.Warn("Failed to sync repositories", "error", err)
+	} else {
+		notifyDryRun(ctx, r.logger, r.sender, cfg, plan)
+	}
+
+	r.logger.Info("Configuration reloaded", "changed", changed)
+	if len(restart) > 0 {
+		r.logger.Warn("Some changed settings only take effect after a restart", "settings", restart)
+	}
+	return changed, nil
+}
+
+// Settings returns the effective configuration with secrets redacted
+func (r *reloader) Settings() []telegram.ConfigSetting {
+	cfg, _ := r.live.Load()
+	var settings []telegram.ConfigSetting
+	for _, s := range cfg.Settings() {
+		settings = append(settings, telegram.ConfigSetting{Name: s.Name, Value: s.Value})
+	}
+	return settings
+}
+
+// pollSchedule returns the release scheduler's interval and cron schedule.
+// With adaptive polling the scheduler wakes up when the next repository is
+// due, and at least once per maximum interval.
+func pollSchedule(cfg *config.Config) (time.Duration, *scheduler.CronSchedule, error) {
+	interval := time.Duration(cfg.IntervalMinutes) * time.Minute
+	if cfg.AdaptivePolling {
+		interval = time.Duration(cfg.MaxIntervalMinutes) * time.Minute
+	}
+	if cfg.PollCron == "" {
+		return interval, nil, nil
+	}
+
+	loc, err := time.LoadLocation(cfg.TimeZone)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to load time zone for POLL_CRON: %w", err)
+	}
+	pollCron, err := scheduler.ParseCron(cfg.PollCron, loc)
+	if err != nil {
+		return 0, nil, fmt.Errorf("invalid POLL_CRON: %w", err)
+	}
+	return interval, pollCron, nil
+}
+
+// configChecks validates the settings parsed by runtime packages
+var configChecks = config.Checks{
+	Cron: func(expr string, loc *time.Location) error {
+		_, err := scheduler.ParseCron(expr, loc)
+		return err
+	},
+}
+
+// newAdvisorClient creates the LLM advisor, or returns nil when it is disabled
+func newAdvisorClient(cfg *config.Config, store db.Storage, prompts *advisor.PromptLibrary) *advisor.Client {
+	if !cfg.AdvisorEnabled {
+		return nil
+	}
+	return advisor.New(cfg.OpenRouterAPIKey, cfg.OpenRouterModel, advisor.Options{
+		FallbackModels:   cfg.AdvisorFallbacks,
+		FailureThreshold: cfg.AdvisorFailures,
+		Cooldown:         time.Duration(cfg.AdvisorCooldownSecs) * time.Second,
+		AttemptTimeout:   time.Duration(cfg.AdvisorTimeoutSecs) * time.Second,
+		Prices:           advisorPrices(cfg.AdvisorPrices),
+		DailyBudget:      cfg.AdvisorDailyBudget,
+		MonthlyBudget:    cfg.AdvisorMonthBudget,
+		Usage:            usageStore{store},
+		ContextTokens:    cfg.AdvisorContextToks,
+		Prompts:          prompts,
+	})
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yourorg/tg-release-bot/internal/config"
+	"github.com/yourorg/tg-release-bot/internal/db"
+	"github.com/yourorg/tg-release-bot/internal/telegram"
+)
+
+// appliedReposSetting stores the configured repository list applied last, the
+// base a merge compares the configuration with to tell configuration changes
+// from chat-command changes
+const appliedReposSetting = "repositories.applied"
+
+// repoState is the tracked state of a repository that the configuration controls
+type repoState struct {
+	Owner       string        `json:"owner"`
+	Name        string        `json:"name"`
+	Prereleases bool          `json:"prereleases"`
+	Interval    time.Duration `json:"interval"` // polling interval override, 0 for the default
+}
+
+func (r repoState) fullName() string {
+	return r.Owner + "/" + r.Name
+}
+
+func (r repoState) key() string {
+	return strings.ToLower(r.fullName())
+}
+
+// sameSettings compares the flags and interval, ignoring the name's case
+func (r repoState) sameSettings(o repoState) bool {
+	return r.Prereleases == o.Prereleases && r.Interval == o.Interval
+}
+
+func (r repoState) describe() string {
+	var flags []string
+	if r.Prereleases {
+		flags = append(flags, "prereleases")
+	}
+	if r.Interval > 0 {
+		flags = append(flags, "every "+r.Interval.String())
+	}
+	if len(flags) == 0 {
+		return r.fullName()
+	}
+	return fmt.Sprintf("%s (%s)", r.fullName(), strings.Join(flags, ", "))
+}
+
+// repoPlan lists the changes that bring the tracked repositories in line with
+// the configuration
+type repoPlan struct {
+	Add    []repoState
+	Update []repoState
+	Remove []repoState
+}
+
+func (p repoPlan) empty() bool {
+	return len(p.Add) == 0 && len(p.Update) == 0 && len(p.Remove) == 0
+}
+
+// String formats the plan as a diff, one repository per line
+func (p repoPlan) String() string {
+	var lines []string
+	for _, r := range p.Add {
+		lines = append(lines, "+ "+r.describe())
+	}
+	for _, r := range p.Update {
+		lines = append(lines, "~ "+r.describe())
+	}
+	for _, r := range p.Remove {
+		lines = append(lines, "- "+r.fullName())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// planRepositories compares the configured repositories with the tracked ones.
+// In sync mode the configuration is the source of truth. In merge mode only
+// what changed in the configuration since the last applied list is applied:
+// new entries are added unless already tracked, changed entries are updated
+// unless removed with a chat command, and dropped entries are kept.
+func planRepositories(mode string, configured []config.Repository, current, applied map[string]repoState) repoPlan {
+	var plan repoPlan
+	listed := make(map[string]bool)
+
+	for _, r := range configured {
+		want := configuredState(r)
+		listed[want.key()] = true
+		cur, tracked := current[want.key()]
+
+		if mode == config.RepositorySyncExact {
+			switch {
+			case !tracked:
+				plan.Add = append(plan.Add, want)
+			case !cur.sameSettings(want):
+				plan.Update = append(plan.Update, want)
+			}
+			continue
+		}
+
+		base, known := applied[want.key()]
+		switch {
+		case !known && !tracked:
+			plan.Add = append(plan.Add, want)
+		case known && tracked && !base.sameSettings(want) && !cur.sameSettings(want):
+			plan.Update = append(plan.Update, want)
+		}
+	}
+
+	if mode == config.RepositorySyncExact {
+		for key, cur := range current {
+			if !listed[key] {
+				plan.Remove = append(plan.Remove, cur)
+			}
+		}
+		sort.Slice(plan.Remove, func(i, j int) bool { return plan.Remove[i].key() < plan.Remove[j].key() })
+	}
+	return plan
+}
+
+func configuredState(r config.Repository) repoState {
+	return repoState{
+		Owner:       r.Owner,
+		Name:        r.Name,
+		Prereleases: r.TrackPrereleases,
+		Interval:    time.Duration(r.IntervalMinutes) * time.Minute,
+	}
+}
+
+// syncRepositories applies the configured repositories to the store according
+// to the repository sync mode. In dry-run mode the plan is only returned.
+func syncRepositories(ctx context.Context, logger *slog.Logger, store db.Storage, cfg *config.Config) (repoPlan, error) {
+	current, err := trackedRepositories(ctx, store)
+	if err != nil {
+		return repoPlan{}, err
+	}
+	applied, err := appliedRepositories(ctx, store)
+	if err != nil {
+		return repoPlan{}, err
+	}
+
+	plan := planRepositories(cfg.RepositorySync, cfg.InitialRepositories, current, applied)
+	// An empty list in sync mode is far more likely a missing configuration
+	// file or variable than a wish to drop every repository with its history
+	removesAll := len(cfg.InitialRepositories) == 0 && len(plan.Remove) > 0
+	if cfg.RepositorySyncDryRun {
+		if removesAll {
+			logger.Warn("Repository sync would remove every tracked repository, no repositories are configured",
+				"remove", len(plan.Remove))
+		}
+		logger.Info("Repository sync dry run, not applied", "mode", cfg.RepositorySync,
+			"add", len(plan.Add), "update", len(plan.Update), "remove", len(plan.Remove), "diff", plan.String())
+		return plan, nil
+	}
+	if removesAll {
+		return repoPlan{}, fmt.Errorf("refusing to remove all %d tracked repositories: no repositories are configured in %s mode; configure them or check the plan with REPOSITORY_SYNC_DRY_RUN=1",
+			len(plan.Remove), cfg.RepositorySync)
+	}
+	if plan.empty() {
+		logger.Debug("Tracked repositories match the configuration", "mode", cfg.RepositorySync)
+	}
+
+	for _, r := range plan.Add {
+		if err := applyRepository(ctx, store, r); err != nil {
+			logger.Warn("Failed to add repository from configuration", "repo", r.fullName(), "error", err)
+			continue
+		}
+		logger.Info("Repository added from configuration", "repo", r.describe())
+	}
+	for _, r := range plan.Update {
+		if err := applyRepository(ctx, store, r); err != nil {
+			logger.Warn("Failed to update repository from configuration", "repo", r.fullName(), "error", err)
+			continue
+		}
+		logger.Info("Repository updated from configuration", "repo", r.describe())
+	}
+	for _, r := range plan.Remove {
+		if err := store.RemoveRepository(ctx, r.Owner, r.Name); err != nil {
+			logger.Warn("Failed to remove repository missing from configuration", "repo", r.fullName(), "error", err)
+			continue
+		}
+		logger.Info("Repository removed, it is not in the configuration", "repo", r.fullName())
+	}
+
+	// Remember what was applied so the next merge sees only configuration changes
+	var states []repoState
+	for _, r := range cfg.InitialRepositories {
+		states = append(states, configuredState(r))
+	}
+	data, err := json.Marshal(states)
+	if err != nil {
+		return plan, err
+	}
+	if err := store.SetSetting(ctx, appliedReposSetting, string(data)); err != nil {
+		return plan, fmt.Errorf("failed to save applied repositories: %w", err)
+	}
+	return plan, nil
+}
+
+// applyRepository tracks a repository with the given flags and interval override
+func applyRepository(ctx context.Context, store db.Storage, r repoState) error {
+	if err := store.AddRepository(ctx, r.Owner, r.Name, r.Prereleases); err != nil {
+		return err
+	}
+	sch, err := store.GetRepoSchedule(ctx, r.Owner, r.Name)
+	if err != nil {
+		return err
+	}
+	if sch.IntervalOverride != r.Interval {
+		return store.SetRepoInterval(ctx, r.Owner, r.Name, r.Interval)
+	}
+	return nil
+}
+
+// trackedRepositories returns the tracked repositories with their interval overrides
+func trackedRepositories(ctx context.Context, store db.Storage) (map[string]repoState, error) {
+	repos, err := store.ListRepositories(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list repositories: %w", err)
+	}
+
+	current := make(map[string]repoState, len(repos))
+	for _, repo := range repos {
+		sch, err := store.GetRepoSchedule(ctx, repo.Owner, repo.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get schedule of %s/%s: %w", repo.Owner, repo.Name, err)
+		}
+		r := repoState{Owner: repo.Owner, Name: repo.Name, Prereleases: repo.TrackPrereleases, Interval: sch.IntervalOverride}
+		current[r.key()] = r
+	}
+	return current, nil
+}
+
+// appliedRepositories returns the configured list applied last, empty before the first sync
+func appliedRepositories(ctx context.Context, store db.Storage) (map[string]repoState, error) {
+	value, err := store.GetSetting(ctx, appliedReposSetting)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get applied repositories: %w", err)
+	}
+
+	applied := make(map[string]repoState)
+	if value == "" {
+		return applied, nil
+	}
+	var states []repoState
+	if err := json.Unmarshal([]byte(value), &states); err != nil {
+		return nil, fmt.Errorf("failed to parse applied repositories: %w", err)
+	}
+	for _, r := range states {
+		applied[r.key()] = r
+	}
+	return applied, nil
+}
+
+// notifyDryRun sends a repository sync dry-run plan to the admins privately
+func notifyDryRun(ctx context.Context, logger *slog.Logger, sender *telegram.Sender, cfg *config.Config, plan repoPlan) {
+	if !cfg.RepositorySyncDryRun || plan.empty() {
+		return
+	}
+
+	text := fmt.Sprintf("🧪 <b>Repository sync dry run</b> (%s mode), not applied:\n<pre>%s</pre>",
+		cfg.RepositorySync, html.EscapeString(plan.String()))
+	for _, userID := range cfg.AllowedUserIDs {
+		if err := sender.SendHTML(ctx, userID, text); err != nil {
+			logger.Debug("Failed to send repository sync plan to admin", "user_id", userID, "error", err)
+		}
+	}
+}
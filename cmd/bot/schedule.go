@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/yourorg/tg-release-bot/internal/config"
+	"github.com/yourorg/tg-release-bot/internal/db"
+	"github.com/yourorg/tg-release-bot/internal/github"
+	"github.com/yourorg/tg-release-bot/internal/scheduler"
+)
+
+// pollBounds returns the adaptive polling limits from configuration
+func pollBounds(cfg *config.Config) scheduler.PollBounds {
+	return scheduler.PollBounds{
+		Min: time.Duration(cfg.IntervalMinutes) * time.Minute,
+		Max: time.Duration(cfg.MaxIntervalMinutes) * time.Minute,
+	}
+}
+
+// scheduleNextCheck records when a repository should be polled next. Releases
+// are those observed in this check (nil when GitHub returned 304 or failed),
+// used to refresh the repository's release cadence.
+func scheduleNextCheck(
+	ctx context.Context,
+	logger *slog.Logger,
+	store db.Storage,
+	cfg *config.Config,
+	repo db.Repository,
+	releases []github.Release,
+) {
+	sch, err := store.GetRepoSchedule(ctx, repo.Owner, repo.Name)
+	if err != nil {
+		logger.Warn("Failed to get repository schedule", "error", err)
+		return
+	}
+
+	if len(releases) > 0 {
+		published := make([]time.Time, 0, len(releases))
+		for _, r := range releases {
+			published = append(published, r.PublishedAt)
+			if r.PublishedAt.After(sch.LastReleaseAt) {
+				sch.LastReleaseAt = r.PublishedAt
+			}
+		}
+		if cadence := scheduler.EstimateCadence(published); cadence > 0 {
+			sch.Cadence = cadence
+		}
+	}
+
+	now := time.Now()
+	sch.LastCheckedAt = now
+	if cfg.AdaptivePolling {
+		sch.NextCheckAt = scheduler.NextCheck(now, sch.LastReleaseAt, sch.Cadence, sch.IntervalOverride, pollBounds(cfg))
+	} else {
+		sch.NextCheckAt = scheduler.NextCheck(now, time.Time{}, 0, sch.IntervalOverride, pollBounds(cfg))
+	}
+
+	if err := store.SaveRepoSchedule(ctx, sch); err != nil {
+		logger.Warn("Failed to save repository schedule", "error", err)
+		return
+	}
+	logger.Debug("Scheduled next check", "next_check_at", sch.NextCheckAt, "cadence", sch.Cadence)
+}
@@ -0,0 +1,120 @@
+package advisor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestBreakerOpensAfterThreshold(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	b := newBreaker(2, time.Minute)
+
+	b.Failure(now)
+	if !b.Allow(now) {
+		t.Fatal("breaker opened before reaching the threshold")
+	}
+	if state, _ := b.State(now); state != StateClosed {
+		t.Fatalf("state = %s, want %s", state, StateClosed)
+	}
+
+	b.Failure(now)
+	if b.Allow(now.Add(30 * time.Second)) {
+		t.Fatal("open breaker allowed a request during the cooldown")
+	}
+	state, until := b.State(now.Add(30 * time.Second))
+	if state != StateOpen || !until.Equal(now.Add(time.Minute)) {
+		t.Fatalf("state = %s until %v, want %s until %v", state, until, StateOpen, now.Add(time.Minute))
+	}
+}
+
+func TestBreakerHalfOpenAllowsSingleProbe(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	b := newBreaker(1, time.Minute)
+	b.Failure(now)
+
+	later := now.Add(time.Minute)
+	if state, _ := b.State(later); state != StateHalfOpen {
+		t.Fatalf("state = %s, want %s", state, StateHalfOpen)
+	}
+	if !b.Allow(later) {
+		t.Fatal("half-open breaker refused the probe")
+	}
+	if b.Allow(later) {
+		t.Fatal("half-open breaker allowed a second concurrent probe")
+	}
+}
+
+func TestBreakerProbeOutcome(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	later := now.Add(time.Minute)
+
+	tests := []struct {
+		name      string
+		finish    func(b *breaker)
+		wantState string
+		wantAllow bool
+	}{
+		{
+			name:      "success closes",
+			finish:    func(b *breaker) { b.Success() },
+			wantState: StateClosed,
+			wantAllow: true,
+		},
+		{
+			name:      "failure reopens",
+			finish:    func(b *breaker) { b.Failure(later) },
+			wantState: StateOpen,
+			wantAllow: false,
+		},
+		{
+			name:      "cancel releases the probe",
+			finish:    func(b *breaker) { b.Cancel() },
+			wantState: StateHalfOpen,
+			wantAllow: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBreaker(1, time.Minute)
+			b.Failure(now)
+			if !b.Allow(later) {
+				t.Fatal("half-open breaker refused the probe")
+			}
+			tt.finish(b)
+
+			if state, _ := b.State(later); state != tt.wantState {
+				t.Errorf("state = %s, want %s", state, tt.wantState)
+			}
+			if got := b.Allow(later); got != tt.wantAllow {
+				t.Errorf("Allow = %v, want %v", got, tt.wantAllow)
+			}
+		})
+	}
+}
+
+func TestCompleteReleasesProbeOnCancel(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	c := New("key", "model-a", Options{FailureThreshold: 1, Cooldown: time.Millisecond, AttemptTimeout: time.Minute})
+	c.baseURL = srv.URL
+	b := c.breakers["model-a"]
+	b.Failure(time.Now().Add(-time.Second))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := c.complete(ctx, "o/r", "v1", []Message{{Role: "user", Content: "hi"}}, 10); err == nil {
+		t.Fatal("complete succeeded after the caller's context expired")
+	}
+
+	if !b.Allow(time.Now()) {
+		t.Fatal("probe stayed taken after the caller cancelled it")
+	}
+}
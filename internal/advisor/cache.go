@@ -0,0 +1,74 @@
+package advisor
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// adviceCache keeps recent advice so chats sharing a prompt do not pay twice
+type adviceCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	max     int
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	advice  string
+	expires time.Time
+}
+
+func newAdviceCache(ttl time.Duration, max int) *adviceCache {
+	return &adviceCache{ttl: ttl, max: max, entries: make(map[string]cacheEntry)}
+}
+
+// cacheKey identifies advice by release, upgrade path, language and prompt version
+func cacheKey(rc ReleaseContext, prompt Prompt) string {
+	return strings.Join([]string{
+		rc.Repo,
+		rc.Tag,
+		rc.PreviousTag,
+		boolKey(rc.Upgrade),
+		rc.Language,
+		prompt.Name,
+		prompt.Version(),
+	}, "\x00")
+}
+
+func boolKey(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+func (c *adviceCache) get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok || time.Now().After(e.expires) {
+		return "", false
+	}
+	return e.advice, true
+}
+
+func (c *adviceCache) put(key, advice string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if len(c.entries) >= c.max {
+		for k, e := range c.entries {
+			if now.After(e.expires) {
+				delete(c.entries, k)
+			}
+		}
+		// Still full - drop everything rather than track recency
+		if len(c.entries) >= c.max {
+			c.entries = make(map[string]cacheEntry)
+		}
+	}
+	c.entries[key] = cacheEntry{advice: advice, expires: now.Add(c.ttl)}
+}
@@ -0,0 +1,194 @@
+package advisor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// charsPerToken is a rough estimate used to budget prompt size without a tokenizer
+const charsPerToken = 4
+
+// maxReduceChunks bounds how many map calls a long release body may cost
+const maxReduceChunks = 4
+
+// ReleaseContext is everything the advisor knows about a release
+type ReleaseContext struct {
+	Repo         string
+	Tag          string
+	PreviousTag  string
+	Upgrade      bool     // PreviousTag is the version the team runs, Body covers every release since
+	Description  string   // repository description
+	Body         string   // full release notes (markdown)
+	Bullets      []string // extracted changelog bullets, used when Body is empty
+	Commits      []string // commit titles between PreviousTag and Tag
+	ChangedFiles []string // files changed between PreviousTag and Tag
+
+	Prompt   Prompt // zero value means the default prompt
+	Language string // chat language passed to prompt templates
+}
+
+// BuildPrompt assembles the user prompt for a release within a token budget.
+// The release body gets the largest share of the budget; commit titles and
+// changed files are truncated to their own shares.
+func BuildPrompt(rc ReleaseContext, maxTokens int) string {
+	budget := maxTokens * charsPerToken
+	bodyBudget := budget * 6 / 10
+	commitsBudget := budget * 25 / 100
+	filesBudget := budget - bodyBudget - commitsBudget
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Релиз: %s %s\n", rc.Repo, rc.Tag))
+	if rc.Upgrade {
+		sb.WriteString(fmt.Sprintf("Команда сейчас использует: %s. Ниже release notes всех версий после неё.\n", rc.PreviousTag))
+	} else if rc.PreviousTag != "" {
+		sb.WriteString(fmt.Sprintf("Предыдущая версия: %s\n", rc.PreviousTag))
+	}
+	if rc.Description != "" {
+		sb.WriteString(fmt.Sprintf("О проекте: %s\n", rc.Description))
+	}
+
+	body := strings.TrimSpace(rc.Body)
+	if body == "" {
+		body = strings.Join(rc.Bullets, "; ")
+	}
+	sb.WriteString("\nИзменения:\n")
+	sb.WriteString(truncate(body, bodyBudget))
+	sb.WriteString("\n")
+
+	if len(rc.Commits) > 0 {
+		sb.WriteString(fmt.Sprintf("\nКоммиты с %s:\n", rc.PreviousTag))
+		sb.WriteString(listWithin(rc.Commits, commitsBudget))
+	}
+	if len(rc.ChangedFiles) > 0 {
+		sb.WriteString("\nИзменённые файлы:\n")
+		sb.WriteString(listWithin(rc.ChangedFiles, filesBudget))
+	}
+
+	if rc.Upgrade {
+		sb.WriteString(fmt.Sprintf("\nСведи вместе breaking changes и обязательные шаги миграции для обновления с %s до %s.", rc.PreviousTag, rc.Tag))
+	}
+	sb.WriteString("\nПроанализируй что важно для DevOps/разработчиков. Опирайся только на данные выше. Следуй СТРОГО формату из system prompt.")
+	return sb.String()
+}
+
+// SplitChunks splits text into chunks of at most maxChars, preferring paragraph boundaries
+func SplitChunks(text string, maxChars int) []string {
+	if maxChars <= 0 || len(text) <= maxChars {
+		return []string{text}
+	}
+
+	var chunks []string
+	var current strings.Builder
+	for _, para := range strings.SplitAfter(text, "\n\n") {
+		for len(para) > maxChars {
+			if current.Len() > 0 {
+				chunks = append(chunks, current.String())
+				current.Reset()
+			}
+			cut := strings.LastIndex(para[:maxChars], "\n")
+			if cut <= 0 {
+				cut = runeBoundary(para, maxChars)
+			}
+			chunks = append(chunks, para[:cut])
+			para = para[cut:]
+		}
+		if current.Len()+len(para) > maxChars {
+			chunks = append(chunks, current.String())
+			current.Reset()
+		}
+		current.WriteString(para)
+	}
+	if current.Len() > 0 {
+		chunks = append(chunks, current.String())
+	}
+	return chunks
+}
+
+// runeBoundary returns the largest rune start in s at or before n, or the
+// first one after n when a single rune is longer than n
+func runeBoundary(s string, n int) int {
+	cut := n
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	if cut > 0 {
+		return cut
+	}
+	for cut = n; cut < len(s) && !utf8.RuneStart(s[cut]); cut++ {
+	}
+	return cut
+}
+
+// reduceBody summarises a release body that does not fit the body budget.
+// Chunks are summarised concurrently and joined in their original order.
+// Chunks beyond maxReduceChunks are not summarised; a marker tells the model
+// that the notes were cut.
+func (c *Client) reduceBody(ctx context.Context, rc ReleaseContext, maxTokens int) (string, error) {
+	bodyBudget := maxTokens * charsPerToken * 6 / 10
+	if len(rc.Body) <= bodyBudget {
+		return rc.Body, nil
+	}
+
+	chunks := SplitChunks(rc.Body, bodyBudget)
+	dropped := 0
+	if len(chunks) > maxReduceChunks {
+		dropped = len(chunks) - maxReduceChunks
+		chunks = chunks[:maxReduceChunks]
+	}
+
+	summaries := make([]string, len(chunks))
+	errs := make([]error, len(chunks))
+	var wg sync.WaitGroup
+	for i, chunk := range chunks {
+		wg.Add(1)
+		go func(i int, chunk string) {
+			defer wg.Done()
+			summaries[i], errs[i] = c.complete(ctx, rc.Repo, rc.Tag, []Message{
+				{Role: "system", Content: "Сожми фрагмент release notes в короткий список фактов: breaking changes, миграции, удалённые/устаревшие опции, security-исправления, важные новые возможности. Без вступлений."},
+				{Role: "user", Content: fmt.Sprintf("%s %s, часть %d из %d:\n\n%s", rc.Repo, rc.Tag, i+1, len(chunks), chunk)},
+			}, maxTokens/len(chunks))
+		}(i, chunk)
+	}
+	wg.Wait()
+
+	var parts []string
+	for i, summary := range summaries {
+		if errs[i] != nil {
+			return "", fmt.Errorf("failed to summarise chunk %d: %w", i+1, errs[i])
+		}
+		parts = append(parts, summary)
+	}
+	if dropped > 0 {
+		parts = append(parts, fmt.Sprintf("… release notes обрезаны: не вошло частей — %d", dropped))
+	}
+	return strings.Join(parts, "\n"), nil
+}
+
+// truncate cuts s to at most n bytes on a line boundary when possible
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	cut := strings.LastIndex(s[:n], "\n")
+	if cut < n/2 {
+		cut = n
+	}
+	return strings.ToValidUTF8(s[:cut], "") + "\n…"
+}
+
+// listWithin renders items as a list that fits into n bytes
+func listWithin(items []string, n int) string {
+	var sb strings.Builder
+	for i, item := range items {
+		line := "- " + strings.TrimSpace(item) + "\n"
+		if sb.Len()+len(line) > n {
+			sb.WriteString(fmt.Sprintf("… и ещё %d\n", len(items)-i))
+			break
+		}
+		sb.WriteString(line)
+	}
+	return sb.String()
+}
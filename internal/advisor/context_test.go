@@ -0,0 +1,244 @@
+package advisor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestBuildPromptTokenBudget(t *testing.T) {
+	const maxTokens = 100 // 400 bytes: 240 body, 100 commits, 60 files
+	var commits, files []string
+	for i := 0; i < 50; i++ {
+		commits = append(commits, fmt.Sprintf("commit %02d", i))
+		files = append(files, fmt.Sprintf("pkg/file%02d.go", i))
+	}
+	rc := ReleaseContext{
+		Repo:         "o/r",
+		Tag:          "v2.0.0",
+		PreviousTag:  "v1.0.0",
+		Body:         strings.Repeat("x", 1000),
+		Commits:      commits,
+		ChangedFiles: files,
+	}
+
+	prompt := BuildPrompt(rc, maxTokens)
+
+	if got := strings.Count(prompt, "x"); got != 240 {
+		t.Errorf("body kept %d bytes, want the 240 byte body share", got)
+	}
+	if !strings.Contains(prompt, "commit 00") || strings.Contains(prompt, "commit 49") {
+		t.Error("commit list was not cut to its share")
+	}
+	if !strings.Contains(prompt, "… и ещё") {
+		t.Error("cut lists do not say how many items were left out")
+	}
+	if !strings.Contains(prompt, "pkg/file00.go") || strings.Contains(prompt, "pkg/file49.go") {
+		t.Error("file list was not cut to its share")
+	}
+}
+
+func TestBuildPromptFallsBackToBullets(t *testing.T) {
+	prompt := BuildPrompt(ReleaseContext{Repo: "o/r", Tag: "v1", Bullets: []string{"fix a", "fix b"}}, 1000)
+	if !strings.Contains(prompt, "fix a; fix b") {
+		t.Errorf("prompt without a body does not list the bullets:\n%s", prompt)
+	}
+}
+
+func TestTruncateKeepsValidUTF8(t *testing.T) {
+	s := strings.Repeat("я", 100) // two bytes per rune
+	for n := 1; n < 40; n++ {
+		got := truncate(s, n)
+		if !utf8.ValidString(got) {
+			t.Fatalf("truncate(%d) returned invalid UTF-8: %q", n, got)
+		}
+		if len(strings.TrimSuffix(got, "\n…")) > n {
+			t.Fatalf("truncate(%d) kept %d bytes", n, len(got))
+		}
+	}
+}
+
+func TestTruncatePrefersLineBoundary(t *testing.T) {
+	s := "first line\nsecond line\nthird line"
+	if got := truncate(s, 25); got != "first line\nsecond line\n…" {
+		t.Errorf("truncate = %q", got)
+	}
+	if got := truncate(s, 100); got != s {
+		t.Errorf("short text was changed: %q", got)
+	}
+}
+
+func TestSplitChunks(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		maxChars int
+		want     []string
+	}{
+		{
+			name:     "fits",
+			text:     "short",
+			maxChars: 10,
+			want:     []string{"short"},
+		},
+		{
+			name:     "paragraphs",
+			text:     "aaaa\n\nbbbb\n\ncccc",
+			maxChars: 12,
+			want:     []string{"aaaa\n\nbbbb\n\n", "cccc"},
+		},
+		{
+			name:     "long paragraph split on lines",
+			text:     "aaa\nbbb\nccc\nddd",
+			maxChars: 8,
+			want:     []string{"aaa\nbbb", "\nccc\nddd"},
+		},
+		{
+			name:     "no newline",
+			text:     "abcdefghij",
+			maxChars: 4,
+			want:     []string{"abcd", "efgh", "ij"},
+		},
+		{
+			name:     "no newline, multibyte runes",
+			text:     "яяяяя",
+			maxChars: 5,
+			want:     []string{"яя", "яя", "я"},
+		},
+		{
+			name:     "rune longer than the limit",
+			text:     "яя",
+			maxChars: 1,
+			want:     []string{"я", "я"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SplitChunks(tt.text, tt.maxChars)
+			if strings.Join(got, "") != tt.text {
+				t.Errorf("chunks do not add up to the text: %q", got)
+			}
+			for _, chunk := range got {
+				if !utf8.ValidString(chunk) {
+					t.Errorf("chunk %q splits a rune", chunk)
+				}
+				if len(chunk) > tt.maxChars && utf8.RuneCountInString(chunk) > 1 {
+					t.Errorf("chunk %q is longer than %d", chunk, tt.maxChars)
+				}
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("SplitChunks = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeUsage is an in-memory UsageStore
+type fakeUsage struct {
+	mu        sync.Mutex
+	spent     float64
+	recordErr error
+	calls     int
+}
+
+func (f *fakeUsage) RecordUsage(ctx context.Context, u Usage) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if f.recordErr != nil {
+		return f.recordErr
+	}
+	f.spent += u.CostUSD
+	return nil
+}
+
+func (f *fakeUsage) CostSince(ctx context.Context, since time.Time) (float64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.spent, nil
+}
+
+// newTestClient returns a client talking to a fake OpenRouter that answers
+// every request with the same content and usage
+func newTestClient(t *testing.T, usage *fakeUsage, dailyBudget float64) *Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{{"message": map[string]string{"role": "assistant", "content": "summary"}}},
+			"usage":   map[string]int{"prompt_tokens": 1_000_000, "completion_tokens": 0},
+		})
+	}))
+	t.Cleanup(srv.Close)
+
+	c := New("key", "model-a", Options{
+		Prices:      map[string]Price{"model-a": {Prompt: 1}},
+		DailyBudget: dailyBudget,
+		Usage:       usage,
+	})
+	c.baseURL = srv.URL
+	return c
+}
+
+func TestReduceBodyChecksBudgetPerCall(t *testing.T) {
+	usage := &fakeUsage{spent: 5}
+	c := newTestClient(t, usage, 5)
+
+	rc := ReleaseContext{Repo: "o/r", Tag: "v1", Body: strings.Repeat("line\n", 2000)}
+	_, err := c.reduceBody(context.Background(), rc, 100)
+	if !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("reduceBody error = %v, want ErrBudgetExceeded", err)
+	}
+	if usage.calls != 0 {
+		t.Errorf("%d calls were made over budget", usage.calls)
+	}
+}
+
+func TestReduceBodyIgnoresUsageRecordErrors(t *testing.T) {
+	usage := &fakeUsage{recordErr: errors.New("database is locked")}
+	c := newTestClient(t, usage, 100)
+
+	rc := ReleaseContext{Repo: "o/r", Tag: "v1", Body: strings.Repeat("line\n", 2000)}
+	got, err := c.reduceBody(context.Background(), rc, 100)
+	if err != nil {
+		t.Fatalf("reduceBody failed on a usage recording error: %v", err)
+	}
+	if !strings.HasPrefix(got, strings.Repeat("summary\n", maxReduceChunks)) {
+		t.Errorf("reduceBody = %q, want %d summaries", got, maxReduceChunks)
+	}
+}
+
+func TestReduceBodyMarksDroppedChunks(t *testing.T) {
+	c := newTestClient(t, &fakeUsage{}, 100)
+
+	body := strings.Repeat("line\n", 2000)
+	chunks := SplitChunks(body, 100*charsPerToken*6/10)
+	got, err := c.reduceBody(context.Background(), ReleaseContext{Repo: "o/r", Tag: "v1", Body: body}, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(got, "\n")
+	if len(lines) != maxReduceChunks+1 {
+		t.Fatalf("reduceBody = %q, want %d summaries and a marker", got, maxReduceChunks)
+	}
+	if marker := lines[maxReduceChunks]; !strings.Contains(marker, fmt.Sprint(len(chunks)-maxReduceChunks)) {
+		t.Errorf("truncation marker %q does not say how many parts were dropped", marker)
+	}
+
+	// a body that fits into maxReduceChunks chunks is not marked
+	body = strings.Repeat("line\n", 150)
+	got, err = c.reduceBody(context.Background(), ReleaseContext{Repo: "o/r", Tag: "v1", Body: body}, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(got, "…") {
+		t.Errorf("reduceBody marked a body that was summarised in full: %q", got)
+	}
+}
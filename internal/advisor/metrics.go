@@ -0,0 +1,51 @@
+package advisor
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "release_bot",
+		Subsystem: "advisor",
+		Name:      "request_duration_seconds",
+		Help:      "LLM request latency by model and outcome (ok or error).",
+		Buckets:   []float64{0.25, 0.5, 1, 2, 4, 8, 15, 30},
+	}, []string{"model", "outcome"})
+
+	requestErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "release_bot",
+		Subsystem: "advisor",
+		Name:      "errors_total",
+		Help:      "Failed LLM requests by model.",
+	}, []string{"model"})
+
+	modelSkips = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "release_bot",
+		Subsystem: "advisor",
+		Name:      "skipped_total",
+		Help:      "LLM requests skipped because the model's circuit breaker is open.",
+	}, []string{"model"})
+
+	tokensTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "release_bot",
+		Subsystem: "advisor",
+		Name:      "tokens_total",
+		Help:      "LLM tokens used by model and kind (prompt or completion).",
+	}, []string{"model", "kind"})
+
+	costTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "release_bot",
+		Subsystem: "advisor",
+		Name:      "cost_usd_total",
+		Help:      "Estimated LLM spend in USD by model.",
+	}, []string{"model"})
+
+	usageRecordErrors = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "release_bot",
+		Subsystem: "advisor",
+		Name:      "usage_record_errors_total",
+		Help:      "LLM calls whose token usage could not be stored for budget checks.",
+	})
+)
@@ -4,32 +4,157 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/yourorg/tg-release-bot/internal/tracing"
 )
 
+var tracer = otel.Tracer("github.com/yourorg/tg-release-bot/internal/advisor")
+
+// ErrNoModelAvailable is returned when every model in the chain is skipped by its circuit breaker
+var ErrNoModelAvailable = errors.New("no advisor model available")
+
+// Options configures the model fallback chain and circuit breakers
+type Options struct {
+	FallbackModels   []string      // tried in order after the primary model
+	FailureThreshold int           // consecutive failures before a model's breaker opens
+	Cooldown         time.Duration // how long an open breaker skips its model
+	AttemptTimeout   time.Duration // timeout of a single model attempt
+
+	Prices        map[string]Price // per-model prices; unknown models are free
+	DailyBudget   float64          // USD per UTC day, 0 disables the cap
+	MonthlyBudget float64          // USD per UTC month, 0 disables the cap
+	Usage         UsageStore       // optional usage persistence
+
+	ContextTokens int            // prompt token budget for release context
+	Prompts       *PromptLibrary // named prompts; nil means only the default prompt
+}
+
 // Client provides LLM advisory functionality via OpenRouter
 type Client struct {
-	apiKey  string
-	model   string
-	http    *http.Client
-	baseURL string
+	apiKey         string
+	models         []string
+	http           *http.Client
+	baseURL        string
+	attemptTimeout time.Duration
+	breakers       map[string]*breaker
+	prices         map[string]Price
+	dailyBudget    float64
+	monthlyBudget  float64
+	usage          UsageStore
+	contextTokens  int
+	cache          *adviceCache
+	prompts        *PromptLibrary
+
+	mu    sync.Mutex
+	stats map[string]*ModelStats
+}
+
+// ModelStats holds success/failure counters for a single model
+type ModelStats struct {
+	Model        string
+	Successes    int
+	Failures     int
+	Skipped      int
+	TotalLatency time.Duration
+	PromptTokens int
+	OutputTokens int
+	CostUSD      float64
+	LastError    string
+	State        string
+	OpenUntil    time.Time
 }
 
 // New creates a new OpenRouter client
-func New(apiKey, model string) *Client {
-	return &Client{
-		apiKey:  apiKey,
-		model:   model,
-		baseURL: "https://openrouter.ai/api/v1",
+func New(apiKey, model string, opt Options) *Client {
+	if opt.FailureThreshold <= 0 {
+		opt.FailureThreshold = 3
+	}
+	if opt.Cooldown <= 0 {
+		opt.Cooldown = 5 * time.Minute
+	}
+	if opt.AttemptTimeout <= 0 {
+		opt.AttemptTimeout = 8 * time.Second
+	}
+	if opt.ContextTokens <= 0 {
+		opt.ContextTokens = 3000
+	}
+
+	c := &Client{
+		apiKey:         apiKey,
+		baseURL:        "https://openrouter.ai/api/v1",
+		attemptTimeout: opt.AttemptTimeout,
+		breakers:       make(map[string]*breaker),
+		stats:          make(map[string]*ModelStats),
+		prices:         opt.Prices,
+		dailyBudget:    opt.DailyBudget,
+		monthlyBudget:  opt.MonthlyBudget,
+		usage:          opt.Usage,
+		contextTokens:  opt.ContextTokens,
+		cache:          newAdviceCache(24*time.Hour, 500),
+		prompts:        opt.Prompts,
 		http: &http.Client{
 			Timeout: 20 * time.Second, // Увеличили timeout с 10 до 20 секунд
 		},
 	}
+
+	for _, m := range append([]string{model}, opt.FallbackModels...) {
+		m = strings.TrimSpace(m)
+		if m == "" || c.breakers[m] != nil {
+			continue
+		}
+		c.models = append(c.models, m)
+		c.breakers[m] = newBreaker(opt.FailureThreshold, opt.Cooldown)
+		c.stats[m] = &ModelStats{Model: m}
+	}
+
+	return c
+}
+
+// Models returns the ordered model chain
+func (c *Client) Models() []string {
+	if c == nil {
+		return nil
+	}
+	return append([]string(nil), c.models...)
+}
+
+// Prompts returns the prompt library
+func (c *Client) Prompts() *PromptLibrary {
+	if c == nil {
+		return nil
+	}
+	return c.prompts
+}
+
+// Stats returns a snapshot of per-model counters and breaker states in chain order
+func (c *Client) Stats() []ModelStats {
+	if c == nil {
+		return nil
+	}
+
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	result := make([]ModelStats, 0, len(c.models))
+	for _, m := range c.models {
+		st := *c.stats[m]
+		st.State, st.OpenUntil = c.breakers[m].State(now)
+		result = append(result, st)
+	}
+	return result
 }
 
 // Request represents an OpenRouter API request
@@ -52,16 +177,26 @@ type Response struct {
 			Content string `json:"content"`
 		} `json:"message"`
 	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage,omitempty"`
 	Error *struct {
 		Message string `json:"message"`
 		Code    string `json:"code"`
 	} `json:"error,omitempty"`
 }
 
-// Advise generates advice about a GitHub release
+// Advise generates advice about a GitHub release from changelog bullets only
 func (c *Client) Advise(ctx context.Context, repo, tag string, bullets []string) (string, error) {
+	return c.AdviseRelease(ctx, ReleaseContext{Repo: repo, Tag: tag, Bullets: bullets})
+}
+
+// AdviseRelease generates advice about a GitHub release from its full context.
+// Release bodies that do not fit the context budget are summarised first.
+func (c *Client) AdviseRelease(ctx context.Context, rc ReleaseContext) (string, error) {
 	// Skip if client is not configured
-	if c == nil || c.apiKey == "" || c.model == "" {
+	if c == nil || c.apiKey == "" || len(c.models) == 0 {
 		return "", nil
 	}
 	
@@ -70,48 +205,162 @@ func (c *Client) Advise(ctx context.Context, repo, tag string, bullets []string)
 		return "", nil
 	}
 
-	prompt := c.buildPrompt(repo, tag, bullets)
-
-	req := Request{
-		Model:     c.model,
-		MaxTokens: 350, // Увеличили с 200 до 350 для более полных ответов
-		Messages: []Message{
-			{
-				Role:    "system",
-				Content: "Ты опытный DevOps инженер. Анализируешь релизы для разработчиков. Отвечай СТРОГО в формате:\n\n🔧 КЛЮЧЕВЫЕ ИЗМЕНЕНИЯ:\n• [конкретное изменение]\n• [конкретное изменение]\n\n⚠️ ВАЖНО:\n• [что важно знать при обновлении]\n\nОтвечай кратко, максимум 3-4 пункта. БЕЗ заголовков, БЕЗ нумерации, БЕЗ лишнего текста. Только практическая польза для инженеров.",
-			},
-			{
-				Role:    "user",
-				Content: prompt,
-			},
-		},
+	prompt := rc.Prompt
+	if prompt.Name == "" {
+		prompt = DefaultPrompt()
 	}
 
-	return c.makeRequest(ctx, req)
+	key := cacheKey(rc, prompt)
+	if advice, ok := c.cache.get(key); ok {
+		return advice, nil
+	}
+
+	body, err := c.reduceBody(ctx, rc, c.contextTokens)
+	if err != nil {
+		return "", err
+	}
+	rc.Body = body
+
+	system, user, err := prompt.Render(PromptData{
+		Repo:         rc.Repo,
+		Tag:          rc.Tag,
+		PreviousTag:  rc.PreviousTag,
+		Description:  rc.Description,
+		Body:         truncate(body, c.contextTokens*charsPerToken*6/10),
+		Bullets:      rc.Bullets,
+		Commits:      rc.Commits,
+		ChangedFiles: rc.ChangedFiles,
+		Language:     rc.Language,
+		Context:      BuildPrompt(rc, c.contextTokens),
+	})
+	if err != nil {
+		return "", fmt.Errorf("prompt %s: %w", prompt.Name, err)
+	}
+
+	messages := []Message{
+		{Role: "system", Content: system},
+		{Role: "user", Content: user},
+	}
+
+	content, err := c.complete(ctx, rc.Repo, rc.Tag, messages, 350) // Увеличили с 200 до 350 для более полных ответов
+	// Format and limit response length for Telegram
+	advice := formatLLMResponse(content)
+	if advice != "" {
+		c.cache.put(key, advice)
+	}
+	return advice, err
 }
 
-// buildPrompt creates a prompt for the LLM
-func (c *Client) buildPrompt(repo, tag string, bullets []string) string {
-	bulletsText := strings.Join(bullets, "; ")
-	
-	return fmt.Sprintf(`Релиз: %s %s
+// complete tries each model of the chain in order, skipping models whose
+// circuit breaker is open, and returns the first successful answer. The
+// budget is checked before every call, including each map call of reduceBody.
+func (c *Client) complete(ctx context.Context, repo, tag string, messages []Message, maxTokens int) (result string, resultErr error) {
+	ctx, span := tracer.Start(ctx, "advisor complete", trace.WithAttributes(
+		attribute.String("repo", repo),
+		attribute.String("tag", tag),
+	))
+	defer func() { tracing.End(span, resultErr) }()
+
+	if err := c.checkBudget(ctx); err != nil {
+		return "", err
+	}
+
+	var lastErr error
+
+	for _, model := range c.models {
+		if !c.breakers[model].Allow(time.Now()) {
+			c.record(model, func(st *ModelStats) { st.Skipped++ })
+			modelSkips.WithLabelValues(model).Inc()
+			span.AddEvent("model skipped", trace.WithAttributes(attribute.String("advisor.model", model)))
+			continue
+		}
 
-Изменения:
-%s
+		attemptCtx, cancel := context.WithTimeout(ctx, c.attemptTimeout)
+		attemptCtx, attemptSpan := tracer.Start(attemptCtx, "advisor request",
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(attribute.String("advisor.model", model)))
+		start := time.Now()
+		content, usage, err := c.makeRequest(attemptCtx, Request{
+			Model:     model,
+			MaxTokens: maxTokens,
+			Messages:  messages,
+		})
+		latency := time.Since(start)
+		attemptSpan.SetAttributes(
+			attribute.Int("advisor.prompt_tokens", usage.PromptTokens),
+			attribute.Int("advisor.completion_tokens", usage.CompletionTokens),
+		)
+		tracing.End(attemptSpan, err)
+		cancel()
 
-Проанализируй что важно для DevOps/разработчиков. Следуй СТРОГО формату из system prompt.`, repo, tag, bulletsText)
+		// The caller gave up - this is not the model's fault
+		if ctx.Err() != nil {
+			c.breakers[model].Cancel()
+			return "", ctx.Err()
+		}
+
+		if err != nil {
+			requestDuration.WithLabelValues(model, "error").Observe(latency.Seconds())
+			requestErrors.WithLabelValues(model).Inc()
+			c.breakers[model].Failure(time.Now())
+			c.record(model, func(st *ModelStats) {
+				st.Failures++
+				st.TotalLatency += latency
+				st.LastError = err.Error()
+			})
+			lastErr = fmt.Errorf("model %s: %w", model, err)
+			continue
+		}
+
+		usage.Repo, usage.Tag = repo, tag
+		usage.CostUSD = c.cost(usage.Model, usage.PromptTokens, usage.CompletionTokens)
+
+		requestDuration.WithLabelValues(model, "ok").Observe(latency.Seconds())
+		tokensTotal.WithLabelValues(model, "prompt").Add(float64(usage.PromptTokens))
+		tokensTotal.WithLabelValues(model, "completion").Add(float64(usage.CompletionTokens))
+		costTotal.WithLabelValues(model).Add(usage.CostUSD)
+
+		c.breakers[model].Success()
+		span.SetAttributes(attribute.String("advisor.model", model), attribute.Float64("advisor.cost_usd", usage.CostUSD))
+		c.record(model, func(st *ModelStats) {
+			st.Successes++
+			st.TotalLatency += latency
+			st.PromptTokens += usage.PromptTokens
+			st.OutputTokens += usage.CompletionTokens
+			st.CostUSD += usage.CostUSD
+		})
+		// The answer is paid for already; losing its usage row only makes the
+		// budget check undercount, so it does not fail the call
+		if err := c.recordUsage(ctx, usage); err != nil {
+			usageRecordErrors.Inc()
+			span.RecordError(fmt.Errorf("failed to record usage: %w", err))
+		}
+		return content, nil
+	}
+
+	if lastErr == nil {
+		return "", ErrNoModelAvailable
+	}
+	return "", lastErr
+}
+
+// record updates stats of a model under the lock
+func (c *Client) record(model string, update func(st *ModelStats)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	update(c.stats[model])
 }
 
 // makeRequest sends a request to OpenRouter API
-func (c *Client) makeRequest(ctx context.Context, req Request) (string, error) {
+func (c *Client) makeRequest(ctx context.Context, req Request) (string, Usage, error) {
 	jsonData, err := json.Marshal(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		return "", Usage{}, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/chat/completions", bytes.NewReader(jsonData))
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return "", Usage{}, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
@@ -122,35 +371,37 @@ func (c *Client) makeRequest(ctx context.Context, req Request) (string, error) {
 
 	resp, err := c.http.Do(httpReq)
 	if err != nil {
-		return "", fmt.Errorf("request failed: %w", err)
+		return "", Usage{}, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		// Читаем тело ответа для более детальной ошибки
 		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("openrouter returned status %d: %s", resp.StatusCode, string(body))
+		return "", Usage{}, fmt.Errorf("openrouter returned status %d: %s", resp.StatusCode, string(body))
 	}
 
 	var response Response
 	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return "", fmt.Errorf("failed to decode response: %w", err)
+		return "", Usage{}, fmt.Errorf("failed to decode response: %w", err)
 	}
 
 	if response.Error != nil {
-		return "", fmt.Errorf("openrouter error: %s", response.Error.Message)
+		return "", Usage{}, fmt.Errorf("openrouter error: %s", response.Error.Message)
 	}
 
 	if len(response.Choices) == 0 {
-		return "", fmt.Errorf("no choices in response")
+		return "", Usage{}, fmt.Errorf("no choices in response")
 	}
 
-	content := strings.TrimSpace(response.Choices[0].Message.Content)
-
-	// Format and limit response length for Telegram
-	content = formatLLMResponse(content)
+	usage := Usage{Model: req.Model}
+	if response.Usage != nil {
+		usage.PromptTokens = response.Usage.PromptTokens
+		usage.CompletionTokens = response.Usage.CompletionTokens
+	}
 
-	return content, nil
+	content := strings.TrimSpace(response.Choices[0].Message.Content)
+	return content, usage, nil
 }
 
 // formatLLMResponse cleans up and formats LLM response for better readability
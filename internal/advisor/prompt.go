@@ -0,0 +1,324 @@
+package advisor
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// DefaultPromptName is the name of the built-in prompt
+const DefaultPromptName = "default"
+
+// Prompt assignment scopes
+const (
+	ScopeChat = "chat"
+	ScopeRepo = "repo"
+)
+
+const defaultSystemPrompt = "Ты опытный DevOps инженер. Анализируешь релизы для разработчиков. Отвечай СТРОГО в формате:\n\n🔧 КЛЮЧЕВЫЕ ИЗМЕНЕНИЯ:\n• [конкретное изменение]\n• [конкретное изменение]\n\n⚠️ ВАЖНО:\n• [что важно знать при обновлении]\n\nОтвечай кратко, максимум 3-4 пункта. БЕЗ заголовков, БЕЗ нумерации, БЕЗ лишнего текста. Только практическая польза для инженеров."
+
+// defaultUserPrompt renders the token-budgeted context assembled by BuildPrompt
+const defaultUserPrompt = "{{.Context}}"
+
+// Prompt is a named pair of system and user templates (Go text/template)
+type Prompt struct {
+	Name   string
+	System string
+	User   string
+	Source string // "builtin", "file" or "db"
+}
+
+// PromptData holds the variables available to prompt templates
+type PromptData struct {
+	Repo         string
+	Tag          string
+	PreviousTag  string
+	Description  string
+	Body         string
+	Bullets      []string
+	Commits      []string
+	ChangedFiles []string
+	Language     string
+	Context      string // the default prompt assembled from all of the above
+}
+
+var promptFuncs = template.FuncMap{
+	"join": strings.Join,
+}
+
+// DefaultPrompt returns the built-in DevOps prompt
+func DefaultPrompt() Prompt {
+	return Prompt{Name: DefaultPromptName, System: defaultSystemPrompt, User: defaultUserPrompt, Source: "builtin"}
+}
+
+// Version identifies the prompt contents; it changes whenever a template changes
+func (p Prompt) Version() string {
+	sum := sha256.Sum256([]byte(p.System + "\x00" + p.User))
+	return hex.EncodeToString(sum[:4])
+}
+
+// Validate checks that both templates parse
+func (p Prompt) Validate() error {
+	if _, err := template.New("system").Funcs(promptFuncs).Parse(p.System); err != nil {
+		return fmt.Errorf("invalid system template: %w", err)
+	}
+	if _, err := template.New("user").Funcs(promptFuncs).Parse(p.User); err != nil {
+		return fmt.Errorf("invalid user template: %w", err)
+	}
+	return nil
+}
+
+// Render executes both templates
+func (p Prompt) Render(data PromptData) (system, user string, err error) {
+	system, err = renderTemplate("system", p.System, data)
+	if err != nil {
+		return "", "", err
+	}
+	user, err = renderTemplate("user", p.User, data)
+	if err != nil {
+		return "", "", err
+	}
+	return system, user, nil
+}
+
+func renderTemplate(name, text string, data PromptData) (string, error) {
+	tmpl, err := template.New(name).Funcs(promptFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s template: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render %s template: %w", name, err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// ParsePromptFile parses a prompt file. The file defines the "system" and
+// optionally the "user" template:
+//
+//	{{define "system"}}You are a security engineer...{{end}}
+//	{{define "user"}}{{.Repo}} {{.Tag}}: {{.Body}}{{end}}
+//
+// A file without define blocks is used as the system template. The source
+// text of each block is kept as written, so the prompt version only changes
+// when the file does.
+func ParsePromptFile(name, content string) (Prompt, error) {
+	p := Prompt{Name: name, User: defaultUserPrompt, Source: "file"}
+
+	tmpl, err := template.New(name).Funcs(promptFuncs).Parse(content)
+	if err != nil {
+		return Prompt{}, fmt.Errorf("prompt %s: %w", name, err)
+	}
+	hasSystem, hasUser := tmpl.Lookup("system") != nil, tmpl.Lookup("user") != nil
+	switch {
+	case hasUser && !hasSystem:
+		return Prompt{}, fmt.Errorf("prompt %s: defines \"user\" but not \"system\"", name)
+	case !hasSystem:
+		p.System = strings.TrimSpace(content)
+		return p, nil
+	}
+
+	if p.System, err = defineSource(tmpl, content, "system"); err != nil {
+		return Prompt{}, fmt.Errorf("prompt %s: %w", name, err)
+	}
+	if hasUser {
+		if p.User, err = defineSource(tmpl, content, "user"); err != nil {
+			return Prompt{}, fmt.Errorf("prompt %s: %w", name, err)
+		}
+	}
+	return p, p.Validate()
+}
+
+var (
+	defineRe = regexp.MustCompile(`\{\{-?\s*define\s+"(\w+)"\s*-?\}\}`)
+	endRe    = regexp.MustCompile(`\{\{-?\s*end\s*-?\}\}`)
+)
+
+// defineSource returns the source text of a {{define "name"}} block of
+// content. Blocks may contain {{if}} or {{range}} with their own {{end}}, so
+// the block ends at the first {{end}} before which the text parses to the
+// same tree as the one the template parsed.
+func defineSource(tmpl *template.Template, content, name string) (string, error) {
+	want := strings.TrimSpace(tmpl.Lookup(name).Tree.Root.String())
+	for _, m := range defineRe.FindAllStringSubmatchIndex(content, -1) {
+		if content[m[2]:m[3]] != name {
+			continue
+		}
+		start := m[1]
+		for _, e := range endRe.FindAllStringIndex(content[start:], -1) {
+			body := content[start : start+e[0]]
+			t, err := template.New(name).Funcs(promptFuncs).Parse(body)
+			if err != nil {
+				continue
+			}
+			got := ""
+			if t.Tree != nil {
+				got = strings.TrimSpace(t.Tree.Root.String())
+			}
+			if got == want {
+				return strings.TrimSpace(body), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("cannot find the source of the %q block", name)
+}
+
+// PromptStore persists prompts and their assignments
+type PromptStore interface {
+	SavePrompt(ctx context.Context, name, system, user string) error
+	GetPrompt(ctx context.Context, name string) (Prompt, bool, error)
+	ListPrompts(ctx context.Context) ([]Prompt, error)
+	AssignPrompt(ctx context.Context, scope, target, name string) error
+	UnassignPrompt(ctx context.Context, scope, target string) error
+	PromptAssignment(ctx context.Context, scope, target string) (string, error)
+}
+
+// PromptLibrary resolves named prompts from the database, prompt files and the built-in default
+type PromptLibrary struct {
+	store PromptStore
+	files map[string]Prompt
+}
+
+// NewPromptLibrary creates a prompt library. Prompts are loaded from *.tmpl
+// files in dir (if set); prompts saved in the database take precedence.
+func NewPromptLibrary(store PromptStore, dir string) (*PromptLibrary, error) {
+	lib := &PromptLibrary{store: store, files: make(map[string]Prompt)}
+	if dir == "" {
+		return lib, nil
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read prompt file: %w", err)
+		}
+		name := strings.TrimSuffix(filepath.Base(path), ".tmpl")
+		p, err := ParsePromptFile(name, string(content))
+		if err != nil {
+			return nil, err
+		}
+		lib.files[name] = p
+	}
+	return lib, nil
+}
+
+// Get returns a prompt by name
+func (l *PromptLibrary) Get(ctx context.Context, name string) (Prompt, bool, error) {
+	if l == nil {
+		if name == DefaultPromptName {
+			return DefaultPrompt(), true, nil
+		}
+		return Prompt{}, false, nil
+	}
+
+	if l.store != nil {
+		p, ok, err := l.store.GetPrompt(ctx, name)
+		if err != nil || ok {
+			return p, ok, err
+		}
+	}
+	if p, ok := l.files[name]; ok {
+		return p, true, nil
+	}
+	if name == DefaultPromptName {
+		return DefaultPrompt(), true, nil
+	}
+	return Prompt{}, false, nil
+}
+
+// List returns all available prompts sorted by name
+func (l *PromptLibrary) List(ctx context.Context) ([]Prompt, error) {
+	byName := map[string]Prompt{DefaultPromptName: DefaultPrompt()}
+	if l != nil {
+		for name, p := range l.files {
+			byName[name] = p
+		}
+		if l.store != nil {
+			stored, err := l.store.ListPrompts(ctx)
+			if err != nil {
+				return nil, err
+			}
+			for _, p := range stored {
+				byName[p.Name] = p
+			}
+		}
+	}
+
+	prompts := make([]Prompt, 0, len(byName))
+	for _, p := range byName {
+		prompts = append(prompts, p)
+	}
+	sort.Slice(prompts, func(i, j int) bool { return prompts[i].Name < prompts[j].Name })
+	return prompts, nil
+}
+
+// Save validates and stores a prompt in the database
+func (l *PromptLibrary) Save(ctx context.Context, name, system, user string) (Prompt, error) {
+	if l == nil || l.store == nil {
+		return Prompt{}, fmt.Errorf("prompt storage is not configured")
+	}
+	if user == "" {
+		user = defaultUserPrompt
+	}
+	p := Prompt{Name: name, System: system, User: user, Source: "db"}
+	if err := p.Validate(); err != nil {
+		return Prompt{}, err
+	}
+	return p, l.store.SavePrompt(ctx, name, system, user)
+}
+
+// Assign assigns an existing prompt to a chat or repository.
+// Assigning the default prompt removes the assignment.
+func (l *PromptLibrary) Assign(ctx context.Context, scope, target, name string) error {
+	if l == nil || l.store == nil {
+		return fmt.Errorf("prompt storage is not configured")
+	}
+	if name == DefaultPromptName {
+		return l.store.UnassignPrompt(ctx, scope, target)
+	}
+	if _, ok, err := l.Get(ctx, name); err != nil {
+		return err
+	} else if !ok {
+		return fmt.Errorf("unknown prompt %q", name)
+	}
+	return l.store.AssignPrompt(ctx, scope, target, name)
+}
+
+// Resolve returns the prompt for a chat and repository: the chat assignment
+// wins over the repository assignment, which wins over the default prompt
+func (l *PromptLibrary) Resolve(ctx context.Context, chatID int64, repo string) (Prompt, error) {
+	if l == nil || l.store == nil {
+		return DefaultPrompt(), nil
+	}
+
+	for _, a := range []struct{ scope, target string }{
+		{ScopeChat, fmt.Sprint(chatID)},
+		{ScopeRepo, repo},
+	} {
+		name, err := l.store.PromptAssignment(ctx, a.scope, a.target)
+		if err != nil {
+			return DefaultPrompt(), err
+		}
+		if name == "" {
+			continue
+		}
+		if p, ok, err := l.Get(ctx, name); err != nil {
+			return DefaultPrompt(), err
+		} else if ok {
+			return p, nil
+		}
+	}
+	return DefaultPrompt(), nil
+}
@@ -0,0 +1,109 @@
+package advisor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParsePromptFileKeepsDefineSource(t *testing.T) {
+	system := `You review {{ .Repo | printf "%q" }} releases.
+{{- /* keep it short */ -}}
+{{if .Commits}}Commits:{{range .Commits}}
+- {{.}}{{end}}{{end}}`
+	user := `{{ .Repo }} {{ .Tag }}: {{ .Body }}`
+	content := "# security review prompt\n" +
+		`{{define "system"}}` + system + `{{end}}` + "\n" +
+		`{{- define "user" -}}` + "\n" + user + "\n" + `{{- end}}` + "\n"
+
+	p, err := ParsePromptFile("security", content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.System != system {
+		t.Errorf("System =\n%s\nwant\n%s", p.System, system)
+	}
+	if p.User != user {
+		t.Errorf("User = %q, want %q", p.User, user)
+	}
+
+	gotSystem, gotUser, err := p.Render(PromptData{Repo: "o/r", Tag: "v1", Body: "notes", Commits: []string{"a", "b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "You review \"o/r\" releases.Commits:\n- a\n- b"; gotSystem != want {
+		t.Errorf("rendered system = %q, want %q", gotSystem, want)
+	}
+	if want := "o/r v1: notes"; gotUser != want {
+		t.Errorf("rendered user = %q, want %q", gotUser, want)
+	}
+}
+
+func TestParsePromptFileVersionFollowsSource(t *testing.T) {
+	a, err := ParsePromptFile("p", `{{define "system"}}Hi {{/* v1 */}}{{.Repo}}{{end}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ParsePromptFile("p", `{{define "system"}}Hi {{/* v2 */}}{{.Repo}}{{end}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Version() == b.Version() {
+		t.Error("editing the file did not change the prompt version")
+	}
+}
+
+func TestParsePromptFileDefines(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		wantSystem string
+		wantUser   string
+		wantErr    string
+	}{
+		{
+			name:       "no defines",
+			content:    "  You are a DevOps engineer.\n",
+			wantSystem: "You are a DevOps engineer.",
+			wantUser:   defaultUserPrompt,
+		},
+		{
+			name:       "system only",
+			content:    `{{define "system"}}Be brief.{{end}}`,
+			wantSystem: "Be brief.",
+			wantUser:   defaultUserPrompt,
+		},
+		{
+			name:       "empty system",
+			content:    `{{define "system"}}{{end}}{{define "user"}}{{.Tag}}{{end}}`,
+			wantSystem: "",
+			wantUser:   "{{.Tag}}",
+		},
+		{
+			name:    "user only",
+			content: `{{define "user"}}{{.Repo}}{{end}}`,
+			wantErr: `defines "user" but not "system"`,
+		},
+		{
+			name:    "syntax error",
+			content: `{{define "system"}}{{if .Repo}}{{end}}`,
+			wantErr: "prompt p:",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParsePromptFile("p", tt.content)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.System != tt.wantSystem || p.User != tt.wantUser {
+				t.Errorf("got system %q, user %q; want %q, %q", p.System, p.User, tt.wantSystem, tt.wantUser)
+			}
+		})
+	}
+}
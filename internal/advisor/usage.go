@@ -0,0 +1,78 @@
+package advisor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrBudgetExceeded is returned when the daily or monthly advisor budget is spent
+var ErrBudgetExceeded = errors.New("advisor budget exceeded")
+
+// Price is the cost of a model in USD per million tokens
+type Price struct {
+	Prompt     float64
+	Completion float64
+}
+
+// Usage is token usage of a single successful advisor call
+type Usage struct {
+	Repo             string
+	Tag              string
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+	CostUSD          float64
+}
+
+// UsageStore persists advisor usage and reports spend for budget checks
+type UsageStore interface {
+	RecordUsage(ctx context.Context, u Usage) error
+	CostSince(ctx context.Context, since time.Time) (float64, error)
+}
+
+// cost computes the cost of a call from the configured price table
+func (c *Client) cost(model string, promptTokens, completionTokens int) float64 {
+	price, ok := c.prices[model]
+	if !ok {
+		return 0
+	}
+	return (float64(promptTokens)*price.Prompt + float64(completionTokens)*price.Completion) / 1_000_000
+}
+
+// checkBudget returns ErrBudgetExceeded once the daily or monthly spend reaches its cap
+func (c *Client) checkBudget(ctx context.Context) error {
+	if c.usage == nil || (c.dailyBudget <= 0 && c.monthlyBudget <= 0) {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	if c.dailyBudget > 0 {
+		spent, err := c.usage.CostSince(ctx, time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC))
+		if err != nil {
+			return fmt.Errorf("failed to get daily spend: %w", err)
+		}
+		if spent >= c.dailyBudget {
+			return fmt.Errorf("%w: daily spend $%.4f of $%.2f", ErrBudgetExceeded, spent, c.dailyBudget)
+		}
+	}
+	if c.monthlyBudget > 0 {
+		spent, err := c.usage.CostSince(ctx, time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC))
+		if err != nil {
+			return fmt.Errorf("failed to get monthly spend: %w", err)
+		}
+		if spent >= c.monthlyBudget {
+			return fmt.Errorf("%w: monthly spend $%.4f of $%.2f", ErrBudgetExceeded, spent, c.monthlyBudget)
+		}
+	}
+	return nil
+}
+
+// recordUsage persists usage of a successful call; failures are not fatal for the advice
+func (c *Client) recordUsage(ctx context.Context, u Usage) error {
+	if c.usage == nil {
+		return nil
+	}
+	return c.usage.RecordUsage(ctx, u)
+}
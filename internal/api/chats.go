@@ -0,0 +1,263 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/yourorg/tg-release-bot/internal/db"
+)
+
+// Subscription sources
+const (
+	sourceConfig = "config" // from the configuration file, read-only here
+	sourceAPI    = "api"    // added through the API
+)
+
+// chat is a notification chat in API responses
+type chat struct {
+	ID       int64  `json:"id"`
+	Title    string `json:"title"`
+	Language string `json:"language"`
+}
+
+// chatRequest adds or updates a chat; unset fields keep their value
+type chatRequest struct {
+	ID       *int64  `json:"id"` // only when adding
+	Title    *string `json:"title"`
+	Language *string `json:"language"`
+}
+
+// subscription is a repository a chat receives releases of
+type subscription struct {
+	Repo   string `json:"repo"`
+	Source string `json:"source"`
+}
+
+func (s *Server) handleListChats(w http.ResponseWriter, r *http.Request) {
+	chats, err := s.store.ListChats(r.Context())
+	if err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+
+	list := make([]chat, 0, len(chats))
+	for _, c := range chats {
+		list = append(list, chat(c))
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *Server) handleGetChat(w http.ResponseWriter, r *http.Request) {
+	c, ok := s.lookupChat(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, chat(c))
+}
+
+func (s *Server) handleAddChat(w http.ResponseWriter, r *http.Request) {
+	var req chatRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if req.ID == nil || *req.ID == 0 {
+		writeError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	existing, err := s.findChat(r.Context(), *req.ID)
+	if err != nil && err != errNotFound {
+		s.internalError(w, r, err)
+		return
+	}
+	status := http.StatusOK
+	if err == errNotFound {
+		// Same defaults as /setchat
+		existing = db.Chat{ID: *req.ID, Title: fmt.Sprintf("Chat %d", *req.ID), Language: "ru"}
+		status = http.StatusCreated
+	}
+
+	c, err := s.applyChat(r.Context(), existing, req)
+	if err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+	s.logger.Info("Admin API: chat saved", "chat_id", c.ID)
+	writeJSON(w, status, c)
+}
+
+func (s *Server) handleUpdateChat(w http.ResponseWriter, r *http.Request) {
+	existing, ok := s.lookupChat(w, r)
+	if !ok {
+		return
+	}
+	var req chatRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if req.ID != nil && *req.ID != existing.ID {
+		writeError(w, http.StatusBadRequest, "id cannot be changed")
+		return
+	}
+
+	c, err := s.applyChat(r.Context(), existing, req)
+	if err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+	s.logger.Info("Admin API: chat updated", "chat_id", c.ID)
+	writeJSON(w, http.StatusOK, c)
+}
+
+func (s *Server) handleDeleteChat(w http.ResponseWriter, r *http.Request) {
+	c, ok := s.lookupChat(w, r)
+	if !ok {
+		return
+	}
+	if err := s.store.RemoveChat(r.Context(), c.ID); err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+	s.logger.Info("Admin API: chat removed", "chat_id", c.ID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleListSubscriptions lists the subscriptions from the configuration file
+// and the API; a chat without any receives all repositories
+func (s *Server) handleListSubscriptions(w http.ResponseWriter, r *http.Request) {
+	c, ok := s.lookupChat(w, r)
+	if !ok {
+		return
+	}
+	subs, err := s.subscriptions(r.Context(), c.ID)
+	if err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, subs)
+}
+
+func (s *Server) handleSubscribe(w http.ResponseWriter, r *http.Request) {
+	c, ok := s.lookupChat(w, r)
+	if !ok {
+		return
+	}
+	repo, ok := s.lookupRepo(w, r)
+	if !ok {
+		return
+	}
+
+	if err := s.store.AddSubscription(r.Context(), c.ID, repo.Owner, repo.Name); err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+	s.logger.Info("Admin API: chat subscribed", "chat_id", c.ID, "repo", repo.Owner+"/"+repo.Name)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleUnsubscribe(w http.ResponseWriter, r *http.Request) {
+	c, ok := s.lookupChat(w, r)
+	if !ok {
+		return
+	}
+	full := r.PathValue("owner") + "/" + r.PathValue("name")
+
+	subs, err := s.subscriptions(r.Context(), c.ID)
+	if err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+	var found *subscription
+	for i := range subs {
+		if strings.EqualFold(subs[i].Repo, full) {
+			found = &subs[i]
+			break
+		}
+	}
+	switch {
+	case found == nil:
+		writeError(w, http.StatusNotFound, fmt.Sprintf("chat %d is not subscribed to %s", c.ID, full))
+		return
+	case found.Source == sourceConfig:
+		writeError(w, http.StatusConflict, fmt.Sprintf("subscription to %s is set in the configuration file", full))
+		return
+	}
+
+	owner, name, _ := strings.Cut(found.Repo, "/")
+	if err := s.store.RemoveSubscription(r.Context(), c.ID, owner, name); err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+	s.logger.Info("Admin API: chat unsubscribed", "chat_id", c.ID, "repo", found.Repo)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// subscriptions merges the configured and stored subscriptions of a chat
+func (s *Server) subscriptions(ctx context.Context, chatID int64) ([]subscription, error) {
+	stored, err := s.store.ListSubscriptions(ctx, chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	subs := make([]subscription, 0, len(stored))
+	seen := make(map[string]bool)
+	for _, repo := range s.opts.ConfiguredSubscriptions(chatID) {
+		seen[strings.ToLower(repo)] = true
+		subs = append(subs, subscription{Repo: repo, Source: sourceConfig})
+	}
+	for _, sub := range stored {
+		repo := sub.RepoOwner + "/" + sub.RepoName
+		if !seen[strings.ToLower(repo)] {
+			subs = append(subs, subscription{Repo: repo, Source: sourceAPI})
+		}
+	}
+	return subs, nil
+}
+
+func (s *Server) applyChat(ctx context.Context, c db.Chat, req chatRequest) (chat, error) {
+	if req.Title != nil {
+		c.Title = *req.Title
+	}
+	if req.Language != nil {
+		c.Language = *req.Language
+	}
+	if err := s.store.AddChat(ctx, c.ID, c.Title, c.Language); err != nil {
+		return chat{}, err
+	}
+	return chat(c), nil
+}
+
+func (s *Server) findChat(ctx context.Context, id int64) (db.Chat, error) {
+	chats, err := s.store.ListChats(ctx)
+	if err != nil {
+		return db.Chat{}, err
+	}
+	for _, c := range chats {
+		if c.ID == id {
+			return c, nil
+		}
+	}
+	return db.Chat{}, errNotFound
+}
+
+// lookupChat finds the chat named by the path, answering 404 when it is not registered
+func (s *Server) lookupChat(w http.ResponseWriter, r *http.Request) (db.Chat, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid chat ID %q", r.PathValue("id")))
+		return db.Chat{}, false
+	}
+	c, err := s.findChat(r.Context(), id)
+	switch {
+	case err == errNotFound:
+		writeError(w, http.StatusNotFound, fmt.Sprintf("chat %d is not registered", id))
+		return c, false
+	case err != nil:
+		s.internalError(w, r, err)
+		return c, false
+	}
+	return c, true
+}
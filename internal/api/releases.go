@@ -0,0 +1,111 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/yourorg/tg-release-bot/internal/db"
+)
+
+// release is a processed release in API responses
+type release struct {
+	Repo        string    `json:"repo"`
+	Tag         string    `json:"tag"`
+	Name        string    `json:"name,omitempty"`
+	URL         string    `json:"url,omitempty"`
+	Prerelease  bool      `json:"prerelease"`
+	PublishedAt time.Time `json:"published_at"`
+	Message     string    `json:"message,omitempty"` // notification HTML as sent
+}
+
+// checkStatus describes the release check job
+type checkStatus struct {
+	Running      bool       `json:"running"`
+	Pending      bool       `json:"pending"`
+	LastRun      *time.Time `json:"last_run,omitempty"`
+	LastDuration string     `json:"last_duration,omitempty"`
+	LastResult   string     `json:"last_result,omitempty"`
+	LastError    string     `json:"last_error,omitempty"`
+	NextRun      *time.Time `json:"next_run,omitempty"`
+	Runs         int        `json:"runs"`
+	Skipped      int        `json:"skipped"`
+}
+
+// handleListReleases lists the most recently published releases of all
+// tracked repositories, newest first
+func (s *Server) handleListReleases(w http.ResponseWriter, r *http.Request) {
+	limit, ok := parseLimit(w, r)
+	if !ok {
+		return
+	}
+	repos, err := s.store.ListRepositories(r.Context())
+	if err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+
+	var all []db.ProcessedRelease
+	for _, repo := range repos {
+		history, err := s.store.ListReleaseHistory(r.Context(), repo.Owner, repo.Name, limit)
+		if err != nil {
+			s.internalError(w, r, err)
+			return
+		}
+		all = append(all, history...)
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].PublishedAt.After(all[j].PublishedAt) })
+	if len(all) > limit {
+		all = all[:limit]
+	}
+	writeJSON(w, http.StatusOK, releaseList(all))
+}
+
+func (s *Server) handleCheckStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.checkStatus())
+}
+
+// handleTriggerCheck starts a release check like /forcecheck; it runs in the
+// background, poll GET /check for the result
+func (s *Server) handleTriggerCheck(w http.ResponseWriter, r *http.Request) {
+	if err := s.jobs.TriggerCheck(r.Context()); err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+	s.logger.Info("Admin API: release check triggered")
+	writeJSON(w, http.StatusAccepted, s.checkStatus())
+}
+
+func (s *Server) checkStatus() checkStatus {
+	st := s.jobs.Status()
+	status := checkStatus{
+		Running:    st.Running,
+		Pending:    st.Pending,
+		LastRun:    optionalTime(st.LastStart),
+		LastResult: st.LastResult,
+		LastError:  st.LastError,
+		NextRun:    optionalTime(st.NextRun),
+		Runs:       st.Runs,
+		Skipped:    st.Skipped,
+	}
+	if st.LastResult != "" {
+		status.LastDuration = st.LastDuration.Round(time.Millisecond).String()
+	}
+	return status
+}
+
+func releaseList(history []db.ProcessedRelease) []release {
+	list := make([]release, 0, len(history))
+	for _, r := range history {
+		list = append(list, release{
+			Repo:        r.RepoOwner + "/" + r.RepoName,
+			Tag:         r.TagName,
+			Name:        r.Name,
+			URL:         r.URL,
+			Prerelease:  r.Prerelease,
+			PublishedAt: r.PublishedAt,
+			Message:     r.Message,
+		})
+	}
+	return list
+}
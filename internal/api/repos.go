@@ -0,0 +1,294 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yourorg/tg-release-bot/internal/db"
+)
+
+// repository is a tracked repository in API responses
+type repository struct {
+	Repo            string     `json:"repo"`
+	Owner           string     `json:"owner"`
+	Name            string     `json:"name"`
+	Prereleases     bool       `json:"prereleases"`
+	IntervalMinutes int        `json:"interval_minutes"` // 0 polls on the default or adaptive schedule
+	NextCheckAt     *time.Time `json:"next_check_at,omitempty"`
+	LastCheckedAt   *time.Time `json:"last_checked_at,omitempty"`
+	LastReleaseAt   *time.Time `json:"last_release_at,omitempty"`
+}
+
+// repoRequest adds or updates a repository; unset fields keep their value
+type repoRequest struct {
+	Repo            string `json:"repo"` // owner/name, only when adding
+	Prereleases     *bool  `json:"prereleases"`
+	IntervalMinutes *int   `json:"interval_minutes"`
+}
+
+func (req repoRequest) validate() error {
+	if req.IntervalMinutes != nil && *req.IntervalMinutes < 0 {
+		return fmt.Errorf("interval_minutes must not be negative, got %d", *req.IntervalMinutes)
+	}
+	return nil
+}
+
+func (s *Server) handleListRepos(w http.ResponseWriter, r *http.Request) {
+	repos, err := s.store.ListRepositories(r.Context())
+	if err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+
+	list := make([]repository, 0, len(repos))
+	for _, repo := range repos {
+		item, err := s.describeRepo(r.Context(), repo)
+		if err != nil {
+			s.internalError(w, r, err)
+			return
+		}
+		list = append(list, item)
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *Server) handleGetRepo(w http.ResponseWriter, r *http.Request) {
+	repo, ok := s.lookupRepo(w, r)
+	if !ok {
+		return
+	}
+	item, err := s.describeRepo(r.Context(), repo)
+	if err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, item)
+}
+
+func (s *Server) handleAddRepo(w http.ResponseWriter, r *http.Request) {
+	var req repoRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	owner, name, err := parseRepo(req.Repo)
+	if err == nil {
+		err = req.validate()
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	existing, err := s.findRepo(r.Context(), owner, name)
+	if err != nil && err != errNotFound {
+		s.internalError(w, r, err)
+		return
+	}
+	status := http.StatusCreated
+	if err == nil {
+		status = http.StatusOK
+	}
+
+	repo, err := s.applyRepo(r.Context(), owner, name, existing, req)
+	if err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+	s.logger.Info("Admin API: repository saved", "repo", repo.Repo)
+	writeJSON(w, status, repo)
+}
+
+// handleAddRepos adds or updates several repositories at once. The whole
+// batch is validated before anything is written.
+func (s *Server) handleAddRepos(w http.ResponseWriter, r *http.Request) {
+	var reqs []repoRequest
+	if !decode(w, r, &reqs) {
+		return
+	}
+	type target struct{ owner, name string }
+	targets := make([]target, len(reqs))
+	for i, req := range reqs {
+		owner, name, err := parseRepo(req.Repo)
+		if err == nil {
+			err = req.validate()
+		}
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("item %d: %v", i, err))
+			return
+		}
+		targets[i] = target{owner, name}
+	}
+
+	saved := make([]repository, 0, len(reqs))
+	for i, req := range reqs {
+		existing, err := s.findRepo(r.Context(), targets[i].owner, targets[i].name)
+		if err != nil && err != errNotFound {
+			s.internalError(w, r, err)
+			return
+		}
+		repo, err := s.applyRepo(r.Context(), targets[i].owner, targets[i].name, existing, req)
+		if err != nil {
+			s.internalError(w, r, err)
+			return
+		}
+		saved = append(saved, repo)
+	}
+	s.logger.Info("Admin API: repositories saved", "count", len(saved))
+	writeJSON(w, http.StatusOK, saved)
+}
+
+func (s *Server) handleUpdateRepo(w http.ResponseWriter, r *http.Request) {
+	existing, ok := s.lookupRepo(w, r)
+	if !ok {
+		return
+	}
+	var req repoRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if req.Repo != "" {
+		writeError(w, http.StatusBadRequest, "repo cannot be changed, remove the repository and add it again")
+		return
+	}
+	if err := req.validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	repo, err := s.applyRepo(r.Context(), existing.Owner, existing.Name, existing, req)
+	if err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+	s.logger.Info("Admin API: repository updated", "repo", repo.Repo)
+	writeJSON(w, http.StatusOK, repo)
+}
+
+func (s *Server) handleDeleteRepo(w http.ResponseWriter, r *http.Request) {
+	repo, ok := s.lookupRepo(w, r)
+	if !ok {
+		return
+	}
+	if err := s.store.RemoveRepository(r.Context(), repo.Owner, repo.Name); err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+	s.logger.Info("Admin API: repository removed", "repo", repo.Owner+"/"+repo.Name)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleRepoReleases(w http.ResponseWriter, r *http.Request) {
+	repo, ok := s.lookupRepo(w, r)
+	if !ok {
+		return
+	}
+	limit, ok := parseLimit(w, r)
+	if !ok {
+		return
+	}
+
+	history, err := s.store.ListReleaseHistory(r.Context(), repo.Owner, repo.Name, limit)
+	if err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, releaseList(history))
+}
+
+// applyRepo tracks a repository and applies the requested changes; existing
+// is the repository as stored, zero when it is new
+func (s *Server) applyRepo(ctx context.Context, owner, name string, existing db.Repository, req repoRequest) (repository, error) {
+	if existing.Owner != "" {
+		// Keep the stored spelling so the name matches its history
+		owner, name = existing.Owner, existing.Name
+	}
+	prereleases := existing.TrackPrereleases
+	if req.Prereleases != nil {
+		prereleases = *req.Prereleases
+	}
+
+	if existing.Owner == "" || prereleases != existing.TrackPrereleases {
+		if err := s.store.AddRepository(ctx, owner, name, prereleases); err != nil {
+			return repository{}, err
+		}
+	}
+	if req.IntervalMinutes != nil {
+		interval := time.Duration(*req.IntervalMinutes) * time.Minute
+		if err := s.store.SetRepoInterval(ctx, owner, name, interval); err != nil {
+			return repository{}, err
+		}
+	}
+	return s.describeRepo(ctx, db.Repository{Owner: owner, Name: name, TrackPrereleases: prereleases})
+}
+
+// describeRepo adds the polling schedule to a repository
+func (s *Server) describeRepo(ctx context.Context, repo db.Repository) (repository, error) {
+	sch, err := s.store.GetRepoSchedule(ctx, repo.Owner, repo.Name)
+	if err != nil {
+		return repository{}, err
+	}
+	return repository{
+		Repo:            repo.Owner + "/" + repo.Name,
+		Owner:           repo.Owner,
+		Name:            repo.Name,
+		Prereleases:     repo.TrackPrereleases,
+		IntervalMinutes: int(sch.IntervalOverride / time.Minute),
+		NextCheckAt:     optionalTime(sch.NextCheckAt),
+		LastCheckedAt:   optionalTime(sch.LastCheckedAt),
+		LastReleaseAt:   optionalTime(sch.LastReleaseAt),
+	}, nil
+}
+
+// findRepo returns a tracked repository, matching its name case-insensitively
+func (s *Server) findRepo(ctx context.Context, owner, name string) (db.Repository, error) {
+	repos, err := s.store.ListRepositories(ctx)
+	if err != nil {
+		return db.Repository{}, err
+	}
+	for _, repo := range repos {
+		if strings.EqualFold(repo.Owner, owner) && strings.EqualFold(repo.Name, name) {
+			return repo, nil
+		}
+	}
+	return db.Repository{}, errNotFound
+}
+
+// lookupRepo finds the repository named by the path, answering 404 when it is not tracked
+func (s *Server) lookupRepo(w http.ResponseWriter, r *http.Request) (db.Repository, bool) {
+	owner, name := r.PathValue("owner"), r.PathValue("name")
+	repo, err := s.findRepo(r.Context(), owner, name)
+	switch {
+	case err == errNotFound:
+		writeError(w, http.StatusNotFound, fmt.Sprintf("repository %s/%s is not tracked", owner, name))
+		return repo, false
+	case err != nil:
+		s.internalError(w, r, err)
+		return repo, false
+	}
+	return repo, true
+}
+
+// parseLimit reads the limit query parameter, 20 by default and at most 100
+func parseLimit(w http.ResponseWriter, r *http.Request) (int, bool) {
+	value := r.URL.Query().Get("limit")
+	if value == "" {
+		return 20, true
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 1 || limit > 100 {
+		writeError(w, http.StatusBadRequest, "limit must be a number from 1 to 100")
+		return 0, false
+	}
+	return limit, true
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,213 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/yourorg/tg-release-bot/internal/db"
+	"github.com/yourorg/tg-release-bot/internal/scheduler"
+)
+
+// Prefix is the path under which the API is served
+const Prefix = "/api/v1"
+
+// maxBodyBytes limits request bodies
+const maxBodyBytes = 1 << 20
+
+//go:embed openapi.yaml
+var openAPISpec []byte
+
+// Store is the part of db.Storage the API manages; it is the same store
+// telegram.Bot works on, so changes made here and with chat commands agree
+type Store interface {
+	AddRepository(ctx context.Context, owner, name string, trackPrereleases bool) error
+	RemoveRepository(ctx context.Context, owner, name string) error
+	ListRepositories(ctx context.Context) ([]db.Repository, error)
+	GetRepoSchedule(ctx context.Context, repoOwner, repoName string) (db.RepoSchedule, error)
+	SetRepoInterval(ctx context.Context, repoOwner, repoName string, interval time.Duration) error
+
+	AddChat(ctx context.Context, chatID int64, title, language string) error
+	RemoveChat(ctx context.Context, chatID int64) error
+	ListChats(ctx context.Context) ([]db.Chat, error)
+
+	AddSubscription(ctx context.Context, chatID int64, repoOwner, repoName string) error
+	RemoveSubscription(ctx context.Context, chatID int64, repoOwner, repoName string) error
+	ListSubscriptions(ctx context.Context, chatID int64) ([]db.Subscription, error)
+
+	GetSetting(ctx context.Context, key string) (string, error)
+	SetSetting(ctx context.Context, key, value string) error
+	DeleteSetting(ctx context.Context, key string) error
+	ListSettings(ctx context.Context) (map[string]string, error)
+
+	ListReleaseHistory(ctx context.Context, repoOwner, repoName string, limit int) ([]db.ProcessedRelease, error)
+}
+
+// JobRunner triggers release checks and reports their status
+type JobRunner interface {
+	TriggerCheck(ctx context.Context) error
+	Status() scheduler.Status
+}
+
+var (
+	_ Store     = db.Storage(nil)
+	_ JobRunner = (*scheduler.Scheduler)(nil)
+)
+
+// Options configures the API server
+type Options struct {
+	// Token returns the bearer token requests must carry; the API is
+	// disabled while it is empty. It is read on every request so a
+	// configuration reload takes effect immediately.
+	Token func() string
+	// ConfiguredSubscriptions returns the subscriptions of a chat from the
+	// configuration file, which the API shows but cannot remove
+	ConfiguredSubscriptions func(chatID int64) []string
+	// InternalSetting reports whether a settings key holds state the bot
+	// keeps for itself, which the API shows but does not let clients change
+	InternalSetting func(key string) bool
+}
+
+// Server serves the admin REST API
+type Server struct {
+	store   Store
+	jobs    JobRunner
+	opts    Options
+	logger  *slog.Logger
+	handler http.Handler
+}
+
+// New creates the admin API server
+func New(store Store, jobs JobRunner, opts Options, logger *slog.Logger) *Server {
+	if opts.Token == nil {
+		opts.Token = func() string { return "" }
+	}
+	if opts.ConfiguredSubscriptions == nil {
+		opts.ConfiguredSubscriptions = func(int64) []string { return nil }
+	}
+	if opts.InternalSetting == nil {
+		opts.InternalSetting = func(string) bool { return false }
+	}
+
+	s := &Server{store: store, jobs: jobs, opts: opts, logger: logger}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+Prefix+"/openapi.yaml", s.handleOpenAPI)
+
+	mux.Handle("GET "+Prefix+"/repos", s.auth(s.handleListRepos))
+	mux.Handle("POST "+Prefix+"/repos", s.auth(s.handleAddRepo))
+	mux.Handle("POST "+Prefix+"/repos/batch", s.auth(s.handleAddRepos))
+	mux.Handle("GET "+Prefix+"/repos/{owner}/{name}", s.auth(s.handleGetRepo))
+	mux.Handle("PATCH "+Prefix+"/repos/{owner}/{name}", s.auth(s.handleUpdateRepo))
+	mux.Handle("DELETE "+Prefix+"/repos/{owner}/{name}", s.auth(s.handleDeleteRepo))
+	mux.Handle("GET "+Prefix+"/repos/{owner}/{name}/releases", s.auth(s.handleRepoReleases))
+
+	mux.Handle("GET "+Prefix+"/chats", s.auth(s.handleListChats))
+	mux.Handle("POST "+Prefix+"/chats", s.auth(s.handleAddChat))
+	mux.Handle("GET "+Prefix+"/chats/{id}", s.auth(s.handleGetChat))
+	mux.Handle("PATCH "+Prefix+"/chats/{id}", s.auth(s.handleUpdateChat))
+	mux.Handle("DELETE "+Prefix+"/chats/{id}", s.auth(s.handleDeleteChat))
+	mux.Handle("GET "+Prefix+"/chats/{id}/subscriptions", s.auth(s.handleListSubscriptions))
+	mux.Handle("PUT "+Prefix+"/chats/{id}/subscriptions/{owner}/{name}", s.auth(s.handleSubscribe))
+	mux.Handle("DELETE "+Prefix+"/chats/{id}/subscriptions/{owner}/{name}", s.auth(s.handleUnsubscribe))
+
+	mux.Handle("GET "+Prefix+"/settings", s.auth(s.handleListSettings))
+	mux.Handle("GET "+Prefix+"/settings/{key}", s.auth(s.handleGetSetting))
+	mux.Handle("PUT "+Prefix+"/settings/{key}", s.auth(s.handlePutSetting))
+	mux.Handle("DELETE "+Prefix+"/settings/{key}", s.auth(s.handleDeleteSetting))
+
+	mux.Handle("GET "+Prefix+"/releases", s.auth(s.handleListReleases))
+	mux.Handle("GET "+Prefix+"/check", s.auth(s.handleCheckStatus))
+	mux.Handle("POST "+Prefix+"/check", s.auth(s.handleTriggerCheck))
+
+	mux.HandleFunc(Prefix+"/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "no such endpoint, see "+Prefix+"/openapi.yaml")
+	})
+	s.handler = mux
+	return s
+}
+
+// ServeHTTP implements http.Handler for all paths under Prefix
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handler.ServeHTTP(w, r)
+}
+
+// auth rejects requests without the configured bearer token
+func (s *Server) auth(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := s.opts.Token()
+		if token == "" {
+			writeError(w, http.StatusNotFound, "admin API is disabled, set ADMIN_API_TOKEN")
+			return
+		}
+
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="tg-release-bot"`)
+			writeError(w, http.StatusUnauthorized, "missing or invalid bearer token")
+			return
+		}
+		next(w, r)
+	})
+}
+
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/yaml")
+	w.Write(openAPISpec)
+}
+
+// apiError is the body of every error response
+type apiError struct {
+	Error string `json:"error"`
+}
+
+// errNotFound is returned by lookups of rows that do not exist
+var errNotFound = errors.New("not found")
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, apiError{Error: msg})
+}
+
+// internalError logs a store failure and answers 500 without its details
+func (s *Server) internalError(w http.ResponseWriter, r *http.Request, err error) {
+	s.logger.Error("Admin API request failed", "method", r.Method, "path", r.URL.Path, "error", err)
+	writeError(w, http.StatusInternalServerError, "internal error")
+}
+
+// decode reads a JSON request body into v, rejecting unknown fields
+func decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		msg := "invalid JSON body: " + err.Error()
+		if errors.Is(err, io.EOF) {
+			msg = "request body is empty"
+		}
+		writeError(w, http.StatusBadRequest, msg)
+		return false
+	}
+	return true
+}
+
+// parseRepo splits owner/name
+func parseRepo(repo string) (owner, name string, err error) {
+	owner, name, ok := strings.Cut(strings.TrimSpace(repo), "/")
+	if !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+		return "", "", fmt.Errorf("invalid repository %q, want owner/name", repo)
+	}
+	return owner, name, nil
+}
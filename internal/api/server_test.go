@@ -0,0 +1,407 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourorg/tg-release-bot/internal/db"
+	"github.com/yourorg/tg-release-bot/internal/scheduler"
+)
+
+const testToken = "secret"
+
+// fakeStore is an in-memory Store
+type fakeStore struct {
+	repos     map[string]db.Repository // keyed by owner/name
+	intervals map[string]time.Duration
+	chats     map[int64]db.Chat
+	subs      map[int64][]db.Subscription
+	settings  map[string]string
+	writes    int
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		repos:     map[string]db.Repository{},
+		intervals: map[string]time.Duration{},
+		chats:     map[int64]db.Chat{},
+		subs:      map[int64][]db.Subscription{},
+		settings:  map[string]string{},
+	}
+}
+
+func (f *fakeStore) AddRepository(ctx context.Context, owner, name string, trackPrereleases bool) error {
+	f.writes++
+	f.repos[owner+"/"+name] = db.Repository{Owner: owner, Name: name, TrackPrereleases: trackPrereleases}
+	return nil
+}
+
+func (f *fakeStore) RemoveRepository(ctx context.Context, owner, name string) error {
+	f.writes++
+	delete(f.repos, owner+"/"+name)
+	delete(f.intervals, owner+"/"+name)
+	return nil
+}
+
+func (f *fakeStore) ListRepositories(ctx context.Context) ([]db.Repository, error) {
+	var repos []db.Repository
+	for _, repo := range f.repos {
+		repos = append(repos, repo)
+	}
+	sort.Slice(repos, func(i, j int) bool { return repos[i].Owner+"/"+repos[i].Name < repos[j].Owner+"/"+repos[j].Name })
+	return repos, nil
+}
+
+func (f *fakeStore) GetRepoSchedule(ctx context.Context, repoOwner, repoName string) (db.RepoSchedule, error) {
+	return db.RepoSchedule{RepoOwner: repoOwner, RepoName: repoName, IntervalOverride: f.intervals[repoOwner+"/"+repoName]}, nil
+}
+
+func (f *fakeStore) SetRepoInterval(ctx context.Context, repoOwner, repoName string, interval time.Duration) error {
+	f.writes++
+	f.intervals[repoOwner+"/"+repoName] = interval
+	return nil
+}
+
+func (f *fakeStore) AddChat(ctx context.Context, chatID int64, title, language string) error {
+	f.writes++
+	f.chats[chatID] = db.Chat{ID: chatID, Title: title, Language: language}
+	return nil
+}
+
+func (f *fakeStore) RemoveChat(ctx context.Context, chatID int64) error {
+	f.writes++
+	delete(f.chats, chatID)
+	delete(f.subs, chatID)
+	return nil
+}
+
+func (f *fakeStore) ListChats(ctx context.Context) ([]db.Chat, error) {
+	var chats []db.Chat
+	for _, c := range f.chats {
+		chats = append(chats, c)
+	}
+	sort.Slice(chats, func(i, j int) bool { return chats[i].ID < chats[j].ID })
+	return chats, nil
+}
+
+func (f *fakeStore) AddSubscription(ctx context.Context, chatID int64, repoOwner, repoName string) error {
+	f.writes++
+	f.subs[chatID] = append(f.subs[chatID], db.Subscription{ChatID: chatID, RepoOwner: repoOwner, RepoName: repoName})
+	return nil
+}
+
+func (f *fakeStore) RemoveSubscription(ctx context.Context, chatID int64, repoOwner, repoName string) error {
+	f.writes++
+	subs := f.subs[chatID][:0]
+	for _, sub := range f.subs[chatID] {
+		if sub.RepoOwner != repoOwner || sub.RepoName != repoName {
+			subs = append(subs, sub)
+		}
+	}
+	f.subs[chatID] = subs
+	return nil
+}
+
+func (f *fakeStore) ListSubscriptions(ctx context.Context, chatID int64) ([]db.Subscription, error) {
+	return f.subs[chatID], nil
+}
+
+func (f *fakeStore) GetSetting(ctx context.Context, key string) (string, error) {
+	return f.settings[key], nil
+}
+
+func (f *fakeStore) SetSetting(ctx context.Context, key, value string) error {
+	f.writes++
+	f.settings[key] = value
+	return nil
+}
+
+func (f *fakeStore) DeleteSetting(ctx context.Context, key string) error {
+	f.writes++
+	delete(f.settings, key)
+	return nil
+}
+
+func (f *fakeStore) ListSettings(ctx context.Context) (map[string]string, error) {
+	return f.settings, nil
+}
+
+func (f *fakeStore) ListReleaseHistory(ctx context.Context, repoOwner, repoName string, limit int) ([]db.ProcessedRelease, error) {
+	return nil, nil
+}
+
+// fakeJobs is a JobRunner counting triggers
+type fakeJobs struct {
+	triggered int
+}
+
+func (f *fakeJobs) TriggerCheck(ctx context.Context) error {
+	f.triggered++
+	return nil
+}
+
+func (f *fakeJobs) Status() scheduler.Status {
+	return scheduler.Status{Runs: f.triggered}
+}
+
+type testAPI struct {
+	t      *testing.T
+	server *Server
+	store  *fakeStore
+	jobs   *fakeJobs
+}
+
+func newTestAPI(t *testing.T, opts Options) *testAPI {
+	if opts.Token == nil {
+		opts.Token = func() string { return testToken }
+	}
+	store, jobs := newFakeStore(), &fakeJobs{}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return &testAPI{t: t, server: New(store, jobs, opts, logger), store: store, jobs: jobs}
+}
+
+// do sends a request with the test token and returns the recorded response
+func (a *testAPI) do(method, path, body string) *httptest.ResponseRecorder {
+	a.t.Helper()
+	return a.doWithToken(method, path, body, testToken)
+}
+
+func (a *testAPI) doWithToken(method, path, body, token string) *httptest.ResponseRecorder {
+	a.t.Helper()
+	req := httptest.NewRequest(method, Prefix+path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	a.server.ServeHTTP(rec, req)
+	return rec
+}
+
+// expect checks the status of a request and returns its response
+func (a *testAPI) expect(method, path, body string, status int) *httptest.ResponseRecorder {
+	a.t.Helper()
+	rec := a.do(method, path, body)
+	if rec.Code != status {
+		a.t.Errorf("%s %s = %d, want %d: %s", method, path, rec.Code, status, rec.Body)
+	}
+	return rec
+}
+
+func TestAuth(t *testing.T) {
+	disabled := newTestAPI(t, Options{Token: func() string { return "" }})
+	if rec := disabled.do("GET", "/repos", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("request with the API disabled = %d, want 404", rec.Code)
+	}
+
+	a := newTestAPI(t, Options{})
+	tests := []struct {
+		name   string
+		header string
+		want   int
+	}{
+		{"no header", "", http.StatusUnauthorized},
+		{"wrong token", "Bearer nope", http.StatusUnauthorized},
+		{"not a bearer token", "Basic " + testToken, http.StatusUnauthorized},
+		{"valid token", "Bearer " + testToken, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", Prefix+"/repos", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			a.server.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if tt.want == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Error("401 without a WWW-Authenticate header")
+			}
+		})
+	}
+
+	if rec := a.doWithToken("GET", "/openapi.yaml", "", ""); rec.Code != http.StatusOK {
+		t.Errorf("OpenAPI spec without a token = %d, want 200", rec.Code)
+	}
+}
+
+func TestRepoCRUD(t *testing.T) {
+	a := newTestAPI(t, Options{})
+
+	a.expect("POST", "/repos", `{"repo": "golang/go"}`, http.StatusCreated)
+	a.expect("POST", "/repos", `{"repo": "golang/go", "prereleases": true}`, http.StatusOK)
+	a.expect("POST", "/repos", `{"repo": "golang"}`, http.StatusBadRequest)
+	a.expect("POST", "/repos", `{"repo": "a/b", "interval_minutes": -1}`, http.StatusBadRequest)
+	a.expect("POST", "/repos", ``, http.StatusBadRequest)
+
+	var repo repository
+	rec := a.expect("GET", "/repos/Golang/GO", "", http.StatusOK)
+	if err := json.NewDecoder(rec.Body).Decode(&repo); err != nil {
+		t.Fatal(err)
+	}
+	if repo.Repo != "golang/go" || !repo.Prereleases {
+		t.Errorf("GET repository = %+v", repo)
+	}
+
+	a.expect("PATCH", "/repos/golang/go", `{"interval_minutes": 30}`, http.StatusOK)
+	if got := a.store.intervals["golang/go"]; got != 30*time.Minute {
+		t.Errorf("interval = %s, want 30m", got)
+	}
+	a.expect("PATCH", "/repos/golang/go", `{"repo": "golang/tools"}`, http.StatusBadRequest)
+	a.expect("PATCH", "/repos/golang/tools", `{"prereleases": true}`, http.StatusNotFound)
+
+	a.expect("GET", "/repos/golang/go/releases?limit=0", "", http.StatusBadRequest)
+	a.expect("GET", "/repos/golang/go/releases", "", http.StatusOK)
+
+	a.expect("DELETE", "/repos/golang/go", "", http.StatusNoContent)
+	a.expect("DELETE", "/repos/golang/go", "", http.StatusNotFound)
+	a.expect("GET", "/repos/golang/go", "", http.StatusNotFound)
+}
+
+func TestChatCRUD(t *testing.T) {
+	a := newTestAPI(t, Options{})
+
+	a.expect("POST", "/chats", `{"title": "Ops"}`, http.StatusBadRequest)
+	a.expect("POST", "/chats", `{"id": -100}`, http.StatusCreated)
+	if c := a.store.chats[-100]; c.Title != "Chat -100" || c.Language != "ru" {
+		t.Errorf("new chat = %+v, want the /setchat defaults", c)
+	}
+	a.expect("POST", "/chats", `{"id": -100, "language": "en"}`, http.StatusOK)
+
+	a.expect("GET", "/chats/-100", "", http.StatusOK)
+	a.expect("GET", "/chats/abc", "", http.StatusBadRequest)
+	a.expect("GET", "/chats/42", "", http.StatusNotFound)
+
+	a.expect("PATCH", "/chats/-100", `{"id": 42}`, http.StatusBadRequest)
+	a.expect("PATCH", "/chats/-100", `{"title": "Releases"}`, http.StatusOK)
+	if c := a.store.chats[-100]; c.Title != "Releases" || c.Language != "en" {
+		t.Errorf("updated chat = %+v", c)
+	}
+
+	a.expect("DELETE", "/chats/-100", "", http.StatusNoContent)
+	a.expect("DELETE", "/chats/-100", "", http.StatusNotFound)
+}
+
+func TestSubscriptionCRUD(t *testing.T) {
+	a := newTestAPI(t, Options{ConfiguredSubscriptions: func(chatID int64) []string {
+		if chatID == -100 {
+			return []string{"kubernetes/kubernetes"}
+		}
+		return nil
+	}})
+	a.expect("POST", "/chats", `{"id": -100}`, http.StatusCreated)
+	a.expect("POST", "/repos", `{"repo": "golang/go"}`, http.StatusCreated)
+
+	a.expect("PUT", "/chats/-100/subscriptions/golang/tools", "", http.StatusNotFound)
+	a.expect("PUT", "/chats/42/subscriptions/golang/go", "", http.StatusNotFound)
+	a.expect("PUT", "/chats/-100/subscriptions/golang/go", "", http.StatusNoContent)
+
+	var subs []subscription
+	rec := a.expect("GET", "/chats/-100/subscriptions", "", http.StatusOK)
+	if err := json.NewDecoder(rec.Body).Decode(&subs); err != nil {
+		t.Fatal(err)
+	}
+	want := []subscription{{"kubernetes/kubernetes", sourceConfig}, {"golang/go", sourceAPI}}
+	if len(subs) != len(want) || subs[0] != want[0] || subs[1] != want[1] {
+		t.Errorf("subscriptions = %+v, want %+v", subs, want)
+	}
+
+	a.expect("DELETE", "/chats/-100/subscriptions/kubernetes/kubernetes", "", http.StatusConflict)
+	a.expect("DELETE", "/chats/-100/subscriptions/golang/tools", "", http.StatusNotFound)
+	a.expect("DELETE", "/chats/-100/subscriptions/Golang/Go", "", http.StatusNoContent)
+	if len(a.store.subs[-100]) != 0 {
+		t.Errorf("subscription was not removed: %+v", a.store.subs[-100])
+	}
+}
+
+func TestBatchRejectsWholeBatch(t *testing.T) {
+	a := newTestAPI(t, Options{})
+
+	rec := a.expect("POST", "/repos/batch", `[{"repo": "golang/go"}, {"repo": "invalid"}, {"repo": "a/b"}]`, http.StatusBadRequest)
+	if !strings.Contains(rec.Body.String(), "item 1") {
+		t.Errorf("error does not name the invalid item: %s", rec.Body)
+	}
+	a.expect("POST", "/repos/batch", `[{"repo": "golang/go"}, {"repo": "a/b", "interval_minutes": -5}]`, http.StatusBadRequest)
+	if a.store.writes != 0 || len(a.store.repos) != 0 {
+		t.Errorf("invalid batches wrote to the store: %d writes, repos %v", a.store.writes, a.store.repos)
+	}
+
+	a.expect("POST", "/repos/batch", `[{"repo": "golang/go"}, {"repo": "a/b", "interval_minutes": 5}]`, http.StatusOK)
+	if len(a.store.repos) != 2 {
+		t.Errorf("valid batch saved %d repositories, want 2", len(a.store.repos))
+	}
+}
+
+func TestUnknownFieldsRejected(t *testing.T) {
+	a := newTestAPI(t, Options{})
+	a.expect("POST", "/chats", `{"id": -100}`, http.StatusCreated)
+	a.expect("POST", "/repos", `{"repo": "golang/go"}`, http.StatusCreated)
+	writes := a.store.writes
+
+	tests := []struct {
+		method, path, body string
+	}{
+		{"POST", "/repos", `{"repo": "a/b", "track_prereleases": true}`},
+		{"POST", "/repos/batch", `[{"repo": "a/b", "interval": 5}]`},
+		{"PATCH", "/repos/golang/go", `{"prerelease": true}`},
+		{"POST", "/chats", `{"id": -200, "lang": "en"}`},
+		{"PATCH", "/chats/-100", `{"name": "Ops"}`},
+		{"PUT", "/settings/language", `{"value": "en", "scope": "global"}`},
+	}
+	for _, tt := range tests {
+		rec := a.expect(tt.method, tt.path, tt.body, http.StatusBadRequest)
+		if !strings.Contains(rec.Body.String(), "unknown field") {
+			t.Errorf("%s %s: error does not name the unknown field: %s", tt.method, tt.path, rec.Body)
+		}
+	}
+	if a.store.writes != writes {
+		t.Errorf("requests with unknown fields wrote to the store %d times", a.store.writes-writes)
+	}
+}
+
+func TestSettingsAndCheck(t *testing.T) {
+	a := newTestAPI(t, Options{})
+
+	a.expect("GET", "/settings/language", "", http.StatusNotFound)
+	a.expect("PUT", "/settings/language", `{"value": ""}`, http.StatusBadRequest)
+	a.expect("PUT", "/settings/language", `{"value": "en"}`, http.StatusOK)
+	a.expect("GET", "/settings/language", "", http.StatusOK)
+	a.expect("DELETE", "/settings/language", "", http.StatusNoContent)
+
+	a.expect("POST", "/check", "", http.StatusAccepted)
+	if a.jobs.triggered != 1 {
+		t.Errorf("check triggered %d times, want 1", a.jobs.triggered)
+	}
+	a.expect("GET", "/nope", "", http.StatusNotFound)
+}
+
+func TestInternalSettingsAreReadOnly(t *testing.T) {
+	a := newTestAPI(t, Options{
+		InternalSetting: func(key string) bool { return key == "repositories.applied" },
+	})
+	a.store.settings["repositories.applied"] = `[{"owner":"golang","name":"go"}]`
+
+	a.expect("GET", "/settings/repositories.applied", "", http.StatusOK)
+	rec := a.expect("PUT", "/settings/repositories.applied", `{"value": "[]"}`, http.StatusForbidden)
+	if !strings.Contains(rec.Body.String(), "managed by the bot") {
+		t.Errorf("error does not explain the rejection: %s", rec.Body)
+	}
+	a.expect("DELETE", "/settings/repositories.applied", "", http.StatusForbidden)
+	if a.store.writes != 0 {
+		t.Errorf("changes to an internal setting wrote to the store %d times", a.store.writes)
+	}
+	if got := a.store.settings["repositories.applied"]; got != `[{"owner":"golang","name":"go"}]` {
+		t.Errorf("internal setting changed to %q", got)
+	}
+
+	a.expect("PUT", "/settings/language", `{"value": "en"}`, http.StatusOK)
+}
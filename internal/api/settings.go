@@ -0,0 +1,82 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// setting is a stored key/value setting
+type setting struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+func (s *Server) handleListSettings(w http.ResponseWriter, r *http.Request) {
+	settings, err := s.store.ListSettings(r.Context())
+	if err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, settings)
+}
+
+func (s *Server) handleGetSetting(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+	value, err := s.store.GetSetting(r.Context(), key)
+	if err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+	// The store does not tell a missing setting from an empty one
+	if value == "" {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("setting %q is not set", key))
+		return
+	}
+	writeJSON(w, http.StatusOK, setting{Key: key, Value: value})
+}
+
+func (s *Server) handlePutSetting(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+	if !s.writableSetting(w, key) {
+		return
+	}
+	var req struct {
+		Value *string `json:"value"`
+	}
+	if !decode(w, r, &req) {
+		return
+	}
+	if req.Value == nil || *req.Value == "" {
+		writeError(w, http.StatusBadRequest, "value is required, delete the setting to clear it")
+		return
+	}
+
+	if err := s.store.SetSetting(r.Context(), key, *req.Value); err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+	s.logger.Info("Admin API: setting saved", "key", key)
+	writeJSON(w, http.StatusOK, setting{Key: key, Value: *req.Value})
+}
+
+func (s *Server) handleDeleteSetting(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+	if !s.writableSetting(w, key) {
+		return
+	}
+	if err := s.store.DeleteSetting(r.Context(), key); err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+	s.logger.Info("Admin API: setting deleted", "key", key)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writableSetting rejects changes to settings the bot manages itself
+func (s *Server) writableSetting(w http.ResponseWriter, key string) bool {
+	if s.opts.InternalSetting(key) {
+		writeError(w, http.StatusForbidden, fmt.Sprintf("setting %q is managed by the bot", key))
+		return false
+	}
+	return true
+}
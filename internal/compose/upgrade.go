@@ -0,0 +1,146 @@
+package compose
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// breakingKeywords mark changelog lines that matter when upgrading
+var breakingKeywords = []string{
+	"breaking",
+	"deprecat",
+	"removed",
+	"migrat",
+	"incompatib",
+	"action required",
+	"upgrade note",
+	"urgent upgrade",
+}
+
+var headingRe = regexp.MustCompile(`^\s*#{1,6}\s+(.+)$`)
+
+// UpgradeRelease is a single release on the upgrade path
+type UpgradeRelease struct {
+	Tag    string
+	URL    string
+	BodyMD string
+}
+
+// UpgradeInput data for composing an upgrade-path message
+type UpgradeInput struct {
+	RepoFull  string
+	From      string           // version the chat runs
+	Releases  []UpgradeRelease // releases after From, oldest first; the last one is the new release
+	Published time.Time
+	Advisor   string // optional LLM advice
+}
+
+// BuildUpgradeHTML creates an HTML message summarising all releases between
+// the pinned version and the new release
+func BuildUpgradeHTML(in UpgradeInput, opt Options) string {
+	if len(in.Releases) == 0 {
+		return ""
+	}
+
+	loc, _ := time.LoadLocation(opt.TimeZone)
+	if loc == nil {
+		loc = time.UTC
+	}
+	latest := in.Releases[len(in.Releases)-1]
+
+	var sb strings.Builder
+	sb.WriteString("⬆️ <b>")
+	sb.WriteString(html.EscapeString(in.RepoFull))
+	sb.WriteString("</b> <code>" + html.EscapeString(in.From) + "</code> → ")
+	sb.WriteString(`<a href="` + latest.URL + `">` + html.EscapeString(latest.Tag) + "</a>\n")
+	sb.WriteString("📅 " + in.Published.In(loc).Format("2006-01-02 15:04") + "\n")
+
+	if len(in.Releases) > 1 {
+		tags := make([]string, 0, len(in.Releases))
+		for _, r := range in.Releases {
+			tags = append(tags, `<a href="`+r.URL+`">`+html.EscapeString(r.Tag)+"</a>")
+		}
+		sb.WriteString(fmt.Sprintf("\n📦 Релизов с вашей версии: %d\n%s\n", len(in.Releases), strings.Join(tags, ", ")))
+	}
+
+	var breaking []string
+	for _, r := range in.Releases {
+		for _, line := range TakeBreaking(r.BodyMD, opt.MaxBullets) {
+			breaking = append(breaking, "<code>"+html.EscapeString(r.Tag)+"</code> "+line)
+		}
+	}
+	if len(breaking) > 0 {
+		sb.WriteString("\n⚠️ <b>Breaking changes и миграция:</b>")
+		maxToShow := min(len(breaking), opt.MaxBullets)
+		for i := 0; i < maxToShow; i++ {
+			sb.WriteString("\n▪️ " + breaking[i])
+		}
+		if len(breaking) > maxToShow {
+			sb.WriteString(fmt.Sprintf("\n<i>... и ещё %d</i>", len(breaking)-maxToShow))
+		}
+		sb.WriteString("\n")
+	} else {
+		sb.WriteString("\n✅ Явных breaking changes в release notes не найдено\n")
+	}
+
+	sb.WriteString("\n<a href=\"" + latest.URL + "\">📖 Полный changelog</a>")
+
+	if strings.TrimSpace(in.Advisor) != "" {
+		sb.WriteString("\n\n💡 " + html.EscapeString(in.Advisor))
+	}
+
+	return sanitizeUTF8(sb.String())
+}
+
+// TakeBreaking extracts changelog lines about breaking changes, deprecations
+// and migrations. Every line under a heading mentioning them is included.
+func TakeBreaking(md string, maxLines int) []string {
+	var lines []string
+	inSection := false
+
+	for _, line := range strings.Split(md, "\n") {
+		if m := headingRe.FindStringSubmatch(line); m != nil {
+			inSection = hasBreakingKeyword(m[1])
+			continue
+		}
+
+		text := strings.TrimSpace(line)
+		if text == "" {
+			continue
+		}
+		if !inSection && !hasBreakingKeyword(text) {
+			continue
+		}
+
+		if m := bulletRe.FindStringSubmatch(line); m != nil {
+			text = m[1]
+		}
+		text = stripFormatting(text)
+		if len(text) < 10 {
+			continue
+		}
+		if len(text) > 200 {
+			text = text[:200] + "…"
+		}
+
+		lines = append(lines, text)
+		if maxLines > 0 && len(lines) >= maxLines {
+			break
+		}
+	}
+
+	return lines
+}
+
+func hasBreakingKeyword(s string) bool {
+	s = strings.ToLower(s)
+	for _, kw := range breakingKeywords {
+		if strings.Contains(s, kw) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+package compose
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildUpgradeHTML(t *testing.T) {
+	published := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+
+	if got := BuildUpgradeHTML(UpgradeInput{RepoFull: "o/r", From: "v1.0.0"}, Options{}); got != "" {
+		t.Errorf("BuildUpgradeHTML without releases = %q, want empty", got)
+	}
+
+	tests := []struct {
+		name   string
+		in     UpgradeInput
+		limit  int
+		want   []string
+		absent []string
+	}{
+		{
+			name: "single release",
+			in: UpgradeInput{
+				RepoFull: "o/r",
+				From:     "v1.0.0",
+				Releases: []UpgradeRelease{{Tag: "v1.1.0", URL: "https://example.com/v1.1.0", BodyMD: "- fix a crash in the parser"}},
+			},
+			want: []string{
+				`⬆️ <b>o/r</b> <code>v1.0.0</code> → <a href="https://example.com/v1.1.0">v1.1.0</a>`,
+				"📅 2026-03-01 09:30",
+				"✅ Явных breaking changes в release notes не найдено",
+			},
+			absent: []string{"Релизов с вашей версии"},
+		},
+		{
+			name: "path across prereleases",
+			in: UpgradeInput{
+				RepoFull: "o/r",
+				From:     "v1.1.0-rc.1",
+				Releases: []UpgradeRelease{
+					{Tag: "v1.1.0-rc.2", URL: "https://example.com/rc2", BodyMD: "## Breaking Changes\n- drop the legacy v1 API & its flags"},
+					{Tag: "v1.1.0", URL: "https://example.com/v1.1.0", BodyMD: "- the --foo flag is deprecated, use --bar"},
+				},
+				Advisor: "upgrade <carefully>",
+			},
+			want: []string{
+				`<code>v1.1.0-rc.1</code> → <a href="https://example.com/v1.1.0">v1.1.0</a>`,
+				"📦 Релизов с вашей версии: 2\n" +
+					`<a href="https://example.com/rc2">v1.1.0-rc.2</a>, <a href="https://example.com/v1.1.0">v1.1.0</a>`,
+				"▪️ <code>v1.1.0-rc.2</code> drop the legacy v1 API &amp; its flags",
+				"▪️ <code>v1.1.0</code> the --foo flag is deprecated, use --bar",
+				"💡 upgrade &lt;carefully&gt;",
+			},
+			absent: []string{"Явных breaking changes"},
+		},
+		{
+			name: "breaking changes over the limit",
+			in: UpgradeInput{
+				RepoFull: "o/r",
+				From:     "v1",
+				Releases: []UpgradeRelease{
+					{Tag: "v2", BodyMD: "## Breaking\n- first removed option\n- second removed option"},
+					{Tag: "v3", BodyMD: "## Breaking\n- third removed option"},
+				},
+			},
+			limit: 2,
+			want:  []string{"first removed option", "second removed option", "... и ещё 1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opt := Options{MaxBullets: 10, TimeZone: "UTC"}
+			if tt.limit > 0 {
+				opt.MaxBullets = tt.limit
+			}
+			tt.in.Published = published
+			got := BuildUpgradeHTML(tt.in, opt)
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("message does not contain %q:\n%s", want, got)
+				}
+			}
+			for _, absent := range tt.absent {
+				if strings.Contains(got, absent) {
+					t.Errorf("message contains %q:\n%s", absent, got)
+				}
+			}
+		})
+	}
+}
+
+func TestTakeBreaking(t *testing.T) {
+	md := `## Breaking Changes
+- removed the deprecated --legacy flag
+- short
+
+## Features
+- new dashboard for releases
+- config migration is now automatic
+* **BREAKING**: the API returns ` + "`v2`" + ` objects`
+
+	got := TakeBreaking(md, 0)
+	want := []string{
+		"removed the deprecated --legacy flag",
+		"config migration is now automatic",
+		"BREAKING: the API returns v2 objects",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("TakeBreaking = %q, want %q", got, want)
+	}
+
+	if got := TakeBreaking(md, 1); len(got) != 1 {
+		t.Errorf("TakeBreaking with a limit of 1 returned %d lines", len(got))
+	}
+}
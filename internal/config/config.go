@@ -1,116 +1,474 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type Config struct {
-	GithubToken         string
-	TelegramToken       string
-	DefaultChatID       int64
-	IntervalMinutes     int
-	TimeZone            string
-	AdvisorEnabled      bool
-	OpenRouterAPIKey    string
-	OpenRouterModel     string
-	AllowedUserIDs      []int64
-	MaxChangelogChars   int
-	MaxBullets          int
-	InitialRepositories []Repository
-	MaxReleaseAgeDays   int
-}
-
-// Repository represents a repository configuration from environment
+	GithubToken             string
+	TelegramToken           string
+	DefaultChatID           int64
+	IntervalMinutes         int
+	MaxIntervalMinutes      int
+	AdaptivePolling         bool
+	PollCron                string
+	PollJitterSecs          int
+	TimeZone                string
+	AdvisorEnabled          bool
+	OpenRouterAPIKey        string
+	OpenRouterModel         string
+	AdvisorFallbacks        []string
+	AdvisorFailures         int
+	AdvisorCooldownSecs     int
+	AdvisorTimeoutSecs      int
+	AdvisorPrices           map[string]ModelPrice
+	AdvisorDailyBudget      float64
+	AdvisorMonthBudget      float64
+	AdvisorContextToks      int
+	AdvisorPromptsDir       string
+	AllowedUserIDs          []int64
+	MaxChangelogChars       int
+	MaxBullets              int
+	InitialRepositories     []Repository
+	MaxReleaseAgeDays       int
+	Workers                 int
+	SchedulerOverlap        string
+	GithubRequestsPerSecond float64
+	RetentionDays           int
+	RetentionKeepPerRepo    int
+	MaintenanceHours        int
+	BackupDir               string
+	BackupHours             int
+	BackupKeep              int
+	ConfigFile              string
+	Chats                   []Chat
+	RepositorySync          string
+	RepositorySyncDryRun    bool
+	HTTPAddr                string
+	ReadyMaxMissedRuns      int
+	AdminAPIToken           string
+	DashboardEnabled        bool
+	DashboardPassword       string
+	TracingExporter         string
+	TracingSampleRatio      float64
+}
+
+// ModelPrice is the price of an LLM model in USD per million tokens
+type ModelPrice struct {
+	Prompt     float64
+	Completion float64
+}
+
+// Repository source modes
+const (
+	SourceReleases = "releases"
+	SourceTags     = "tags"
+)
+
+// Chat delivery modes
+const (
+	DeliveryNotify = "notify"
+	DeliverySilent = "silent"
+)
+
+// Repository sync modes
+const (
+	// RepositorySyncMerge adds configured repositories and applies changes
+	// made to the configured list, keeping changes made with chat commands
+	RepositorySyncMerge = "merge"
+	// RepositorySyncExact makes the tracked repositories match the configured
+	// list, removing the ones that are not listed
+	RepositorySyncExact = "sync"
+)
+
+// Scheduler overlap policies, see scheduler.OverlapPolicy
+const (
+	OverlapSkip  = "skip"
+	OverlapQueue = "queue"
+)
+
+// Tracing exporters, see tracing.Options
+const (
+	TracingNone   = ""
+	TracingOTLP   = "otlp"
+	TracingStdout = "stdout"
+)
+
+// Checks validate settings whose syntax is defined by runtime packages. The
+// caller passes them to Load so config does not depend on those packages; a
+// nil check accepts any value.
+type Checks struct {
+	// Cron parses a POLL_CRON expression evaluated in loc
+	Cron func(expr string, loc *time.Location) error
+}
+
+// Repository represents a repository configuration from the config file or environment
 type Repository struct {
 	Owner            string
 	Name             string
 	TrackPrereleases bool
+	Source           string   // SourceReleases or SourceTags
+	IntervalMinutes  int      // fixed polling interval, 0 for the global or adaptive one
+	Include          []string // tag patterns to notify about, all tags when empty
+	Exclude          []string // tag patterns to skip
+
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+}
+
+// FullName returns owner/name
+func (r Repository) FullName() string {
+	return r.Owner + "/" + r.Name
+}
+
+// MatchesTag reports whether a release tag passes the include and exclude filters
+func (r Repository) MatchesTag(tag string) bool {
+	for _, re := range r.exclude {
+		if re.MatchString(tag) {
+			return false
+		}
+	}
+	if len(r.include) == 0 {
+		return true
+	}
+	for _, re := range r.include {
+		if re.MatchString(tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// compile compiles the tag filters and checks the source mode
+func (r *Repository) compile() error {
+	if r.Source == "" {
+		r.Source = SourceReleases
+	}
+	if r.Source != SourceReleases && r.Source != SourceTags {
+		return fmt.Errorf("repository %s: source must be %q or %q, got %q", r.FullName(), SourceReleases, SourceTags, r.Source)
+	}
+
+	r.include, r.exclude = nil, nil
+	for _, pattern := range r.Include {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("repository %s: include pattern: %w", r.FullName(), err)
+		}
+		r.include = append(r.include, re)
+	}
+	for _, pattern := range r.Exclude {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("repository %s: exclude pattern: %w", r.FullName(), err)
+		}
+		r.exclude = append(r.exclude, re)
+	}
+	return nil
+}
+
+// Chat represents per-chat settings from the config file
+type Chat struct {
+	ID           int64
+	Title        string
+	Language     string
+	TimeZone     string   // for dates in messages, the global time zone when empty
+	Delivery     string   // DeliveryNotify or DeliverySilent
+	Repositories []string // owner/name subscriptions, all repositories when empty
+	Prompt       string   // advisor prompt assigned to the chat
+}
+
+// Subscribed reports whether the chat receives releases of a repository
+func (c Chat) Subscribed(repo string) bool {
+	if len(c.Repositories) == 0 {
+		return true
+	}
+	for _, r := range c.Repositories {
+		if strings.EqualFold(r, repo) {
+			return true
+		}
+	}
+	return false
 }
 
-func Load() (*Config, error) {
+// RepositoryConfig returns the configured settings of a repository
+func (c *Config) RepositoryConfig(owner, name string) (Repository, bool) {
+	for _, r := range c.InitialRepositories {
+		if strings.EqualFold(r.Owner, owner) && strings.EqualFold(r.Name, name) {
+			return r, true
+		}
+	}
+	return Repository{}, false
+}
+
+// ChatConfig returns the configured settings of a chat
+func (c *Config) ChatConfig(id int64) (Chat, bool) {
+	for _, chat := range c.Chats {
+		if chat.ID == id {
+			return chat, true
+		}
+	}
+	return Chat{}, false
+}
+
+// Load reads the configuration from the environment and, when CONFIG_FILE is
+// set, from a YAML or TOML file. Environment variables override file settings;
+// INITIAL_REPOSITORIES entries are merged into the file's repositories. Every
+// setting is validated, with checks for the settings config cannot parse
+// itself; all problems are returned together as a *ValidationError.
+func Load(checks Checks) (*Config, error) {
+	src := &source{}
+	var fc *fileConfig
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		var err error
+		if fc, err = readFile(path); err != nil {
+			src.problem(err.Error())
+		} else {
+			src.file = fc.values()
+		}
+	}
+
 	cfg := &Config{
-		GithubToken:         mustGetEnv("GITHUB_TOKEN"),
-		TelegramToken:       mustGetEnv("TELEGRAM_BOT_TOKEN"),
-		DefaultChatID:       parseInt64(getEnv("DEFAULT_CHAT_ID", "0")),
-		IntervalMinutes:     parseInt(getEnv("POLL_INTERVAL_MINUTES", "10")),
-		TimeZone:            getEnv("TIMEZONE", "Europe/Amsterdam"),
-		AdvisorEnabled:      getEnv("ADVISOR_ENABLED", "0") == "1",
-		OpenRouterAPIKey:    getEnv("OPENROUTER_API_KEY", ""),
-		OpenRouterModel:     getEnv("OPENROUTER_MODEL", "openrouter/anthropic/claude-3-haiku"),
-		AllowedUserIDs:      parseUserIDs(getEnv("ALLOWED_USER_IDS", "")),
-		MaxChangelogChars:   parseInt(getEnv("MAX_CHANGELOG_CHARS", "2500")),
-		MaxBullets:          parseInt(getEnv("MAX_BULLETS", "8")),
-		InitialRepositories: parseRepositories(getEnv("INITIAL_REPOSITORIES", "")),
-		MaxReleaseAgeDays:   parseInt(getEnv("MAX_RELEASE_AGE_DAYS", "30")),
+		GithubToken:             src.required("GITHUB_TOKEN"),
+		TelegramToken:           src.required("TELEGRAM_BOT_TOKEN"),
+		DefaultChatID:           src.int64("DEFAULT_CHAT_ID", "0"),
+		IntervalMinutes:         src.int("POLL_INTERVAL_MINUTES", "10"),
+		MaxIntervalMinutes:      src.int("MAX_POLL_INTERVAL_MINUTES", "720"),
+		AdaptivePolling:         src.bool("ADAPTIVE_POLLING", "1"),
+		PollCron:                src.get("POLL_CRON", ""),
+		PollJitterSecs:          src.int("POLL_JITTER_SECONDS", "0"),
+		TimeZone:                src.get("TIMEZONE", "Europe/Amsterdam"),
+		AdvisorEnabled:          src.bool("ADVISOR_ENABLED", "0"),
+		OpenRouterAPIKey:        src.secret("OPENROUTER_API_KEY"),
+		OpenRouterModel:         src.get("OPENROUTER_MODEL", "openrouter/anthropic/claude-3-haiku"),
+		AdvisorFallbacks:        parseList(src.get("OPENROUTER_FALLBACK_MODELS", "")),
+		AdvisorFailures:         src.int("ADVISOR_BREAKER_FAILURES", "3"),
+		AdvisorCooldownSecs:     src.int("ADVISOR_BREAKER_COOLDOWN_SECONDS", "300"),
+		AdvisorTimeoutSecs:      src.int("ADVISOR_ATTEMPT_TIMEOUT_SECONDS", "8"),
+		AdvisorPrices:           src.prices("ADVISOR_PRICES"),
+		AdvisorDailyBudget:      src.float("ADVISOR_DAILY_BUDGET_USD", "0"),
+		AdvisorMonthBudget:      src.float("ADVISOR_MONTHLY_BUDGET_USD", "0"),
+		AdvisorContextToks:      src.int("ADVISOR_CONTEXT_TOKENS", "3000"),
+		AdvisorPromptsDir:       src.get("ADVISOR_PROMPTS_DIR", ""),
+		AllowedUserIDs:          src.userIDs("ALLOWED_USER_IDS"),
+		MaxChangelogChars:       src.int("MAX_CHANGELOG_CHARS", "2500"),
+		MaxBullets:              src.int("MAX_BULLETS", "8"),
+		InitialRepositories:     src.repositories("INITIAL_REPOSITORIES"),
+		MaxReleaseAgeDays:       src.int("MAX_RELEASE_AGE_DAYS", "30"),
+		Workers:                 src.int("WORKERS", "4"),
+		SchedulerOverlap:        src.get("SCHEDULER_OVERLAP_POLICY", OverlapQueue),
+		GithubRequestsPerSecond: src.float("GITHUB_REQUESTS_PER_SECOND", "5"),
+		RetentionDays:           src.int("RETENTION_DAYS", "180"),
+		RetentionKeepPerRepo:    src.int("RETENTION_KEEP_PER_REPO", "20"),
+		MaintenanceHours:        src.int("MAINTENANCE_INTERVAL_HOURS", "24"),
+		BackupDir:               src.get("BACKUP_DIR", ""),
+		BackupHours:             src.int("BACKUP_INTERVAL_HOURS", "0"),
+		BackupKeep:              src.int("BACKUP_KEEP", "7"),
+		RepositorySync:          src.get("REPOSITORY_SYNC", RepositorySyncMerge),
+		RepositorySyncDryRun:    src.bool("REPOSITORY_SYNC_DRY_RUN", "0"),
+		HTTPAddr:                src.get("HTTP_ADDR", ""),
+		ReadyMaxMissedRuns:      src.int("READY_MAX_MISSED_RUNS", "3"),
+		AdminAPIToken:           src.secret("ADMIN_API_TOKEN"),
+		DashboardEnabled:        src.bool("DASHBOARD_ENABLED", "0"),
+		DashboardPassword:       src.secret("DASHBOARD_PASSWORD"),
+		TracingExporter:         src.get("TRACING_EXPORTER", TracingNone),
+		TracingSampleRatio:      src.float("TRACING_SAMPLE_RATIO", "1"),
 	}
 
+	cfg.ConfigFile = os.Getenv("CONFIG_FILE")
+	if fc != nil {
+		repos, chats := fc.lists(src)
+		cfg.InitialRepositories = mergeRepositories(repos, cfg.InitialRepositories)
+		cfg.Chats = chats
+	}
+	for i := range cfg.InitialRepositories {
+		if err := cfg.InitialRepositories[i].compile(); err != nil {
+			src.problem(err.Error())
+		}
+	}
+
+	cfg.validate(src, checks)
+	if len(src.problems) > 0 {
+		return nil, &ValidationError{Problems: src.problems}
+	}
 	return cfg, nil
 }
 
-func mustGetEnv(key string) string {
-	value := os.Getenv(key)
-	if value == "" {
-		panic("Required environment variable " + key + " is not set")
-	}
-	return value
+// source looks settings up in the environment first, then in the config
+// file, and collects the problems found while parsing them
+type source struct {
+	file     map[string]string
+	problems []string
+	invalid  map[string]bool // keys that failed to parse, skipped by range checks
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
+func (s *source) get(key, defaultValue string) string {
+	value, err := Getenv(key)
+	if err != nil {
+		s.lookupFailed(key, err)
+	}
+	if value != "" {
+		return value
+	}
+	if value, ok := s.file[key]; ok {
 		return value
 	}
 	return defaultValue
 }
 
-func parseInt(s string) int {
-	i, err := strconv.Atoi(s)
+func (s *source) problem(format string, args ...any) {
+	s.problems = append(s.problems, fmt.Sprintf(format, args...))
+}
+
+func (s *source) fail(key, format string, args ...any) {
+	s.markInvalid(key)
+	s.problem(key+": "+format, args...)
+}
+
+func (s *source) markInvalid(key string) {
+	if s.invalid == nil {
+		s.invalid = make(map[string]bool)
+	}
+	s.invalid[key] = true
+}
+
+// lookupFailed records an environment lookup error, which already names the key
+func (s *source) lookupFailed(key string, err error) {
+	s.markInvalid(key)
+	s.problem("%v", err)
+}
+
+// secret returns a secret, which is only read from the environment or a
+// file named by KEY_FILE, never from the config file
+func (s *source) secret(key string) string {
+	value, err := Getenv(key)
+	if err != nil {
+		s.lookupFailed(key, err)
+	}
+	return value
+}
+
+func (s *source) required(key string) string {
+	value := s.secret(key)
+	if value == "" && !s.invalid[key] {
+		s.fail(key, "required, set it or %s_FILE in the environment", key)
+	}
+	return value
+}
+
+func (s *source) int(key, defaultValue string) int {
+	value := s.get(key, defaultValue)
+	i, err := strconv.Atoi(strings.TrimSpace(value))
 	if err != nil {
+		s.fail(key, "%q is not an integer", value)
 		return 0
 	}
 	return i
 }
 
-func parseInt64(s string) int64 {
-	i, err := strconv.ParseInt(s, 10, 64)
+func (s *source) int64(key, defaultValue string) int64 {
+	value := s.get(key, defaultValue)
+	i, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
 	if err != nil {
+		s.fail(key, "%q is not an integer", value)
 		return 0
 	}
 	return i
 }
 
-func parseUserIDs(s string) []int64 {
-	if s == "" {
-		return nil
+func (s *source) float(key, defaultValue string) float64 {
+	value := s.get(key, defaultValue)
+	f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		s.fail(key, "%q is not a number", value)
+		return 0
 	}
+	return f
+}
+
+func (s *source) bool(key, defaultValue string) bool {
+	value := s.get(key, defaultValue)
+	b, err := strconv.ParseBool(strings.TrimSpace(value))
+	if err != nil {
+		s.fail(key, "%q is not a boolean, use 1 or 0", value)
+		return false
+	}
+	return b
+}
+
+// mergeRepositories adds environment repositories to the file's, letting an
+// environment entry override the prerelease flag of the same repository
+func mergeRepositories(file, env []Repository) []Repository {
+	merged := append([]Repository(nil), file...)
+	for _, e := range env {
+		found := false
+		for i := range merged {
+			if strings.EqualFold(merged[i].FullName(), e.FullName()) {
+				merged[i].TrackPrereleases = e.TrackPrereleases
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, e)
+		}
+	}
+	return merged
+}
 
-	parts := strings.Split(s, ",")
+// userIDs parses a comma-separated list of Telegram user IDs
+func (s *source) userIDs(key string) []int64 {
 	var ids []int64
-	for _, part := range parts {
-		if id := parseInt64(strings.TrimSpace(part)); id != 0 {
-			ids = append(ids, id)
+	for _, part := range parseList(s.get(key, "")) {
+		id, err := strconv.ParseInt(part, 10, 64)
+		if err != nil || id <= 0 {
+			s.fail(key, "%q is not a user ID", part)
+			continue
 		}
+		ids = append(ids, id)
 	}
 	return ids
 }
 
-// parseRepositories parses comma-separated list of repositories from environment variable
-// Format: "owner/repo:pre,owner2/repo2,owner3/repo3:pre"
-// The ":pre" suffix indicates that prereleases should be tracked
-func parseRepositories(s string) []Repository {
-	if s == "" {
-		return nil
+// parseList parses a comma-separated list, dropping empty items
+func parseList(s string) []string {
+	var items []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			items = append(items, part)
+		}
 	}
+	return items
+}
 
-	parts := strings.Split(s, ",")
-	var repos []Repository
-	for _, part := range parts {
-		part = strings.TrimSpace(part)
-		if part == "" {
+// prices parses the advisor price table
+// Format: "model=prompt:completion,model2=prompt:completion" in USD per million tokens
+func (s *source) prices(key string) map[string]ModelPrice {
+	prices := make(map[string]ModelPrice)
+	for _, item := range parseList(s.get(key, "")) {
+		model, price, ok := strings.Cut(item, "=")
+		prompt, completion, ok2 := strings.Cut(price, ":")
+		p, err := strconv.ParseFloat(strings.TrimSpace(prompt), 64)
+		c, err2 := strconv.ParseFloat(strings.TrimSpace(completion), 64)
+		if !ok || !ok2 || err != nil || err2 != nil || strings.TrimSpace(model) == "" || p < 0 || c < 0 {
+			s.fail(key, "%q is not model=prompt:completion", item)
 			continue
 		}
+		prices[strings.TrimSpace(model)] = ModelPrice{Prompt: p, Completion: c}
+	}
+	return prices
+}
 
+// repositories parses comma-separated list of repositories from environment variable
+// Format: "owner/repo:pre,owner2/repo2,owner3/repo3:pre"
+// The ":pre" suffix indicates that prereleases should be tracked
+func (s *source) repositories(key string) []Repository {
+	var repos []Repository
+	for _, part := range parseList(s.get(key, "")) {
 		// Check for :pre suffix
 		trackPrereleases := false
 		if strings.HasSuffix(part, ":pre") {
@@ -118,19 +476,26 @@ func parseRepositories(s string) []Repository {
 			part = strings.TrimSuffix(part, ":pre")
 		}
 
-		// Split owner/repo
-		repoParts := strings.Split(part, "/")
-		if len(repoParts) == 2 {
-			owner := strings.TrimSpace(repoParts[0])
-			name := strings.TrimSpace(repoParts[1])
-			if owner != "" && name != "" {
-				repos = append(repos, Repository{
-					Owner:            owner,
-					Name:             name,
-					TrackPrereleases: trackPrereleases,
-				})
-			}
+		owner, name, ok := splitRepo(part)
+		if !ok {
+			s.fail(key, "%q is not owner/repo or owner/repo:pre", part)
+			continue
 		}
+		repos = append(repos, Repository{
+			Owner:            owner,
+			Name:             name,
+			TrackPrereleases: trackPrereleases,
+		})
 	}
 	return repos
 }
+
+// splitRepo splits owner/name, rejecting empty parts and extra slashes
+func splitRepo(s string) (owner, name string, ok bool) {
+	owner, name, ok = strings.Cut(strings.TrimSpace(s), "/")
+	owner, name = strings.TrimSpace(owner), strings.TrimSpace(name)
+	if !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+		return "", "", false
+	}
+	return owner, name, true
+}
@@ -0,0 +1,283 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setEnv sets the required tokens and the given variables for the test and
+// clears CONFIG_FILE unless it is given
+func setEnv(t *testing.T, env map[string]string) {
+	t.Helper()
+	t.Setenv("GITHUB_TOKEN", "gh-token")
+	t.Setenv("TELEGRAM_BOT_TOKEN", "tg-token")
+	t.Setenv("CONFIG_FILE", "")
+	for key, value := range env {
+		t.Setenv(key, value)
+	}
+}
+
+// writeFile writes a file into a temporary directory and returns its path
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadDefaults(t *testing.T) {
+	setEnv(t, nil)
+	cfg, err := Load(Checks{})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if cfg.GithubToken != "gh-token" || cfg.TelegramToken != "tg-token" {
+		t.Errorf("tokens = %q, %q", cfg.GithubToken, cfg.TelegramToken)
+	}
+	if cfg.IntervalMinutes != 10 || cfg.MaxIntervalMinutes != 720 || !cfg.AdaptivePolling {
+		t.Errorf("polling = %d/%d adaptive=%v, want 10/720 adaptive", cfg.IntervalMinutes, cfg.MaxIntervalMinutes, cfg.AdaptivePolling)
+	}
+	if cfg.SchedulerOverlap != OverlapQueue || cfg.RepositorySync != RepositorySyncMerge || cfg.TracingExporter != TracingNone {
+		t.Errorf("modes = %q, %q, %q", cfg.SchedulerOverlap, cfg.RepositorySync, cfg.TracingExporter)
+	}
+	if cfg.ConfigFile != "" || len(cfg.InitialRepositories) != 0 || len(cfg.Chats) != 0 {
+		t.Errorf("config without a file = %+v", cfg)
+	}
+}
+
+func TestLoadEnvOverridesFile(t *testing.T) {
+	path := writeFile(t, "bot.yaml", `
+timezone: UTC
+poll:
+  interval_minutes: 30
+  workers: 2
+messages:
+  max_bullets: 5
+`)
+	setEnv(t, map[string]string{
+		"CONFIG_FILE":           path,
+		"POLL_INTERVAL_MINUTES": "5",
+		"MAX_BULLETS":           "0",
+	})
+
+	cfg, err := Load(Checks{})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	tests := []struct {
+		name      string
+		got, want any
+	}{
+		{"environment over file", cfg.IntervalMinutes, 5},
+		{"environment zero over file", cfg.MaxBullets, 0},
+		{"file over default", cfg.Workers, 2},
+		{"file string over default", cfg.TimeZone, "UTC"},
+		{"default", cfg.MaxChangelogChars, 2500},
+		{"config file", cfg.ConfigFile, path},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestLoadMergesRepositoriesAndChats(t *testing.T) {
+	path := writeFile(t, "bot.yaml", `
+repositories:
+  - repo: golang/go
+    source: tags
+    interval_minutes: 60
+    include: ['^go1\.']
+    exclude: ['rc']
+  - repo: helm/helm
+    prereleases: true
+chats:
+  - id: -100
+    title: Platform
+    timezone: UTC
+    delivery: silent
+    repositories: [Golang/Go]
+    prompt: short
+  - id: -200
+`)
+	setEnv(t, map[string]string{
+		"CONFIG_FILE":          path,
+		"INITIAL_REPOSITORIES": "GOLANG/go:pre, kubernetes/kubernetes",
+	})
+
+	cfg, err := Load(Checks{})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	var repos []string
+	for _, r := range cfg.InitialRepositories {
+		repos = append(repos, r.String())
+	}
+	want := []string{
+		`golang/go:pre,tags,60m,include=^go1\.,exclude=rc`, // the environment only sets the prerelease flag
+		"helm/helm:pre",
+		"kubernetes/kubernetes",
+	}
+	if !reflect.DeepEqual(repos, want) {
+		t.Errorf("repositories = %q, want %q", repos, want)
+	}
+
+	golang, ok := cfg.RepositoryConfig("golang", "GO")
+	if !ok {
+		t.Fatal("RepositoryConfig did not find golang/go")
+	}
+	for tag, matches := range map[string]bool{"go1.22.0": true, "go1.23rc1": false, "weekly.2011": false} {
+		if golang.MatchesTag(tag) != matches {
+			t.Errorf("golang/go MatchesTag(%q) = %v, want %v", tag, !matches, matches)
+		}
+	}
+	if helm, _ := cfg.RepositoryConfig("helm", "helm"); helm.Source != SourceReleases || !helm.MatchesTag("v3.15.0-rc.1") {
+		t.Errorf("helm/helm = %+v, want releases without filters", helm)
+	}
+
+	platform, ok := cfg.ChatConfig(-100)
+	if !ok {
+		t.Fatal("ChatConfig did not find chat -100")
+	}
+	if platform.Delivery != DeliverySilent || platform.TimeZone != "UTC" || platform.Prompt != "short" || platform.Language != "ru" {
+		t.Errorf("chat -100 = %+v", platform)
+	}
+	if !platform.Subscribed("golang/go") || platform.Subscribed("helm/helm") {
+		t.Errorf("chat -100 subscriptions = %v", platform.Repositories)
+	}
+	other, _ := cfg.ChatConfig(-200)
+	if other.Delivery != DeliveryNotify || !other.Subscribed("helm/helm") {
+		t.Errorf("chat -200 = %+v, want notifications of every repository", other)
+	}
+	if _, ok := cfg.ChatConfig(-300); ok {
+		t.Error("ChatConfig found a chat that is not configured")
+	}
+}
+
+func TestLoadCollectsEveryProblem(t *testing.T) {
+	path := writeFile(t, "bot.yaml", `
+repositories:
+  - repo: golang
+  - repo: helm/helm
+    source: git
+  - repo: kubernetes/kubernetes
+    include: ['(']
+  - repo: kubernetes/Kubernetes
+chats:
+  - title: no id
+  - id: -100
+    delivery: loud
+    timezone: Mars/Olympus
+    repositories: [golang]
+  - id: -100
+`)
+	setEnv(t, map[string]string{
+		"CONFIG_FILE":              path,
+		"GITHUB_TOKEN":             "",
+		"POLL_INTERVAL_MINUTES":    "ten",
+		"WORKERS":                  "0",
+		"SCHEDULER_OVERLAP_POLICY": "parallel",
+		"TIMEZONE":                 "Nowhere/City",
+		"ADVISOR_ENABLED":          "yes please",
+		"ADVISOR_PRICES":           "model-a=1",
+		"ALLOWED_USER_IDS":         "42,-1",
+		"ADMIN_API_TOKEN":          "short",
+		"TRACING_EXPORTER":         "jaeger",
+		"TRACING_SAMPLE_RATIO":     "2",
+		"REPOSITORY_SYNC":          "mirror",
+	})
+
+	_, err := Load(Checks{})
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("Load error = %v, want a *ValidationError", err)
+	}
+	want := []string{
+		"GITHUB_TOKEN: required",
+		`POLL_INTERVAL_MINUTES: "ten" is not an integer`,
+		`ADVISOR_ENABLED: "yes please" is not a boolean`,
+		`ADVISOR_PRICES: "model-a=1" is not model=prompt:completion`,
+		`ALLOWED_USER_IDS: "-1" is not a user ID`,
+		`repository "golang": want owner/name`,
+		`chat "no id": id is required`,
+		`chat -100: delivery must be "notify" or "silent", got "loud"`,
+		`repository helm/helm: source must be "releases" or "tags", got "git"`,
+		"repository kubernetes/kubernetes: include pattern",
+		"WORKERS: must be at least 1, got 0",
+		`SCHEDULER_OVERLAP_POLICY: must be "skip" or "queue", got "parallel"`,
+		`TIMEZONE: unknown time zone "Nowhere/City"`,
+		"REPOSITORY_SYNC: must be",
+		"ADMIN_API_TOKEN: requires HTTP_ADDR",
+		"ADMIN_API_TOKEN: must be at least 16 characters long",
+		`TRACING_EXPORTER: must be empty, "otlp" or "stdout", got "jaeger"`,
+		"TRACING_SAMPLE_RATIO: must be between 0 and 1, got 2",
+		"repository kubernetes/Kubernetes: listed more than once",
+		"chat -100: listed more than once",
+		`chat -100: unknown time zone "Mars/Olympus"`,
+		`chat -100: repository "golang": want owner/name`,
+	}
+	for _, w := range want {
+		found := false
+		for _, problem := range invalid.Problems {
+			found = found || strings.Contains(problem, w)
+		}
+		if !found {
+			t.Errorf("no problem mentions %q", w)
+		}
+	}
+	if len(invalid.Problems) != len(want) {
+		t.Errorf("got %d problems, want %d:\n%s", len(invalid.Problems), len(want), strings.Join(invalid.Problems, "\n"))
+	}
+	if !strings.HasPrefix(err.Error(), "invalid configuration: ") {
+		t.Errorf("error = %q", err)
+	}
+}
+
+func TestLoadChecksCron(t *testing.T) {
+	var gotExpr string
+	var gotLoc *time.Location
+	checks := Checks{Cron: func(expr string, loc *time.Location) error {
+		gotExpr, gotLoc = expr, loc
+		if expr == "bad" {
+			return errors.New("expected 5 fields")
+		}
+		return nil
+	}}
+
+	tests := []struct {
+		name    string
+		cron    string
+		checks  Checks
+		wantErr string
+	}{
+		{"valid", "0 9 * * MON-FRI", checks, ""},
+		{"invalid", "bad", checks, "POLL_CRON: expected 5 fields"},
+		{"not checked", "bad", Checks{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotExpr, gotLoc = "", nil
+			setEnv(t, map[string]string{"POLL_CRON": tt.cron, "TIMEZONE": "Asia/Tokyo"})
+			_, err := Load(tt.checks)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("Load error = %v, want %q", err, tt.wantErr)
+			}
+			if tt.checks.Cron != nil && (gotExpr != tt.cron || gotLoc == nil || gotLoc.String() != "Asia/Tokyo") {
+				t.Errorf("cron check got %q in %v", gotExpr, gotLoc)
+			}
+		})
+	}
+}
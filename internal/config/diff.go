@@ -0,0 +1,38 @@
+package config
+
+import (
+	"reflect"
+	"slices"
+)
+
+// Changed returns the names of the settings that differ between two
+// configurations, in field order
+func (c *Config) Changed(other *Config) []string {
+	var changed []string
+	a, b := reflect.ValueOf(c).Elem(), reflect.ValueOf(other).Elem()
+	for i := 0; i < a.NumField(); i++ {
+		field := a.Type().Field(i)
+		var equal bool
+		switch field.Name {
+		case "InitialRepositories":
+			equal = slices.EqualFunc(c.InitialRepositories, other.InitialRepositories, Repository.equal)
+		default:
+			equal = reflect.DeepEqual(a.Field(i).Interface(), b.Field(i).Interface())
+		}
+		if !equal {
+			changed = append(changed, field.Name)
+		}
+	}
+	return changed
+}
+
+// equal compares the configured settings, ignoring the compiled filters
+func (r Repository) equal(o Repository) bool {
+	return r.Owner == o.Owner &&
+		r.Name == o.Name &&
+		r.TrackPrereleases == o.TrackPrereleases &&
+		r.Source == o.Source &&
+		r.IntervalMinutes == o.IntervalMinutes &&
+		slices.Equal(r.Include, o.Include) &&
+		slices.Equal(r.Exclude, o.Exclude)
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestChanged(t *testing.T) {
+	base := func() *Config {
+		return &Config{
+			IntervalMinutes:     10,
+			AllowedUserIDs:      []int64{1},
+			InitialRepositories: []Repository{{Owner: "golang", Name: "go", Source: SourceReleases, Include: []string{`^go1\.`}}},
+			Chats:               []Chat{{ID: -100, Delivery: DeliveryNotify}},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		change func(*Config)
+		want   []string
+	}{
+		{"nothing", func(*Config) {}, nil},
+		{"compiled filters only", func(c *Config) {
+			if err := c.InitialRepositories[0].compile(); err != nil {
+				t.Fatal(err)
+			}
+		}, nil},
+		{"scalar", func(c *Config) { c.IntervalMinutes = 5 }, []string{"IntervalMinutes"}},
+		{"list", func(c *Config) { c.AllowedUserIDs = append(c.AllowedUserIDs, 2) }, []string{"AllowedUserIDs"}},
+		{"repository filter", func(c *Config) { c.InitialRepositories[0].Exclude = []string{"rc"} }, []string{"InitialRepositories"}},
+		{"repository added", func(c *Config) {
+			c.InitialRepositories = append(c.InitialRepositories, Repository{Owner: "helm", Name: "helm"})
+		}, []string{"InitialRepositories"}},
+		{"chat and token", func(c *Config) {
+			c.Chats[0].Delivery = DeliverySilent
+			c.GithubToken = "new"
+		}, []string{"GithubToken", "Chats"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current, next := base(), base()
+			tt.change(next)
+			if got := current.Changed(next); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Changed = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,270 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// fileConfig is the layout of the optional YAML or TOML config file. Scalar
+// settings map onto the environment variables of the same meaning, which
+// override them; secrets stay in the environment.
+type fileConfig struct {
+	TimeZone       *string `yaml:"timezone" toml:"timezone"`
+	DefaultChatID  *int64  `yaml:"default_chat_id" toml:"default_chat_id"`
+	AllowedUserIDs []int64 `yaml:"allowed_user_ids" toml:"allowed_user_ids"`
+
+	Poll struct {
+		IntervalMinutes    *int    `yaml:"interval_minutes" toml:"interval_minutes"`
+		MaxIntervalMinutes *int    `yaml:"max_interval_minutes" toml:"max_interval_minutes"`
+		Adaptive           *bool   `yaml:"adaptive" toml:"adaptive"`
+		Cron               *string `yaml:"cron" toml:"cron"`
+		JitterSeconds      *int    `yaml:"jitter_seconds" toml:"jitter_seconds"`
+		Workers            *int    `yaml:"workers" toml:"workers"`
+		OverlapPolicy      *string `yaml:"overlap_policy" toml:"overlap_policy"`
+		MaxReleaseAgeDays  *int    `yaml:"max_release_age_days" toml:"max_release_age_days"`
+	} `yaml:"poll" toml:"poll"`
+
+	GitHub struct {
+		RequestsPerSecond *float64 `yaml:"requests_per_second" toml:"requests_per_second"`
+	} `yaml:"github" toml:"github"`
+
+	Messages struct {
+		MaxChangelogChars *int `yaml:"max_changelog_chars" toml:"max_changelog_chars"`
+		MaxBullets        *int `yaml:"max_bullets" toml:"max_bullets"`
+	} `yaml:"messages" toml:"messages"`
+
+	Advisor struct {
+		Enabled                *bool                 `yaml:"enabled" toml:"enabled"`
+		Model                  *string               `yaml:"model" toml:"model"`
+		FallbackModels         []string              `yaml:"fallback_models" toml:"fallback_models"`
+		BreakerFailures        *int                  `yaml:"breaker_failures" toml:"breaker_failures"`
+		BreakerCooldownSeconds *int                  `yaml:"breaker_cooldown_seconds" toml:"breaker_cooldown_seconds"`
+		AttemptTimeoutSeconds  *int                  `yaml:"attempt_timeout_seconds" toml:"attempt_timeout_seconds"`
+		Prices                 map[string]ModelPrice `yaml:"prices" toml:"prices"`
+		DailyBudgetUSD         *float64              `yaml:"daily_budget_usd" toml:"daily_budget_usd"`
+		MonthlyBudgetUSD       *float64              `yaml:"monthly_budget_usd" toml:"monthly_budget_usd"`
+		ContextTokens          *int                  `yaml:"context_tokens" toml:"context_tokens"`
+		PromptsDir             *string               `yaml:"prompts_dir" toml:"prompts_dir"`
+	} `yaml:"advisor" toml:"advisor"`
+
+	Storage struct {
+		RetentionDays            *int    `yaml:"retention_days" toml:"retention_days"`
+		RetentionKeepPerRepo     *int    `yaml:"retention_keep_per_repo" toml:"retention_keep_per_repo"`
+		MaintenanceIntervalHours *int    `yaml:"maintenance_interval_hours" toml:"maintenance_interval_hours"`
+		BackupDir                *string `yaml:"backup_dir" toml:"backup_dir"`
+		BackupIntervalHours      *int    `yaml:"backup_interval_hours" toml:"backup_interval_hours"`
+		BackupKeep               *int    `yaml:"backup_keep" toml:"backup_keep"`
+	} `yaml:"storage" toml:"storage"`
+
+	HTTP struct {
+		Addr               *string `yaml:"addr" toml:"addr"`
+		ReadyMaxMissedRuns *int    `yaml:"ready_max_missed_runs" toml:"ready_max_missed_runs"`
+		Dashboard          *bool   `yaml:"dashboard" toml:"dashboard"`
+	} `yaml:"http" toml:"http"`
+
+	Tracing struct {
+		Exporter    *string  `yaml:"exporter" toml:"exporter"`
+		SampleRatio *float64 `yaml:"sample_ratio" toml:"sample_ratio"`
+	} `yaml:"tracing" toml:"tracing"`
+
+	RepositorySync       *string          `yaml:"repository_sync" toml:"repository_sync"`
+	RepositorySyncDryRun *bool            `yaml:"repository_sync_dry_run" toml:"repository_sync_dry_run"`
+	Repositories         []fileRepository `yaml:"repositories" toml:"repositories"`
+	Chats                []fileChat       `yaml:"chats" toml:"chats"`
+}
+
+type fileRepository struct {
+	Repo            string   `yaml:"repo" toml:"repo"`
+	Prereleases     bool     `yaml:"prereleases" toml:"prereleases"`
+	Source          string   `yaml:"source" toml:"source"`
+	IntervalMinutes int      `yaml:"interval_minutes" toml:"interval_minutes"`
+	Include         []string `yaml:"include" toml:"include"`
+	Exclude         []string `yaml:"exclude" toml:"exclude"`
+}
+
+type fileChat struct {
+	ID           int64    `yaml:"id" toml:"id"`
+	Title        string   `yaml:"title" toml:"title"`
+	Language     string   `yaml:"language" toml:"language"`
+	TimeZone     string   `yaml:"timezone" toml:"timezone"`
+	Delivery     string   `yaml:"delivery" toml:"delivery"`
+	Repositories []string `yaml:"repositories" toml:"repositories"`
+	Prompt       string   `yaml:"prompt" toml:"prompt"`
+}
+
+// readFile parses a .yaml, .yml or .toml config file, rejecting unknown keys
+func readFile(path string) (*fileConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var fc fileConfig
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(&fc); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	case ".toml":
+		md, err := toml.Decode(string(data), &fc)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return nil, fmt.Errorf("%s: unknown keys %v", path, undecoded)
+		}
+	default:
+		return nil, fmt.Errorf("%s: unsupported config file type, use .yaml, .yml or .toml", path)
+	}
+	return &fc, nil
+}
+
+// values returns the file's scalar settings keyed by environment variable name
+func (fc *fileConfig) values() map[string]string {
+	v := make(map[string]string)
+	set := func(key string, value any) {
+		switch p := value.(type) {
+		case *string:
+			if p != nil {
+				v[key] = *p
+			}
+		case *int:
+			if p != nil {
+				v[key] = strconv.Itoa(*p)
+			}
+		case *int64:
+			if p != nil {
+				v[key] = strconv.FormatInt(*p, 10)
+			}
+		case *float64:
+			if p != nil {
+				v[key] = strconv.FormatFloat(*p, 'f', -1, 64)
+			}
+		case *bool:
+			if p != nil {
+				v[key] = "0"
+				if *p {
+					v[key] = "1"
+				}
+			}
+		}
+	}
+
+	set("TIMEZONE", fc.TimeZone)
+	set("DEFAULT_CHAT_ID", fc.DefaultChatID)
+	if len(fc.AllowedUserIDs) > 0 {
+		ids := make([]string, len(fc.AllowedUserIDs))
+		for i, id := range fc.AllowedUserIDs {
+			ids[i] = strconv.FormatInt(id, 10)
+		}
+		v["ALLOWED_USER_IDS"] = strings.Join(ids, ",")
+	}
+
+	set("POLL_INTERVAL_MINUTES", fc.Poll.IntervalMinutes)
+	set("MAX_POLL_INTERVAL_MINUTES", fc.Poll.MaxIntervalMinutes)
+	set("ADAPTIVE_POLLING", fc.Poll.Adaptive)
+	set("POLL_CRON", fc.Poll.Cron)
+	set("POLL_JITTER_SECONDS", fc.Poll.JitterSeconds)
+	set("WORKERS", fc.Poll.Workers)
+	set("SCHEDULER_OVERLAP_POLICY", fc.Poll.OverlapPolicy)
+	set("MAX_RELEASE_AGE_DAYS", fc.Poll.MaxReleaseAgeDays)
+	set("GITHUB_REQUESTS_PER_SECOND", fc.GitHub.RequestsPerSecond)
+	set("MAX_CHANGELOG_CHARS", fc.Messages.MaxChangelogChars)
+	set("MAX_BULLETS", fc.Messages.MaxBullets)
+
+	set("ADVISOR_ENABLED", fc.Advisor.Enabled)
+	set("OPENROUTER_MODEL", fc.Advisor.Model)
+	if len(fc.Advisor.FallbackModels) > 0 {
+		v["OPENROUTER_FALLBACK_MODELS"] = strings.Join(fc.Advisor.FallbackModels, ",")
+	}
+	set("ADVISOR_BREAKER_FAILURES", fc.Advisor.BreakerFailures)
+	set("ADVISOR_BREAKER_COOLDOWN_SECONDS", fc.Advisor.BreakerCooldownSeconds)
+	set("ADVISOR_ATTEMPT_TIMEOUT_SECONDS", fc.Advisor.AttemptTimeoutSeconds)
+	if len(fc.Advisor.Prices) > 0 {
+		var prices []string
+		for model, p := range fc.Advisor.Prices {
+			prices = append(prices, fmt.Sprintf("%s=%g:%g", model, p.Prompt, p.Completion))
+		}
+		v["ADVISOR_PRICES"] = strings.Join(prices, ",")
+	}
+	set("ADVISOR_DAILY_BUDGET_USD", fc.Advisor.DailyBudgetUSD)
+	set("ADVISOR_MONTHLY_BUDGET_USD", fc.Advisor.MonthlyBudgetUSD)
+	set("ADVISOR_CONTEXT_TOKENS", fc.Advisor.ContextTokens)
+	set("ADVISOR_PROMPTS_DIR", fc.Advisor.PromptsDir)
+
+	set("RETENTION_DAYS", fc.Storage.RetentionDays)
+	set("RETENTION_KEEP_PER_REPO", fc.Storage.RetentionKeepPerRepo)
+	set("MAINTENANCE_INTERVAL_HOURS", fc.Storage.MaintenanceIntervalHours)
+	set("BACKUP_DIR", fc.Storage.BackupDir)
+	set("BACKUP_INTERVAL_HOURS", fc.Storage.BackupIntervalHours)
+	set("BACKUP_KEEP", fc.Storage.BackupKeep)
+
+	set("HTTP_ADDR", fc.HTTP.Addr)
+	set("READY_MAX_MISSED_RUNS", fc.HTTP.ReadyMaxMissedRuns)
+	set("DASHBOARD_ENABLED", fc.HTTP.Dashboard)
+	set("TRACING_EXPORTER", fc.Tracing.Exporter)
+	set("TRACING_SAMPLE_RATIO", fc.Tracing.SampleRatio)
+	set("REPOSITORY_SYNC", fc.RepositorySync)
+	set("REPOSITORY_SYNC_DRY_RUN", fc.RepositorySyncDryRun)
+	return v
+}
+
+// lists converts the file's repositories and chats, reporting syntax problems
+func (fc *fileConfig) lists(src *source) ([]Repository, []Chat) {
+	var repos []Repository
+	for _, r := range fc.Repositories {
+		owner, name, ok := splitRepo(r.Repo)
+		if !ok {
+			src.problem("repository %q: want owner/name", r.Repo)
+			continue
+		}
+		repos = append(repos, Repository{
+			Owner:            owner,
+			Name:             name,
+			TrackPrereleases: r.Prereleases,
+			Source:           r.Source,
+			IntervalMinutes:  r.IntervalMinutes,
+			Include:          r.Include,
+			Exclude:          r.Exclude,
+		})
+	}
+
+	var chats []Chat
+	for _, c := range fc.Chats {
+		if c.ID == 0 {
+			src.problem("chat %q: id is required", c.Title)
+			continue
+		}
+		chat := Chat{
+			ID:           c.ID,
+			Title:        c.Title,
+			Language:     c.Language,
+			TimeZone:     c.TimeZone,
+			Delivery:     c.Delivery,
+			Repositories: c.Repositories,
+			Prompt:       c.Prompt,
+		}
+		if chat.Language == "" {
+			chat.Language = "ru"
+		}
+		if chat.Delivery == "" {
+			chat.Delivery = DeliveryNotify
+		}
+		if chat.Delivery != DeliveryNotify && chat.Delivery != DeliverySilent {
+			src.problem("chat %d: delivery must be %q or %q, got %q", c.ID, DeliveryNotify, DeliverySilent, c.Delivery)
+		}
+		chats = append(chats, chat)
+	}
+	return repos, chats
+}
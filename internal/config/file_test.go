@@ -0,0 +1,128 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadFileFormats(t *testing.T) {
+	yamlConfig := `
+timezone: UTC
+default_chat_id: -100
+allowed_user_ids: [1, 2]
+poll:
+  interval_minutes: 15
+  adaptive: false
+advisor:
+  fallback_models: [model-b, model-c]
+  prices:
+    model-a: {prompt: 0.25, completion: 1.25}
+tracing:
+  sample_ratio: 0.5
+repositories:
+  - repo: golang/go
+    source: tags
+    include: ['^go1\.']
+chats:
+  - id: -100
+    title: Platform
+    delivery: silent
+`
+	tomlConfig := `
+timezone = "UTC"
+default_chat_id = -100
+allowed_user_ids = [1, 2]
+
+[poll]
+interval_minutes = 15
+adaptive = false
+
+[advisor]
+fallback_models = ["model-b", "model-c"]
+
+[advisor.prices.model-a]
+prompt = 0.25
+completion = 1.25
+
+[tracing]
+sample_ratio = 0.5
+
+[[repositories]]
+repo = "golang/go"
+source = "tags"
+include = ['^go1\.']
+
+[[chats]]
+id = -100
+title = "Platform"
+delivery = "silent"
+`
+	wantValues := map[string]string{
+		"TIMEZONE":                   "UTC",
+		"DEFAULT_CHAT_ID":            "-100",
+		"ALLOWED_USER_IDS":           "1,2",
+		"POLL_INTERVAL_MINUTES":      "15",
+		"ADAPTIVE_POLLING":           "0",
+		"OPENROUTER_FALLBACK_MODELS": "model-b,model-c",
+		"ADVISOR_PRICES":             "model-a=0.25:1.25",
+		"TRACING_SAMPLE_RATIO":       "0.5",
+	}
+
+	for _, tt := range []struct {
+		name    string
+		content string
+	}{
+		{"bot.yaml", yamlConfig},
+		{"bot.yml", yamlConfig},
+		{"bot.toml", tomlConfig},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			fc, err := readFile(writeFile(t, tt.name, tt.content))
+			if err != nil {
+				t.Fatalf("readFile: %v", err)
+			}
+			if got := fc.values(); !reflect.DeepEqual(got, wantValues) {
+				t.Errorf("values = %v, want %v", got, wantValues)
+			}
+
+			src := &source{}
+			repos, chats := fc.lists(src)
+			if len(src.problems) > 0 {
+				t.Fatalf("lists problems: %v", src.problems)
+			}
+			if len(repos) != 1 || repos[0].String() != `golang/go:tags,include=^go1\.` {
+				t.Errorf("repositories = %v", repos)
+			}
+			if len(chats) != 1 || chats[0].ID != -100 || chats[0].Delivery != DeliverySilent || chats[0].Language != "ru" {
+				t.Errorf("chats = %+v", chats)
+			}
+		})
+	}
+}
+
+func TestReadFileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"unknown.yaml", "poll:\n  interval: 5\n", "field interval not found"},
+		{"unknown.toml", "[poll]\ninterval = 5\n", "unknown keys [poll.interval]"},
+		{"secret.yaml", "github_token: abc\n", "field github_token not found"},
+		{"syntax.toml", "timezone = \n", "syntax.toml"},
+		{"bot.json", "{}", "unsupported config file type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readFile(writeFile(t, tt.name, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("readFile error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := readFile(writeFile(t, "empty.yaml", "")); err != nil {
+		t.Errorf("empty YAML file: %v", err)
+	}
+}
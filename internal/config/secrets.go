@@ -0,0 +1,107 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// secretSettings are never shown or logged in clear text
+var secretSettings = map[string]bool{
+	"GithubToken":       true,
+	"TelegramToken":     true,
+	"OpenRouterAPIKey":  true,
+	"AdminAPIToken":     true,
+	"DashboardPassword": true,
+}
+
+// Getenv returns an environment variable, or the contents of the file named by
+// the variable with a _FILE suffix (e.g. GITHUB_TOKEN_FILE for mounted secrets).
+// Setting both is an error.
+func Getenv(key string) (string, error) {
+	value, path := os.Getenv(key), os.Getenv(key+"_FILE")
+	if path == "" {
+		return value, nil
+	}
+	if value != "" {
+		return "", fmt.Errorf("%s and %s_FILE are both set, use one", key, key)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("%s_FILE: %w", key, err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// Setting is a configuration value formatted for display
+type Setting struct {
+	Name  string
+	Value string
+}
+
+// Settings returns the effective configuration in field order, with secrets redacted
+func (c *Config) Settings() []Setting {
+	v := reflect.ValueOf(c).Elem()
+	settings := make([]Setting, 0, v.NumField())
+	for i := 0; i < v.NumField(); i++ {
+		name := v.Type().Field(i).Name
+		value := fmt.Sprint(v.Field(i).Interface())
+		if secretSettings[name] {
+			value = redact(value)
+		}
+		settings = append(settings, Setting{Name: name, Value: value})
+	}
+	return settings
+}
+
+// LogValue implements slog.LogValuer, so a logged configuration never
+// contains secrets
+func (c *Config) LogValue() slog.Value {
+	var attrs []slog.Attr
+	for _, s := range c.Settings() {
+		attrs = append(attrs, slog.String(s.Name, s.Value))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+func redact(value string) string {
+	if value == "" {
+		return ""
+	}
+	return "[redacted]"
+}
+
+// String returns owner/name with the configured options
+func (r Repository) String() string {
+	var opts []string
+	if r.TrackPrereleases {
+		opts = append(opts, "pre")
+	}
+	if r.Source != "" && r.Source != SourceReleases {
+		opts = append(opts, r.Source)
+	}
+	if r.IntervalMinutes > 0 {
+		opts = append(opts, fmt.Sprintf("%dm", r.IntervalMinutes))
+	}
+	if len(r.Include) > 0 {
+		opts = append(opts, "include="+strings.Join(r.Include, "|"))
+	}
+	if len(r.Exclude) > 0 {
+		opts = append(opts, "exclude="+strings.Join(r.Exclude, "|"))
+	}
+	if len(opts) == 0 {
+		return r.FullName()
+	}
+	return r.FullName() + ":" + strings.Join(opts, ",")
+}
+
+// String returns the chat ID with its title
+func (c Chat) String() string {
+	if c.Title == "" {
+		return fmt.Sprint(c.ID)
+	}
+	return fmt.Sprintf("%d (%s)", c.ID, c.Title)
+}
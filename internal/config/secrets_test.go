@@ -0,0 +1,119 @@
+package config
+
+import (
+	"bytes"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGetenv(t *testing.T) {
+	secret := writeFile(t, "token", "  from-file\n")
+	tests := []struct {
+		name    string
+		value   string
+		file    string
+		want    string
+		wantErr string
+	}{
+		{name: "unset"},
+		{name: "value", value: "from-env", want: "from-env"},
+		{name: "file", file: secret, want: "from-file"},
+		{name: "both", value: "from-env", file: secret, wantErr: "TEST_SECRET and TEST_SECRET_FILE are both set"},
+		{name: "missing file", file: filepath.Join(t.TempDir(), "missing"), wantErr: "TEST_SECRET_FILE: "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TEST_SECRET", tt.value)
+			t.Setenv("TEST_SECRET_FILE", tt.file)
+			got, err := Getenv("TEST_SECRET")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Getenv error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("Getenv = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadSecretFiles(t *testing.T) {
+	setEnv(t, map[string]string{
+		"GITHUB_TOKEN":       "",
+		"GITHUB_TOKEN_FILE":  writeFile(t, "github", "gh-from-file\n"),
+		"OPENROUTER_API_KEY": "or-key",
+	})
+	// _FILE works for every setting, not only secrets
+	t.Setenv("POLL_INTERVAL_MINUTES_FILE", writeFile(t, "minutes", "7"))
+
+	cfg, err := Load(Checks{})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.GithubToken != "gh-from-file" || cfg.IntervalMinutes != 7 || cfg.OpenRouterAPIKey != "or-key" {
+		t.Errorf("GithubToken = %q, IntervalMinutes = %d, OpenRouterAPIKey = %q", cfg.GithubToken, cfg.IntervalMinutes, cfg.OpenRouterAPIKey)
+	}
+
+	t.Setenv("TELEGRAM_BOT_TOKEN_FILE", writeFile(t, "telegram", "tg-from-file"))
+	t.Setenv("POLL_INTERVAL_MINUTES", "5")
+	_, err = Load(Checks{})
+	if err == nil {
+		t.Fatal("Load accepted a setting and its _FILE both set")
+	}
+	for _, key := range []string{"TELEGRAM_BOT_TOKEN", "POLL_INTERVAL_MINUTES"} {
+		if !strings.Contains(err.Error(), key+" and "+key+"_FILE are both set") {
+			t.Errorf("error %q does not report the %s conflict", err, key)
+		}
+	}
+	if strings.Contains(err.Error(), "TELEGRAM_BOT_TOKEN: required") {
+		t.Errorf("conflicting token also reported as missing: %q", err)
+	}
+}
+
+func TestSettingsRedactSecrets(t *testing.T) {
+	cfg := &Config{
+		GithubToken:      "ghp_secret",
+		TelegramToken:    "123:secret",
+		OpenRouterAPIKey: "",
+		AdminAPIToken:    "admin-secret-token",
+		TimeZone:         "UTC",
+		IntervalMinutes:  10,
+	}
+
+	values := map[string]string{}
+	for _, s := range cfg.Settings() {
+		values[s.Name] = s.Value
+	}
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"GithubToken", "[redacted]"},
+		{"TelegramToken", "[redacted]"},
+		{"AdminAPIToken", "[redacted]"},
+		{"OpenRouterAPIKey", ""}, // unset secrets show as unset
+		{"DashboardPassword", ""},
+		{"TimeZone", "UTC"},
+		{"IntervalMinutes", "10"},
+	}
+	for _, tt := range tests {
+		if got, ok := values[tt.name]; !ok || got != tt.want {
+			t.Errorf("%s = %q (present %v), want %q", tt.name, got, ok, tt.want)
+		}
+	}
+
+	var buf bytes.Buffer
+	slog.New(slog.NewJSONHandler(&buf, nil)).Info("config", "config", cfg)
+	for _, secret := range []string{"ghp_secret", "123:secret", "admin-secret-token"} {
+		if strings.Contains(buf.String(), secret) {
+			t.Errorf("logged configuration contains %q: %s", secret, buf.String())
+		}
+	}
+	if !strings.Contains(buf.String(), `"TimeZone":"UTC"`) {
+		t.Errorf("logged configuration lacks settings: %s", buf.String())
+	}
+}
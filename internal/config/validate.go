@@ -0,0 +1,138 @@
+package config
+
+import (
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// ValidationError lists every problem found in the configuration
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration: " + strings.Join(e.Problems, "; ")
+}
+
+// validate checks ranges and cross-field constraints of the parsed settings
+func (c *Config) validate(src *source, checks Checks) {
+	src.atLeast("POLL_INTERVAL_MINUTES", c.IntervalMinutes, 1)
+	if c.AdaptivePolling && c.MaxIntervalMinutes < c.IntervalMinutes && !src.invalid["MAX_POLL_INTERVAL_MINUTES"] {
+		src.fail("MAX_POLL_INTERVAL_MINUTES", "must not be less than POLL_INTERVAL_MINUTES (%d), got %d", c.IntervalMinutes, c.MaxIntervalMinutes)
+	}
+	src.atLeast("POLL_JITTER_SECONDS", c.PollJitterSecs, 0)
+	src.atLeast("WORKERS", c.Workers, 1)
+	src.atLeast("MAX_RELEASE_AGE_DAYS", c.MaxReleaseAgeDays, 1)
+	if c.SchedulerOverlap != OverlapSkip && c.SchedulerOverlap != OverlapQueue {
+		src.fail("SCHEDULER_OVERLAP_POLICY", "must be %q or %q, got %q", OverlapSkip, OverlapQueue, c.SchedulerOverlap)
+	}
+	if c.GithubRequestsPerSecond <= 0 && !src.invalid["GITHUB_REQUESTS_PER_SECOND"] {
+		src.fail("GITHUB_REQUESTS_PER_SECOND", "must be positive, got %g", c.GithubRequestsPerSecond)
+	}
+
+	loc, err := time.LoadLocation(c.TimeZone)
+	if err != nil {
+		src.fail("TIMEZONE", "unknown time zone %q", c.TimeZone)
+	} else if c.PollCron != "" && checks.Cron != nil {
+		if err := checks.Cron(c.PollCron, loc); err != nil {
+			src.fail("POLL_CRON", "%v", err)
+		}
+	}
+
+	src.atLeast("MAX_CHANGELOG_CHARS", c.MaxChangelogChars, 1)
+	src.atLeast("MAX_BULLETS", c.MaxBullets, 0)
+
+	if c.AdvisorEnabled && c.OpenRouterAPIKey == "" && !src.invalid["OPENROUTER_API_KEY"] {
+		src.fail("OPENROUTER_API_KEY", "required when ADVISOR_ENABLED is set")
+	}
+	src.atLeast("ADVISOR_BREAKER_FAILURES", c.AdvisorFailures, 1)
+	src.atLeast("ADVISOR_BREAKER_COOLDOWN_SECONDS", c.AdvisorCooldownSecs, 0)
+	src.atLeast("ADVISOR_ATTEMPT_TIMEOUT_SECONDS", c.AdvisorTimeoutSecs, 1)
+	src.atLeast("ADVISOR_CONTEXT_TOKENS", c.AdvisorContextToks, 0)
+	if c.AdvisorDailyBudget < 0 && !src.invalid["ADVISOR_DAILY_BUDGET_USD"] {
+		src.fail("ADVISOR_DAILY_BUDGET_USD", "must not be negative, got %g", c.AdvisorDailyBudget)
+	}
+	if c.AdvisorMonthBudget < 0 && !src.invalid["ADVISOR_MONTHLY_BUDGET_USD"] {
+		src.fail("ADVISOR_MONTHLY_BUDGET_USD", "must not be negative, got %g", c.AdvisorMonthBudget)
+	}
+	if c.AdvisorPromptsDir != "" {
+		if info, err := os.Stat(c.AdvisorPromptsDir); err != nil || !info.IsDir() {
+			src.fail("ADVISOR_PROMPTS_DIR", "%q is not a directory", c.AdvisorPromptsDir)
+		}
+	}
+
+	src.atLeast("RETENTION_DAYS", c.RetentionDays, 0)
+	src.atLeast("RETENTION_KEEP_PER_REPO", c.RetentionKeepPerRepo, 0)
+	src.atLeast("MAINTENANCE_INTERVAL_HOURS", c.MaintenanceHours, 1)
+	src.atLeast("BACKUP_INTERVAL_HOURS", c.BackupHours, 0)
+	src.atLeast("BACKUP_KEEP", c.BackupKeep, 1)
+
+	if c.RepositorySync != RepositorySyncMerge && c.RepositorySync != RepositorySyncExact {
+		src.fail("REPOSITORY_SYNC", "must be %q or %q, got %q", RepositorySyncMerge, RepositorySyncExact, c.RepositorySync)
+	}
+
+	if c.HTTPAddr != "" {
+		if _, _, err := net.SplitHostPort(c.HTTPAddr); err != nil {
+			src.fail("HTTP_ADDR", "%q is not host:port, e.g. :9090", c.HTTPAddr)
+		}
+	}
+	src.atLeast("READY_MAX_MISSED_RUNS", c.ReadyMaxMissedRuns, 1)
+	if c.AdminAPIToken != "" {
+		if c.HTTPAddr == "" {
+			src.fail("ADMIN_API_TOKEN", "requires HTTP_ADDR, the admin API is served by the HTTP server")
+		}
+		if len(c.AdminAPIToken) < 16 {
+			src.fail("ADMIN_API_TOKEN", "must be at least 16 characters long")
+		}
+	}
+	if c.DashboardEnabled && c.HTTPAddr == "" {
+		src.fail("DASHBOARD_ENABLED", "requires HTTP_ADDR, the dashboard is served by the HTTP server")
+	}
+	switch c.TracingExporter {
+	case TracingNone, TracingOTLP, TracingStdout:
+	default:
+		src.fail("TRACING_EXPORTER", "must be empty, %q or %q, got %q", TracingOTLP, TracingStdout, c.TracingExporter)
+	}
+	if (c.TracingSampleRatio < 0 || c.TracingSampleRatio > 1) && !src.invalid["TRACING_SAMPLE_RATIO"] {
+		src.fail("TRACING_SAMPLE_RATIO", "must be between 0 and 1, got %g", c.TracingSampleRatio)
+	}
+
+	seenRepos := make(map[string]bool)
+	for _, r := range c.InitialRepositories {
+		name := strings.ToLower(r.FullName())
+		if seenRepos[name] {
+			src.problem("repository %s: listed more than once", r.FullName())
+		}
+		seenRepos[name] = true
+		if r.IntervalMinutes < 0 {
+			src.problem("repository %s: interval_minutes must not be negative, got %d", r.FullName(), r.IntervalMinutes)
+		}
+	}
+
+	seenChats := make(map[int64]bool)
+	for _, chat := range c.Chats {
+		if seenChats[chat.ID] {
+			src.problem("chat %d: listed more than once", chat.ID)
+		}
+		seenChats[chat.ID] = true
+		if chat.TimeZone != "" {
+			if _, err := time.LoadLocation(chat.TimeZone); err != nil {
+				src.problem("chat %d: unknown time zone %q", chat.ID, chat.TimeZone)
+			}
+		}
+		for _, repo := range chat.Repositories {
+			if _, _, ok := splitRepo(repo); !ok {
+				src.problem("chat %d: repository %q: want owner/name", chat.ID, repo)
+			}
+		}
+	}
+}
+
+// atLeast reports a setting below its minimum unless it already failed to parse
+func (s *source) atLeast(key string, value, minimum int) {
+	if value < minimum && !s.invalid[key] {
+		s.fail(key, "must be at least %d, got %d", minimum, value)
+	}
+}
@@ -0,0 +1,278 @@
+package dashboard
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"embed"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/yourorg/tg-release-bot/internal/config"
+	"github.com/yourorg/tg-release-bot/internal/db"
+	"github.com/yourorg/tg-release-bot/internal/scheduler"
+)
+
+//go:embed templates/*.html static/*
+var assets embed.FS
+
+// recentDeliveries is how many deliveries the page lists
+const recentDeliveries = 50
+
+// Store is the read-only part of db.Storage the dashboard shows
+type Store interface {
+	ListRepositories(ctx context.Context) ([]db.Repository, error)
+	GetRepoSchedule(ctx context.Context, repoOwner, repoName string) (db.RepoSchedule, error)
+	ListReleaseHistory(ctx context.Context, repoOwner, repoName string, limit int) ([]db.ProcessedRelease, error)
+	ListChats(ctx context.Context) ([]db.Chat, error)
+	ListSubscriptions(ctx context.Context, chatID int64) ([]db.Subscription, error)
+	ListRecentDeliveries(ctx context.Context, limit int) ([]db.Delivery, error)
+}
+
+var _ Store = db.Storage(nil)
+
+// Job is a scheduled job shown with its run status
+type Job struct {
+	Name   string
+	Status func() scheduler.Status
+}
+
+// Options configures the dashboard
+type Options struct {
+	// Config returns the configuration in effect
+	Config func() *config.Config
+	// Jobs are the scheduled jobs, in display order
+	Jobs []Job
+	// RateLimit returns the last GitHub rate limit; remaining is -1 while unknown
+	RateLimit func() (remaining int, reset time.Time)
+}
+
+// Dashboard serves a read-only HTML overview of the bot's state
+type Dashboard struct {
+	store   Store
+	opts    Options
+	logger  *slog.Logger
+	page    *template.Template
+	handler http.Handler
+}
+
+// New creates the dashboard
+func New(store Store, opts Options, logger *slog.Logger) *Dashboard {
+	d := &Dashboard{store: store, opts: opts, logger: logger}
+	d.page = template.Must(template.New("index.html").Funcs(template.FuncMap{
+		"time":     d.formatTime,
+		"ago":      ago,
+		"duration": duration,
+	}).ParseFS(assets, "templates/index.html"))
+
+	static, err := fs.Sub(assets, "static")
+	if err != nil {
+		panic(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", d.handleIndex)
+	mux.Handle("GET /static/", http.StripPrefix("/static/", http.FileServerFS(static)))
+	d.handler = mux
+	return d
+}
+
+// ServeHTTP implements http.Handler, asking for the DASHBOARD_PASSWORD when one is set
+func (d *Dashboard) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if password := d.opts.Config().DashboardPassword; password != "" {
+		_, got, ok := r.BasicAuth()
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(password)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="tg-release-bot"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+	}
+	d.handler.ServeHTTP(w, r)
+}
+
+// page is the data the index template renders
+type page struct {
+	Generated  time.Time
+	Jobs       []jobView
+	RateLimit  rateLimitView
+	Repos      []repoView
+	Chats      []chatView
+	Deliveries []db.Delivery
+	Failed     int // failed deliveries among those listed
+}
+
+type jobView struct {
+	Name string
+	scheduler.Status
+}
+
+type rateLimitView struct {
+	Known     bool
+	Remaining int
+	Reset     time.Time
+}
+
+type repoView struct {
+	Repo        string
+	Prereleases bool
+	Interval    time.Duration // override, 0 for the default or adaptive schedule
+	Schedule    db.RepoSchedule
+	LastRelease *db.ProcessedRelease
+}
+
+type chatView struct {
+	db.Chat
+	TimeZone      string
+	Delivery      string
+	Subscriptions []string // all repositories when empty
+	Prompt        string
+	Configured    bool // listed in the configuration file
+}
+
+func (d *Dashboard) handleIndex(w http.ResponseWriter, r *http.Request) {
+	data, err := d.collect(r.Context())
+	if err != nil {
+		d.logger.Error("Failed to collect dashboard data", "error", err)
+		http.Error(w, "Failed to load bot state, see the logs", http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := d.page.Execute(&buf, data); err != nil {
+		d.logger.Error("Failed to render dashboard", "error", err)
+		http.Error(w, "Failed to render the dashboard", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	buf.WriteTo(w)
+}
+
+// collect gathers the page data from the store, the schedulers and the configuration
+func (d *Dashboard) collect(ctx context.Context) (page, error) {
+	cfg := d.opts.Config()
+	data := page{Generated: time.Now()}
+
+	for _, job := range d.opts.Jobs {
+		data.Jobs = append(data.Jobs, jobView{Name: job.Name, Status: job.Status()})
+	}
+	if d.opts.RateLimit != nil {
+		remaining, reset := d.opts.RateLimit()
+		data.RateLimit = rateLimitView{Known: remaining >= 0, Remaining: remaining, Reset: reset}
+	}
+
+	repos, err := d.store.ListRepositories(ctx)
+	if err != nil {
+		return data, fmt.Errorf("failed to list repositories: %w", err)
+	}
+	for _, repo := range repos {
+		sch, err := d.store.GetRepoSchedule(ctx, repo.Owner, repo.Name)
+		if err != nil {
+			return data, fmt.Errorf("failed to get schedule of %s/%s: %w", repo.Owner, repo.Name, err)
+		}
+		view := repoView{
+			Repo:        repo.Owner + "/" + repo.Name,
+			Prereleases: repo.TrackPrereleases,
+			Interval:    sch.IntervalOverride,
+			Schedule:    sch,
+		}
+		history, err := d.store.ListReleaseHistory(ctx, repo.Owner, repo.Name, 1)
+		if err != nil {
+			return data, fmt.Errorf("failed to get releases of %s: %w", view.Repo, err)
+		}
+		if len(history) > 0 {
+			view.LastRelease = &history[0]
+		}
+		data.Repos = append(data.Repos, view)
+	}
+
+	chats, err := d.store.ListChats(ctx)
+	if err != nil {
+		return data, fmt.Errorf("failed to list chats: %w", err)
+	}
+	for _, c := range chats {
+		chatCfg, configured := cfg.ChatConfig(c.ID)
+		view := chatView{
+			Chat:          c,
+			TimeZone:      cfg.TimeZone,
+			Delivery:      config.DeliveryNotify,
+			Subscriptions: slices.Clone(chatCfg.Repositories),
+			Prompt:        chatCfg.Prompt,
+			Configured:    configured,
+		}
+		if chatCfg.TimeZone != "" {
+			view.TimeZone = chatCfg.TimeZone
+		}
+		if chatCfg.Delivery != "" {
+			view.Delivery = chatCfg.Delivery
+		}
+		subs, err := d.store.ListSubscriptions(ctx, c.ID)
+		if err != nil {
+			return data, fmt.Errorf("failed to list subscriptions of chat %d: %w", c.ID, err)
+		}
+		for _, sub := range subs {
+			repo := sub.RepoOwner + "/" + sub.RepoName
+			if !slices.ContainsFunc(view.Subscriptions, func(r string) bool { return strings.EqualFold(r, repo) }) {
+				view.Subscriptions = append(view.Subscriptions, repo)
+			}
+		}
+		data.Chats = append(data.Chats, view)
+	}
+
+	if data.Deliveries, err = d.store.ListRecentDeliveries(ctx, recentDeliveries); err != nil {
+		return data, fmt.Errorf("failed to list deliveries: %w", err)
+	}
+	for _, delivery := range data.Deliveries {
+		if delivery.State == db.DeliveryFailed {
+			data.Failed++
+		}
+	}
+	return data, nil
+}
+
+// formatTime shows a timestamp in the configured time zone
+func (d *Dashboard) formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "—"
+	}
+	if loc, err := time.LoadLocation(d.opts.Config().TimeZone); err == nil {
+		t = t.In(loc)
+	}
+	return t.Format("2006-01-02 15:04 MST")
+}
+
+// ago describes how long ago (or, for future times, in how long) t is
+func ago(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	d := time.Since(t)
+	suffix := " ago"
+	if d < 0 {
+		d, suffix = -d, " from now"
+	}
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm%s", int(d/time.Minute), suffix)
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh%s", int(d/time.Hour), suffix)
+	default:
+		return fmt.Sprintf("%dd%s", int(d/(24*time.Hour)), suffix)
+	}
+}
+
+// duration rounds a run duration for display
+func duration(d time.Duration) time.Duration {
+	if d < time.Second {
+		return d.Round(time.Millisecond)
+	}
+	return d.Round(time.Second)
+}
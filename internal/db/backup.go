@@ -0,0 +1,144 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ErrBackupUnsupported is returned for backups of a PostgreSQL database,
+// which are left to pg_dump
+var ErrBackupUnsupported = errors.New("backups are only supported for SQLite")
+
+// BackupInto writes a consistent snapshot of the database to path using
+// VACUUM INTO. The snapshot is written next to path and renamed into place,
+// so path never holds a partial file. The database stays online meanwhile.
+func (s *Store) BackupInto(ctx context.Context, path string) error {
+	if s.db.dialect != DialectSQLite {
+		return ErrBackupUnsupported
+	}
+
+	tmp := path + ".tmp"
+	os.Remove(tmp)
+	if _, err := s.db.exec(ctx, `VACUUM INTO ?`, tmp); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// ValidateBackup checks that path is an intact SQLite database with a schema
+// this version can migrate: it passes PRAGMA integrity_check and has no
+// migrations newer than the ones embedded in the binary
+func ValidateBackup(ctx context.Context, path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+
+	// query_only keeps the file untouched; the "?" also skips the WAL pragma
+	database, err := OpenWithoutMigrations(path + "?_pragma=query_only(1)")
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	var result string
+	if err := database.queryRow(ctx, `PRAGMA integrity_check`).Scan(&result); err != nil {
+		return fmt.Errorf("not a readable SQLite database: %w", err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("integrity check failed: %s", result)
+	}
+
+	var version int
+	if err := database.queryRow(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version); err != nil {
+		return fmt.Errorf("no schema version found: %w", err)
+	}
+	migrations, err := loadMigrations(DialectSQLite)
+	if err != nil {
+		return err
+	}
+	if latest := migrations[len(migrations)-1].Version; version > latest {
+		return fmt.Errorf("backup has schema version %d, newer than %d supported by this build", version, latest)
+	}
+	return nil
+}
+
+// RestoreBackup validates a backup and swaps it in as the database at dbPath.
+// The current database files are kept with a .before-restore suffix. On
+// failure the files already moved are put back; if that fails too, the error
+// names where they are. The bot must not be running; pending migrations are
+// applied on its next start.
+func RestoreBackup(ctx context.Context, backupPath, dbPath string) error {
+	if err := ValidateBackup(ctx, backupPath); err != nil {
+		return fmt.Errorf("invalid backup: %w", err)
+	}
+
+	tmp := dbPath + ".restore"
+	if err := copyFile(backupPath, tmp); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to copy backup: %w", err)
+	}
+
+	var moved []string
+	err := func() error {
+		for _, suffix := range []string{"", "-wal", "-shm"} {
+			err := os.Rename(dbPath+suffix, dbPath+".before-restore"+suffix)
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("failed to keep current database: %w", err)
+			}
+			moved = append(moved, suffix)
+		}
+		if err := os.Rename(tmp, dbPath); err != nil {
+			return fmt.Errorf("failed to move backup into place: %w", err)
+		}
+		return nil
+	}()
+	if err == nil {
+		return nil
+	}
+
+	os.Remove(tmp)
+	var stranded []string
+	for _, suffix := range moved {
+		if rerr := os.Rename(dbPath+".before-restore"+suffix, dbPath+suffix); rerr != nil {
+			stranded = append(stranded, dbPath+".before-restore"+suffix)
+		}
+	}
+	if len(stranded) > 0 {
+		return fmt.Errorf("%w; the original database files could not be moved back and are at %s", err, strings.Join(stranded, ", "))
+	}
+	return fmt.Errorf("%w; the original database is unchanged at %s", err, dbPath)
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,104 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newBackup returns a database at dir/bot.db with one repository and a
+// backup of it taken before a second repository was added
+func newBackup(t *testing.T, dir string) (dbPath, backupPath string) {
+	t.Helper()
+	ctx := context.Background()
+	dbPath = filepath.Join(dir, "bot.db")
+	backupPath = filepath.Join(dir, "backup.db")
+
+	database, err := Open(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	s := NewStore(database)
+	if err := s.AddRepository(ctx, "golang", "go", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.BackupInto(ctx, backupPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddRepository(ctx, "kubernetes", "kubernetes", false); err != nil {
+		t.Fatal(err)
+	}
+	return dbPath, backupPath
+}
+
+func countRepositories(t *testing.T, dbPath string) int {
+	t.Helper()
+	database, err := Open(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	repos, err := NewStore(database).ListRepositories(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(repos)
+}
+
+func TestRestoreBackup(t *testing.T) {
+	dbPath, backupPath := newBackup(t, t.TempDir())
+
+	if err := RestoreBackup(context.Background(), backupPath, dbPath); err != nil {
+		t.Fatalf("RestoreBackup: %v", err)
+	}
+	if n := countRepositories(t, dbPath); n != 1 {
+		t.Errorf("restored database has %d repositories, want the 1 from the backup", n)
+	}
+	if n := countRepositories(t, dbPath+".before-restore"); n != 2 {
+		t.Errorf("kept database has %d repositories, want 2", n)
+	}
+}
+
+func TestRestoreBackupRollsBack(t *testing.T) {
+	dbPath, backupPath := newBackup(t, t.TempDir())
+	if err := os.WriteFile(dbPath+"-wal", nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	original, err := os.ReadFile(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the main file moves aside, then the WAL cannot replace a directory
+	if err := os.MkdirAll(filepath.Join(dbPath+".before-restore-wal", "busy"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	err = RestoreBackup(context.Background(), backupPath, dbPath)
+	if err == nil {
+		t.Fatal("RestoreBackup succeeded, want an error")
+	}
+	if !strings.Contains(err.Error(), "unchanged at "+dbPath) {
+		t.Errorf("error %q does not say where the database is", err)
+	}
+
+	got, err := os.ReadFile(dbPath)
+	if err != nil {
+		t.Fatalf("original database was not moved back: %v", err)
+	}
+	if !bytes.Equal(got, original) {
+		t.Error("database at the original path is not the original database")
+	}
+	for _, path := range []string{dbPath + ".before-restore", dbPath + ".restore"} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s was left behind: %v", filepath.Base(path), err)
+		}
+	}
+	if _, err := os.Stat(dbPath + "-wal"); err != nil {
+		t.Errorf("WAL file is gone: %v", err)
+	}
+}
@@ -0,0 +1,643 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// conformanceChecks are the behaviours every Storage backend must have. They
+// expect an empty database and leave their rows behind.
+var conformanceChecks = []struct {
+	name string
+	run  func(ctx context.Context, s Storage) error
+}{
+	{"repositories", checkRepositories},
+	{"chats", checkChats},
+	{"processed releases", checkProcessed},
+	{"release history", checkReleaseHistory},
+	{"deliveries", checkDeliveries},
+	{"etags", checkETags},
+	{"settings", checkSettings},
+	{"schedule", checkSchedule},
+	{"pins", checkPins},
+	{"subscriptions", checkSubscriptions},
+	{"prompts", checkPrompts},
+	{"llm usage", checkLLMUsage},
+	{"repository removal cascade", checkRemoveCascade},
+	{"pruning", checkPrune},
+	{"optimize", checkOptimize},
+}
+
+// TestStorageConformance runs the conformance checks against a temporary
+// SQLite database, or against the PostgreSQL database in TEST_DATABASE_URL,
+// which must be empty
+func TestStorageConformance(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		dsn = filepath.Join(t.TempDir(), "conformance.db")
+	}
+	database, err := Open(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+
+	ctx := context.Background()
+	s := NewStore(database)
+	repos, err := s.ListRepositories(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repos) > 0 {
+		t.Fatalf("%s database is not empty", database.Dialect())
+	}
+
+	for _, check := range conformanceChecks {
+		t.Run(check.name, func(t *testing.T) {
+			if err := check.run(ctx, s); err != nil {
+				t.Errorf("%s: %v", database.Dialect(), err)
+			}
+		})
+	}
+}
+
+func checkRepositories(ctx context.Context, s Storage) error {
+	if err := s.AddRepository(ctx, "conf", "b", false); err != nil {
+		return err
+	}
+	if err := s.AddRepository(ctx, "conf", "a", false); err != nil {
+		return err
+	}
+	// Adding again updates the flag instead of duplicating the repository
+	if err := s.AddRepository(ctx, "conf", "a", true); err != nil {
+		return err
+	}
+
+	repos, err := s.ListRepositories(ctx)
+	if err != nil {
+		return err
+	}
+	if len(repos) != 2 || repos[0].Name != "a" || repos[1].Name != "b" {
+		return fmt.Errorf("want repositories [conf/a conf/b], got %v", repos)
+	}
+	if !repos[0].TrackPrereleases || repos[1].TrackPrereleases {
+		return fmt.Errorf("track_prereleases not stored: %v", repos)
+	}
+
+	if err := s.RemoveRepository(ctx, "conf", "b"); err != nil {
+		return err
+	}
+	repos, err = s.ListRepositories(ctx)
+	if err != nil {
+		return err
+	}
+	if len(repos) != 1 {
+		return fmt.Errorf("want 1 repository after removal, got %d", len(repos))
+	}
+	return s.RemoveRepository(ctx, "conf", "a")
+}
+
+func checkChats(ctx context.Context, s Storage) error {
+	// Telegram supergroup IDs do not fit in 32 bits
+	const chatID = -1001234567890
+	if err := s.AddChat(ctx, chatID, "old", "ru"); err != nil {
+		return err
+	}
+	if err := s.AddChat(ctx, chatID, "new", "en"); err != nil {
+		return err
+	}
+
+	chats, err := s.ListChats(ctx)
+	if err != nil {
+		return err
+	}
+	if len(chats) != 1 || chats[0].ID != chatID || chats[0].Title != "new" || chats[0].Language != "en" {
+		return fmt.Errorf("want chat %d updated to new/en, got %v", int64(chatID), chats)
+	}
+
+	if err := s.RemoveChat(ctx, chatID); err != nil {
+		return err
+	}
+	chats, err = s.ListChats(ctx)
+	if err != nil {
+		return err
+	}
+	if len(chats) != 0 {
+		return fmt.Errorf("want no chats after removal, got %v", chats)
+	}
+	return nil
+}
+
+func checkProcessed(ctx context.Context, s Storage) error {
+	processed, err := s.IsProcessed(ctx, "conf", "a", 1)
+	if err != nil {
+		return err
+	}
+	if processed {
+		return errors.New("unknown release reported as processed")
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := s.MarkProcessed(ctx, "conf", "a", 1, "v1.0.0", time.Now()); err != nil {
+			return fmt.Errorf("mark processed (attempt %d): %w", i+1, err)
+		}
+	}
+	processed, err = s.IsProcessed(ctx, "conf", "a", 1)
+	if err != nil {
+		return err
+	}
+	if !processed {
+		return errors.New("marked release not reported as processed")
+	}
+	return nil
+}
+
+func checkReleaseHistory(ctx context.Context, s Storage) error {
+	base := time.Date(2024, 2, 6, 18, 55, 0, 0, time.UTC)
+	for i, tag := range []string{"v2.0.0", "v2.1.0", "v2.2.0-rc.1"} {
+		err := s.RecordRelease(ctx, ProcessedRelease{
+			RepoOwner: "conf", RepoName: "history", ReleaseID: int64(100 + i), TagName: tag,
+			Name: "Release " + tag, Body: "- change", URL: "https://example.com/" + tag,
+			Prerelease: i == 2, Message: "<b>" + tag + "</b>", PublishedAt: base.Add(time.Duration(i) * 24 * time.Hour),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	history, err := s.ListReleaseHistory(ctx, "conf", "history", 2)
+	if err != nil {
+		return err
+	}
+	if len(history) != 2 || history[0].TagName != "v2.2.0-rc.1" || history[1].TagName != "v2.1.0" {
+		return fmt.Errorf("want the 2 newest releases newest first, got %v", history)
+	}
+	if !history[0].Prerelease || !history[0].PublishedAt.Equal(base.Add(48*time.Hour)) {
+		return fmt.Errorf("release details not stored: %+v", history[0])
+	}
+
+	r, ok, err := s.GetReleaseByTag(ctx, "conf", "history", "v2.0.0")
+	if err != nil {
+		return err
+	}
+	if !ok || r.Message != "<b>v2.0.0</b>" || r.URL != "https://example.com/v2.0.0" {
+		return fmt.Errorf("want stored v2.0.0 with its message, got %+v (found=%v)", r, ok)
+	}
+	if _, ok, err := s.GetReleaseByTag(ctx, "conf", "history", "v9.9.9"); err != nil || ok {
+		return fmt.Errorf("want unknown tag not found, got found=%v err=%v", ok, err)
+	}
+	return nil
+}
+
+func checkDeliveries(ctx context.Context, s Storage) error {
+	claim := func(lease time.Duration) (int, error) {
+		return s.ClaimDelivery(ctx, "conf", "a", 1, 10, lease, 5)
+	}
+
+	steps := []struct {
+		name  string
+		lease time.Duration
+		want  int
+		after func() error
+	}{
+		{"first claim", time.Minute, 1, nil},
+		{"claim under lease", time.Minute, 0, func() error {
+			return s.MarkDeliveryFailed(ctx, "conf", "a", 1, 10, 1, errors.New("send failed"))
+		}},
+		{"claim after failure", -time.Minute, 2, nil},
+		{"claim after expired lease", time.Minute, 3, func() error {
+			if err := s.MarkDelivered(ctx, "conf", "a", 1, 10, 2); !errors.Is(err, ErrLeaseLost) {
+				return fmt.Errorf("want ErrLeaseLost for a taken over claim, got %v", err)
+			}
+			return s.MarkDelivered(ctx, "conf", "a", 1, 10, 3)
+		}},
+		{"claim after delivery", time.Minute, 0, nil},
+	}
+	for _, step := range steps {
+		attempt, err := claim(step.lease)
+		if err != nil {
+			return fmt.Errorf("%s: %w", step.name, err)
+		}
+		if attempt != step.want {
+			return fmt.Errorf("%s: want attempt %d, got %d", step.name, step.want, attempt)
+		}
+		if step.after != nil {
+			if err := step.after(); err != nil {
+				return fmt.Errorf("%s: %w", step.name, err)
+			}
+		}
+	}
+
+	recent, err := s.ListRecentDeliveries(ctx, 10)
+	if err != nil {
+		return err
+	}
+	if len(recent) != 1 || recent[0].State != DeliveryDelivered || recent[0].Attempts != 3 || recent[0].LastError != "" {
+		return fmt.Errorf("want one delivered delivery after 3 attempts, got %+v", recent)
+	}
+	if d, ok, err := s.GetDelivery(ctx, "conf", "a", 1, 10); err != nil || !ok || d.State != DeliveryDelivered || d.Attempts != 3 {
+		return fmt.Errorf("want the delivered delivery, got %+v found=%v err=%v", d, ok, err)
+	}
+	if _, ok, err := s.GetDelivery(ctx, "conf", "a", 1, 11); err != nil || ok {
+		return fmt.Errorf("want an unclaimed delivery not found, got found=%v err=%v", ok, err)
+	}
+
+	// a failed pair is given up after maxAttempts
+	for attempt := 1; attempt <= 2; attempt++ {
+		got, err := s.ClaimDelivery(ctx, "conf", "a", 1, 20, time.Minute, 2)
+		if err != nil {
+			return err
+		}
+		if got != attempt {
+			return fmt.Errorf("retry %d: want attempt %d, got %d", attempt, attempt, got)
+		}
+		if err := s.MarkDeliveryFailed(ctx, "conf", "a", 1, 20, attempt, errors.New("send failed")); err != nil {
+			return err
+		}
+	}
+	if got, err := s.ClaimDelivery(ctx, "conf", "a", 1, 20, time.Minute, 2); err != nil || got != 0 {
+		return fmt.Errorf("want a failed pair at the attempt limit not claimed, got attempt %d err=%v", got, err)
+	}
+	if d, _, err := s.GetDelivery(ctx, "conf", "a", 1, 20); err != nil || d.State != DeliveryFailed || d.LastError != "send failed" {
+		return fmt.Errorf("want the failed delivery with its error, got %+v err=%v", d, err)
+	}
+	return nil
+}
+
+func checkETags(ctx context.Context, s Storage) error {
+	etag, err := s.GetETag(ctx, "conf", "etag")
+	if err != nil {
+		return err
+	}
+	if etag != "" {
+		return fmt.Errorf("want empty ETag for unknown repository, got %q", etag)
+	}
+
+	for _, value := range []string{`"one"`, `W/"two"`} {
+		if err := s.PutETag(ctx, "conf", "etag", value); err != nil {
+			return err
+		}
+		etag, err := s.GetETag(ctx, "conf", "etag")
+		if err != nil {
+			return err
+		}
+		if etag != value {
+			return fmt.Errorf("want ETag %q, got %q", value, etag)
+		}
+	}
+	return nil
+}
+
+func checkSettings(ctx context.Context, s Storage) error {
+	const key = "conformance"
+	for _, value := range []string{"one", "two"} {
+		if err := s.SetSetting(ctx, key, value); err != nil {
+			return err
+		}
+		got, err := s.GetSetting(ctx, key)
+		if err != nil {
+			return err
+		}
+		if got != value {
+			return fmt.Errorf("want setting %q, got %q", value, got)
+		}
+	}
+	all, err := s.ListSettings(ctx)
+	if err != nil {
+		return err
+	}
+	if all[key] != "two" {
+		return fmt.Errorf("want listed setting %q, got %q", "two", all[key])
+	}
+
+	if err := s.DeleteSetting(ctx, key); err != nil {
+		return err
+	}
+	got, err := s.GetSetting(ctx, key)
+	if err != nil {
+		return err
+	}
+	if got != "" {
+		return fmt.Errorf("want deleted setting to be empty, got %q", got)
+	}
+	return nil
+}
+
+func checkSchedule(ctx context.Context, s Storage) error {
+	if err := s.AddRepository(ctx, "conf", "sched", false); err != nil {
+		return err
+	}
+	defer s.RemoveRepository(ctx, "conf", "sched")
+
+	now := time.Now().UTC().Truncate(time.Second)
+	due, err := s.ListDueRepositories(ctx, now)
+	if err != nil {
+		return err
+	}
+	if len(due) != 1 {
+		return fmt.Errorf("want never-checked repository to be due, got %v", due)
+	}
+
+	next := now.Add(time.Hour)
+	err = s.SaveRepoSchedule(ctx, RepoSchedule{
+		RepoOwner: "conf", RepoName: "sched",
+		NextCheckAt: next, LastCheckedAt: now, Cadence: 6 * time.Hour,
+	})
+	if err != nil {
+		return err
+	}
+	due, err = s.ListDueRepositories(ctx, now)
+	if err != nil {
+		return err
+	}
+	if len(due) != 0 {
+		return fmt.Errorf("want no due repositories before next check, got %v", due)
+	}
+	nextDue, err := s.NextDueAt(ctx)
+	if err != nil {
+		return err
+	}
+	if !nextDue.Equal(next) {
+		return fmt.Errorf("want next due %s, got %s", next, nextDue)
+	}
+
+	if err := s.SetRepoInterval(ctx, "conf", "sched", 30*time.Minute); err != nil {
+		return err
+	}
+	sch, err := s.GetRepoSchedule(ctx, "conf", "sched")
+	if err != nil {
+		return err
+	}
+	if sch.IntervalOverride != 30*time.Minute || sch.Cadence != 6*time.Hour {
+		return fmt.Errorf("want override 30m and cadence 6h, got %s and %s", sch.IntervalOverride, sch.Cadence)
+	}
+	return nil
+}
+
+func checkPins(ctx context.Context, s Storage) error {
+	if err := s.SetPin(ctx, 10, "conf", "a", "v1.0.0"); err != nil {
+		return err
+	}
+	if err := s.SetPin(ctx, 10, "conf", "a", "v1.1.0"); err != nil {
+		return err
+	}
+
+	pins, err := s.ListRepoPins(ctx, "conf", "a")
+	if err != nil {
+		return err
+	}
+	if len(pins) != 1 || pins[0].Version != "v1.1.0" {
+		return fmt.Errorf("want one pin at v1.1.0, got %v", pins)
+	}
+
+	if err := s.RemovePin(ctx, 10, "conf", "a"); err != nil {
+		return err
+	}
+	pins, err = s.ListPins(ctx, 10)
+	if err != nil {
+		return err
+	}
+	if len(pins) != 0 {
+		return fmt.Errorf("want no pins after removal, got %v", pins)
+	}
+	return nil
+}
+
+func checkPrompts(ctx context.Context, s Storage) error {
+	if err := s.SavePrompt(ctx, "conf", "system one", "user"); err != nil {
+		return err
+	}
+	if err := s.SavePrompt(ctx, "conf", "system two", "user"); err != nil {
+		return err
+	}
+	p, ok, err := s.GetPrompt(ctx, "conf")
+	if err != nil {
+		return err
+	}
+	if !ok || p.System != "system two" {
+		return fmt.Errorf("want updated prompt, got %v (found=%v)", p, ok)
+	}
+
+	if err := s.AssignPrompt(ctx, "chat", "10", "conf"); err != nil {
+		return err
+	}
+	name, err := s.PromptAssignment(ctx, "chat", "10")
+	if err != nil {
+		return err
+	}
+	if name != "conf" {
+		return fmt.Errorf("want assigned prompt conf, got %q", name)
+	}
+	if err := s.UnassignPrompt(ctx, "chat", "10"); err != nil {
+		return err
+	}
+	name, err = s.PromptAssignment(ctx, "chat", "10")
+	if err != nil {
+		return err
+	}
+	if name != "" {
+		return fmt.Errorf("want no assignment after unassign, got %q", name)
+	}
+	return nil
+}
+
+func checkLLMUsage(ctx context.Context, s Storage) error {
+	since := time.Now().Add(-time.Minute)
+	usage := []LLMUsage{
+		{Repo: "conf/a", Model: "m1", PromptTokens: 100, CompletionTokens: 10, CostUSD: 0.25},
+		{Repo: "conf/a", Model: "m2", PromptTokens: 200, CompletionTokens: 20, CostUSD: 0.5},
+	}
+	for _, u := range usage {
+		if err := s.RecordLLMUsage(ctx, u); err != nil {
+			return err
+		}
+	}
+
+	cost, err := s.LLMCostSince(ctx, since)
+	if err != nil {
+		return err
+	}
+	if cost != 0.75 {
+		return fmt.Errorf("want total cost 0.75, got %v", cost)
+	}
+
+	byRepo, err := s.LLMSpendByRepo(ctx, since)
+	if err != nil {
+		return err
+	}
+	if len(byRepo) != 1 || byRepo[0].Calls != 2 || byRepo[0].PromptTokens != 300 {
+		return fmt.Errorf("want one repository with 2 calls and 300 prompt tokens, got %v", byRepo)
+	}
+
+	byModel, err := s.LLMSpendByModel(ctx, since)
+	if err != nil {
+		return err
+	}
+	if len(byModel) != 2 || byModel[0].Key != "m2" {
+		return fmt.Errorf("want models ordered by cost [m2 m1], got %v", byModel)
+	}
+	return nil
+}
+
+func checkSubscriptions(ctx context.Context, s Storage) error {
+	const chatID = -1009876543210
+	if err := s.AddChat(ctx, chatID, "subs", "en"); err != nil {
+		return err
+	}
+	for _, name := range []string{"b", "a", "a"} {
+		if err := s.AddSubscription(ctx, chatID, "subs", name); err != nil {
+			return err
+		}
+	}
+
+	subs, err := s.ListSubscriptions(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	if len(subs) != 2 || subs[0].RepoName != "a" || subs[1].RepoName != "b" {
+		return fmt.Errorf("want subscriptions [subs/a subs/b], got %v", subs)
+	}
+
+	if err := s.RemoveSubscription(ctx, chatID, "subs", "b"); err != nil {
+		return err
+	}
+	if subs, err = s.ListSubscriptions(ctx, chatID); err != nil {
+		return err
+	}
+	if len(subs) != 1 || subs[0].RepoName != "a" {
+		return fmt.Errorf("want subscriptions [subs/a] after removal, got %v", subs)
+	}
+
+	// Removing the chat removes its subscriptions
+	if err := s.RemoveChat(ctx, chatID); err != nil {
+		return err
+	}
+	if subs, err = s.ListSubscriptions(ctx, chatID); err != nil {
+		return err
+	}
+	if len(subs) != 0 {
+		return fmt.Errorf("want no subscriptions after chat removal, got %v", subs)
+	}
+	return nil
+}
+
+func checkRemoveCascade(ctx context.Context, s Storage) error {
+	if err := s.AddRepository(ctx, "cascade", "a", false); err != nil {
+		return err
+	}
+	setup := []error{
+		s.PutETag(ctx, "cascade", "a", `"etag"`),
+		s.SetPin(ctx, 10, "cascade", "a", "v1.0.0"),
+		s.AddSubscription(ctx, 10, "cascade", "a"),
+		s.RecordRelease(ctx, ProcessedRelease{RepoOwner: "cascade", RepoName: "a", ReleaseID: 1, TagName: "v1.0.0", PublishedAt: time.Now()}),
+		s.AssignPrompt(ctx, "repo", "cascade/a", "conf"),
+	}
+	if err := errors.Join(setup...); err != nil {
+		return err
+	}
+
+	if err := s.RemoveRepository(ctx, "cascade", "a"); err != nil {
+		return err
+	}
+
+	etag, err := s.GetETag(ctx, "cascade", "a")
+	if err != nil {
+		return err
+	}
+	pins, err := s.ListRepoPins(ctx, "cascade", "a")
+	if err != nil {
+		return err
+	}
+	history, err := s.ListReleaseHistory(ctx, "cascade", "a", 10)
+	if err != nil {
+		return err
+	}
+	prompt, err := s.PromptAssignment(ctx, "repo", "cascade/a")
+	if err != nil {
+		return err
+	}
+	subs, err := s.ListSubscriptions(ctx, 10)
+	if err != nil {
+		return err
+	}
+	if etag != "" || len(pins) != 0 || len(history) != 0 || prompt != "" || len(subs) != 0 {
+		return fmt.Errorf("repository rows left after removal: etag=%q pins=%d history=%d prompt=%q subscriptions=%d",
+			etag, len(pins), len(history), prompt, len(subs))
+	}
+	return nil
+}
+
+func checkPrune(ctx context.Context, s Storage) error {
+	if err := s.AddRepository(ctx, "prune", "a", false); err != nil {
+		return err
+	}
+	defer s.RemoveRepository(ctx, "prune", "a")
+
+	now := time.Now().UTC()
+	for i := 0; i < 5; i++ {
+		err := s.RecordRelease(ctx, ProcessedRelease{
+			RepoOwner: "prune", RepoName: "a", ReleaseID: int64(i + 1), TagName: fmt.Sprintf("v1.%d.0", i),
+			PublishedAt: now.AddDate(0, 0, -100+i),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	// Rows of a repository that is not tracked are orphans
+	if err := s.PutETag(ctx, "prune", "gone", `"etag"`); err != nil {
+		return err
+	}
+	if err := s.AssignPrompt(ctx, "repo", "prune/gone", "security"); err != nil {
+		return err
+	}
+	if err := s.AssignPrompt(ctx, "repo", "prune/a", "security"); err != nil {
+		return err
+	}
+
+	stats, err := s.Prune(ctx, now.AddDate(0, 0, -30), 2)
+	if err != nil {
+		return err
+	}
+	// Earlier checks leave rows behind, so only lower bounds hold here
+	if stats.Releases < 3 || stats.Orphans < 2 {
+		return fmt.Errorf("want at least 3 releases and 2 orphans pruned, got %+v", stats)
+	}
+
+	history, err := s.ListReleaseHistory(ctx, "prune", "a", 10)
+	if err != nil {
+		return err
+	}
+	if len(history) != 2 || history[0].TagName != "v1.4.0" {
+		return fmt.Errorf("want the 2 newest releases kept, got %v", history)
+	}
+	etag, err := s.GetETag(ctx, "prune", "gone")
+	if err != nil {
+		return err
+	}
+	if etag != "" {
+		return fmt.Errorf("want orphaned ETag pruned, got %q", etag)
+	}
+	if name, err := s.PromptAssignment(ctx, "repo", "prune/gone"); err != nil || name != "" {
+		return fmt.Errorf("want orphaned prompt assignment pruned, got %q, %v", name, err)
+	}
+	if name, err := s.PromptAssignment(ctx, "repo", "prune/a"); err != nil || name != "security" {
+		return fmt.Errorf("want prompt assignment of a tracked repository kept, got %q, %v", name, err)
+	}
+	return nil
+}
+
+func checkOptimize(ctx context.Context, s Storage) error {
+	stats, err := s.Optimize(ctx)
+	if err != nil {
+		return err
+	}
+	if stats.SizeBefore <= 0 || stats.SizeAfter <= 0 {
+		return fmt.Errorf("want database sizes reported, got %+v", stats)
+	}
+	return nil
+}
@@ -4,34 +4,67 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strconv"
+	"strings"
 
+	_ "github.com/lib/pq"
 	_ "modernc.org/sqlite"
 )
 
+// Supported database backends
+const (
+	DialectSQLite   = "sqlite"
+	DialectPostgres = "postgres"
+)
+
 type DB struct {
-	conn *sql.DB
+	conn    *sql.DB
+	dialect string
 }
 
 // Open creates a new database connection and runs migrations
-func Open(dbPath string) (*DB, error) {
-	conn, err := sql.Open("sqlite", dbPath)
+func Open(dsn string) (*DB, error) {
+	db, err := OpenWithoutMigrations(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	// Run migrations
+	if _, err := db.Migrate(context.Background()); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migration failed: %w", err)
+	}
+
+	return db, nil
+}
+
+// OpenWithoutMigrations creates a new database connection and leaves the
+// schema as it is. A postgres:// or postgresql:// URL opens PostgreSQL,
+// anything else is treated as an SQLite file path.
+func OpenWithoutMigrations(dsn string) (*DB, error) {
+	dialect := DialectSQLite
+	driver := "sqlite"
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		dialect = DialectPostgres
+		driver = "postgres"
+	} else if !strings.Contains(dsn, "?") {
+		// Concurrent workers write to the database: wait for locks instead of
+		// failing with SQLITE_BUSY, and let readers proceed during writes
+		dsn += "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	}
+
+	conn, err := sql.Open(driver, dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
 	// Test connection
 	if err := conn.Ping(); err != nil {
+		conn.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	db := &DB{conn: conn}
-
-	// Run migrations
-	if err := db.migrate(); err != nil {
-		return nil, fmt.Errorf("migration failed: %w", err)
-	}
-
-	return db, nil
+	return &DB{conn: conn, dialect: dialect}, nil
 }
 
 // Close closes the database connection
@@ -39,53 +72,44 @@ func (db *DB) Close() error {
 	return db.conn.Close()
 }
 
-// migrate runs database migrations
-func (db *DB) migrate() error {
-	migrations := []string{
-		`CREATE TABLE IF NOT EXISTS repos (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			owner TEXT NOT NULL,
-			name TEXT NOT NULL,
-			track_prereleases INTEGER NOT NULL DEFAULT 0,
-			UNIQUE(owner, name)
-		)`,
-		`CREATE TABLE IF NOT EXISTS chats (
-			id INTEGER PRIMARY KEY,
-			title TEXT,
-			language TEXT DEFAULT 'ru'
-		)`,
-		`CREATE TABLE IF NOT EXISTS processed_releases (
-			repo_owner TEXT NOT NULL,
-			repo_name  TEXT NOT NULL,
-			release_id INTEGER NOT NULL,
-			tag_name   TEXT,
-			published_at TEXT,
-			created_at   TEXT DEFAULT (datetime('now')),
-			PRIMARY KEY (repo_owner, repo_name, release_id)
-		)`,
-		`CREATE TABLE IF NOT EXISTS etags (
-			repo_owner TEXT NOT NULL,
-			repo_name  TEXT NOT NULL,
-			etag       TEXT NOT NULL,
-			updated_at TEXT DEFAULT (datetime('now')),
-			PRIMARY KEY (repo_owner, repo_name)
-		)`,
-		`CREATE TABLE IF NOT EXISTS settings (
-			key TEXT PRIMARY KEY,
-			value TEXT NOT NULL
-		)`,
+// Dialect returns the database backend, DialectSQLite or DialectPostgres
+func (db *DB) Dialect() string {
+	return db.dialect
+}
+
+// BeginTx starts a new transaction
+func (db *DB) BeginTx(ctx context.Context) (*sql.Tx, error) {
+	return db.conn.BeginTx(ctx, nil)
+}
+
+// rebind rewrites ? placeholders into the backend's syntax. Queries are
+// written with ? and must not contain literal question marks.
+func (db *DB) rebind(query string) string {
+	if db.dialect != DialectPostgres || !strings.Contains(query, "?") {
+		return query
 	}
 
-	for _, migration := range migrations {
-		if _, err := db.conn.Exec(migration); err != nil {
-			return fmt.Errorf("failed to execute migration: %w", err)
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
 		}
+		b.WriteRune(r)
 	}
+	return b.String()
+}
 
-	return nil
+func (db *DB) exec(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return db.conn.ExecContext(ctx, db.rebind(query), args...)
 }
 
-// BeginTx starts a new transaction
-func (db *DB) BeginTx(ctx context.Context) (*sql.Tx, error) {
-	return db.conn.BeginTx(ctx, nil)
+func (db *DB) query(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return db.conn.QueryContext(ctx, db.rebind(query), args...)
+}
+
+func (db *DB) queryRow(ctx context.Context, query string, args ...any) *sql.Row {
+	return db.conn.QueryRowContext(ctx, db.rebind(query), args...)
 }
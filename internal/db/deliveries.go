@@ -0,0 +1,161 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Delivery states
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// ErrLeaseLost is returned when recording the outcome of a delivery whose
+// claim was taken over by another run after its lease expired
+var ErrLeaseLost = errors.New("delivery claim was taken over")
+
+// Delivery is the state of sending a release to a chat
+type Delivery struct {
+	RepoOwner string    `json:"repo_owner"`
+	RepoName  string    `json:"repo_name"`
+	ReleaseID int64     `json:"release_id"`
+	ChatID    int64     `json:"chat_id"`
+	TagName   string    `json:"tag_name"` // empty while the release is not recorded yet
+	State     string    `json:"state"`
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"last_error"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ClaimDelivery claims the right to send a release to a chat. It returns the
+// attempt number of the claim, which the caller passes to MarkDelivered or
+// MarkDeliveryFailed to record the outcome, or 0 when the pair cannot be
+// claimed. A pair already delivered, or pending under another unexpired
+// lease, cannot be claimed; a pair whose lease expired (the claimer crashed
+// or overran) can be, and so can a failed pair that has been attempted fewer
+// than maxAttempts times.
+func (s *Store) ClaimDelivery(ctx context.Context, repoOwner, repoName string, releaseID, chatID int64, lease time.Duration, maxAttempts int) (int, error) {
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	query := `INSERT INTO deliveries (repo_owner, repo_name, release_id, chat_id, state, lease_until, attempts, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, 1, ?)
+		ON CONFLICT (repo_owner, repo_name, release_id, chat_id) DO UPDATE SET
+			state = excluded.state,
+			lease_until = excluded.lease_until,
+			attempts = deliveries.attempts + 1,
+			updated_at = excluded.updated_at
+		WHERE (deliveries.state = ? AND deliveries.attempts < ?)
+			OR (deliveries.state = ? AND deliveries.lease_until < ?)`
+	res, err := tx.ExecContext(ctx, s.db.rebind(query),
+		repoOwner, repoName, releaseID, chatID, DeliveryPending, now.Add(lease).Format(time.RFC3339), now.Format(time.RFC3339),
+		DeliveryFailed, maxAttempts, DeliveryPending, now.Format(time.RFC3339))
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim delivery: %w", err)
+	}
+
+	claimed, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if claimed != 1 {
+		return 0, nil
+	}
+
+	var attempt int
+	query = `SELECT attempts FROM deliveries
+		WHERE repo_owner = ? AND repo_name = ? AND release_id = ? AND chat_id = ?`
+	if err := tx.QueryRowContext(ctx, s.db.rebind(query), repoOwner, repoName, releaseID, chatID).Scan(&attempt); err != nil {
+		return 0, fmt.Errorf("failed to read delivery claim: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return attempt, nil
+}
+
+// GetDelivery returns the state of sending a release to a chat
+func (s *Store) GetDelivery(ctx context.Context, repoOwner, repoName string, releaseID, chatID int64) (Delivery, bool, error) {
+	query := `SELECT state, attempts, COALESCE(last_error, ''), updated_at FROM deliveries
+		WHERE repo_owner = ? AND repo_name = ? AND release_id = ? AND chat_id = ?`
+	d := Delivery{RepoOwner: repoOwner, RepoName: repoName, ReleaseID: releaseID, ChatID: chatID}
+	var updatedAt sql.NullString
+	err := s.db.queryRow(ctx, query, repoOwner, repoName, releaseID, chatID).Scan(&d.State, &d.Attempts, &d.LastError, &updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Delivery{}, false, nil
+	}
+	if err != nil {
+		return Delivery{}, false, err
+	}
+	d.UpdatedAt = parseTime(updatedAt)
+	return d, true, nil
+}
+
+// MarkDelivered records that a claimed release was sent to a chat
+func (s *Store) MarkDelivered(ctx context.Context, repoOwner, repoName string, releaseID, chatID int64, attempt int) error {
+	return s.finishDelivery(ctx, repoOwner, repoName, releaseID, chatID, attempt, DeliveryDelivered, "")
+}
+
+// MarkDeliveryFailed records that sending a claimed release to a chat failed
+func (s *Store) MarkDeliveryFailed(ctx context.Context, repoOwner, repoName string, releaseID, chatID int64, attempt int, sendErr error) error {
+	return s.finishDelivery(ctx, repoOwner, repoName, releaseID, chatID, attempt, DeliveryFailed, sendErr.Error())
+}
+
+// finishDelivery records the outcome of a claim. It returns ErrLeaseLost
+// unless the pair is still pending under the caller's attempt.
+func (s *Store) finishDelivery(ctx context.Context, repoOwner, repoName string, releaseID, chatID int64, attempt int, state, lastError string) error {
+	query := `UPDATE deliveries SET state = ?, lease_until = NULL, last_error = ?, updated_at = ?
+		WHERE repo_owner = ? AND repo_name = ? AND release_id = ? AND chat_id = ?
+			AND state = ? AND attempts = ?`
+	res, err := s.db.exec(ctx, query, state, lastError, time.Now().UTC().Format(time.RFC3339),
+		repoOwner, repoName, releaseID, chatID, DeliveryPending, attempt)
+	if err != nil {
+		return err
+	}
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// ListRecentDeliveries returns the most recently updated deliveries, newest first
+func (s *Store) ListRecentDeliveries(ctx context.Context, limit int) ([]Delivery, error) {
+	query := `SELECT d.repo_owner, d.repo_name, d.release_id, d.chat_id, COALESCE(p.tag_name, ''),
+			d.state, d.attempts, COALESCE(d.last_error, ''), d.updated_at
+		FROM deliveries d
+		LEFT JOIN processed_releases p
+			ON p.repo_owner = d.repo_owner AND p.repo_name = d.repo_name AND p.release_id = d.release_id
+		ORDER BY d.updated_at DESC
+		LIMIT ?`
+	rows, err := s.db.query(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []Delivery
+	for rows.Next() {
+		var d Delivery
+		var updatedAt sql.NullString
+		if err := rows.Scan(&d.RepoOwner, &d.RepoName, &d.ReleaseID, &d.ChatID, &d.TagName,
+			&d.State, &d.Attempts, &d.LastError, &updatedAt); err != nil {
+			return nil, err
+		}
+		d.UpdatedAt = parseTime(updatedAt)
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
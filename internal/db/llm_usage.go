@@ -0,0 +1,76 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// LLMUsage represents token usage of a single advisor call
+type LLMUsage struct {
+	Repo             string    `json:"repo"`
+	Tag              string    `json:"tag"`
+	Model            string    `json:"model"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	CostUSD          float64   `json:"cost_usd"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// LLMSpend is aggregated advisor usage grouped by repository or model
+type LLMSpend struct {
+	Key              string  `json:"key"`
+	Calls            int     `json:"calls"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+}
+
+// RecordLLMUsage stores usage of a single advisor call
+func (s *Store) RecordLLMUsage(ctx context.Context, u LLMUsage) error {
+	if u.CreatedAt.IsZero() {
+		u.CreatedAt = time.Now()
+	}
+	query := `INSERT INTO llm_usage (repo, tag, model, prompt_tokens, completion_tokens, cost_usd, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)`
+	_, err := s.db.exec(ctx, query, u.Repo, u.Tag, u.Model, u.PromptTokens, u.CompletionTokens, u.CostUSD, u.CreatedAt.UTC().Format(time.RFC3339))
+	return err
+}
+
+// LLMCostSince returns the total advisor cost since the given time
+func (s *Store) LLMCostSince(ctx context.Context, since time.Time) (float64, error) {
+	query := `SELECT COALESCE(SUM(cost_usd), 0) FROM llm_usage WHERE created_at >= ?`
+	var cost float64
+	err := s.db.queryRow(ctx, query, since.UTC().Format(time.RFC3339)).Scan(&cost)
+	return cost, err
+}
+
+// LLMSpendByRepo returns advisor usage since the given time grouped by repository
+func (s *Store) LLMSpendByRepo(ctx context.Context, since time.Time) ([]LLMSpend, error) {
+	return s.llmSpendBy(ctx, "repo", since)
+}
+
+// LLMSpendByModel returns advisor usage since the given time grouped by model
+func (s *Store) LLMSpendByModel(ctx context.Context, since time.Time) ([]LLMSpend, error) {
+	return s.llmSpendBy(ctx, "model", since)
+}
+
+// llmSpendBy aggregates usage by a column; column must be a trusted identifier
+func (s *Store) llmSpendBy(ctx context.Context, column string, since time.Time) ([]LLMSpend, error) {
+	query := fmt.Sprintf(`SELECT %s, COUNT(*), SUM(prompt_tokens), SUM(completion_tokens), SUM(cost_usd)
+		FROM llm_usage WHERE created_at >= ? GROUP BY %s ORDER BY SUM(cost_usd) DESC, COUNT(*) DESC`, column, column)
+	rows, err := s.db.query(ctx, query, since.UTC().Format(time.RFC3339))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var spend []LLMSpend
+	for rows.Next() {
+		var sp LLMSpend
+		if err := rows.Scan(&sp.Key, &sp.Calls, &sp.PromptTokens, &sp.CompletionTokens, &sp.CostUSD); err != nil {
+			return nil, err
+		}
+		spend = append(spend, sp)
+	}
+	return spend, rows.Err()
+}
@@ -0,0 +1,118 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// PruneStats counts rows removed by Prune
+type PruneStats struct {
+	Releases   int64 `json:"releases"`
+	Deliveries int64 `json:"deliveries"`
+	Orphans    int64 `json:"orphans"`
+}
+
+// OptimizeStats reports the database size in bytes around Optimize; zero when unknown
+type OptimizeStats struct {
+	SizeBefore int64 `json:"size_before"`
+	SizeAfter  int64 `json:"size_after"`
+}
+
+// repoTables lists tables holding per-repository rows, keyed by repo_owner and repo_name
+var repoTables = []string{"etags", "repo_schedule", "processed_releases", "deliveries", "pins", "subscriptions"}
+
+// Prune removes processed releases published before the given time, keeping
+// the newest keepPerRepo releases of every repository so the releases GitHub
+// still lists stay deduplicated. It also removes finished deliveries older
+// than the given time and rows left behind by repositories no longer tracked.
+func (s *Store) Prune(ctx context.Context, before time.Time, keepPerRepo int) (PruneStats, error) {
+	var stats PruneStats
+
+	query := `DELETE FROM processed_releases WHERE (repo_owner, repo_name, release_id) IN (
+		SELECT repo_owner, repo_name, release_id FROM (
+			SELECT repo_owner, repo_name, release_id, published_at,
+				ROW_NUMBER() OVER (PARTITION BY repo_owner, repo_name ORDER BY published_at DESC, release_id DESC) AS rank
+			FROM processed_releases
+		) ranked
+		WHERE rank > ? AND published_at < ?
+	)`
+	res, err := s.db.exec(ctx, query, keepPerRepo, formatTime(before))
+	if err != nil {
+		return stats, fmt.Errorf("failed to prune processed releases: %w", err)
+	}
+	stats.Releases, _ = res.RowsAffected()
+
+	query = `DELETE FROM deliveries WHERE state <> ? AND updated_at < ?`
+	res, err = s.db.exec(ctx, query, DeliveryPending, formatTime(before))
+	if err != nil {
+		return stats, fmt.Errorf("failed to prune deliveries: %w", err)
+	}
+	stats.Deliveries, _ = res.RowsAffected()
+
+	for _, table := range repoTables {
+		query := fmt.Sprintf(`DELETE FROM %s WHERE NOT EXISTS (
+			SELECT 1 FROM repos r WHERE r.owner = %s.repo_owner AND r.name = %s.repo_name
+		)`, table, table, table)
+		res, err := s.db.exec(ctx, query)
+		if err != nil {
+			return stats, fmt.Errorf("failed to prune orphaned %s: %w", table, err)
+		}
+		n, _ := res.RowsAffected()
+		stats.Orphans += n
+	}
+
+	query = `DELETE FROM prompt_assignments WHERE scope = ? AND NOT EXISTS (
+		SELECT 1 FROM repos r WHERE r.owner || '/' || r.name = prompt_assignments.target
+	)`
+	res, err = s.db.exec(ctx, query, "repo")
+	if err != nil {
+		return stats, fmt.Errorf("failed to prune orphaned prompt assignments: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	stats.Orphans += n
+
+	return stats, nil
+}
+
+// Optimize reclaims free space and refreshes query planner statistics:
+// PRAGMA optimize and VACUUM on SQLite, ANALYZE on PostgreSQL where
+// autovacuum reclaims space
+func (s *Store) Optimize(ctx context.Context) (OptimizeStats, error) {
+	var stats OptimizeStats
+	var err error
+
+	if stats.SizeBefore, err = s.databaseSize(ctx); err != nil {
+		return stats, err
+	}
+
+	statements := []string{"PRAGMA optimize", "VACUUM"}
+	if s.db.dialect == DialectPostgres {
+		statements = []string{"ANALYZE"}
+	}
+	for _, stmt := range statements {
+		if _, err := s.db.exec(ctx, stmt); err != nil {
+			return stats, fmt.Errorf("%s failed: %w", stmt, err)
+		}
+	}
+
+	stats.SizeAfter, err = s.databaseSize(ctx)
+	return stats, err
+}
+
+func (s *Store) databaseSize(ctx context.Context) (int64, error) {
+	query := `SELECT page_count * page_size FROM pragma_page_count(), pragma_page_size()`
+	if s.db.dialect == DialectPostgres {
+		query = `SELECT pg_database_size(current_database())`
+	}
+
+	var size int64
+	err := s.db.queryRow(ctx, query).Scan(&size)
+	return size, err
+}
+
+// Ping checks that the database answers queries
+func (s *Store) Ping(ctx context.Context) error {
+	var one int
+	return s.db.queryRow(ctx, `SELECT 1`).Scan(&one)
+}
@@ -0,0 +1,36 @@
+CREATE TABLE IF NOT EXISTS repos (
+	id BIGSERIAL PRIMARY KEY,
+	owner TEXT NOT NULL,
+	name TEXT NOT NULL,
+	track_prereleases INTEGER NOT NULL DEFAULT 0,
+	UNIQUE(owner, name)
+);
+
+CREATE TABLE IF NOT EXISTS chats (
+	id BIGINT PRIMARY KEY,
+	title TEXT,
+	language TEXT DEFAULT 'ru'
+);
+
+CREATE TABLE IF NOT EXISTS processed_releases (
+	repo_owner TEXT NOT NULL,
+	repo_name  TEXT NOT NULL,
+	release_id BIGINT NOT NULL,
+	tag_name   TEXT,
+	published_at TEXT,
+	created_at   TEXT DEFAULT (to_char(now() AT TIME ZONE 'UTC', 'YYYY-MM-DD HH24:MI:SS')),
+	PRIMARY KEY (repo_owner, repo_name, release_id)
+);
+
+CREATE TABLE IF NOT EXISTS etags (
+	repo_owner TEXT NOT NULL,
+	repo_name  TEXT NOT NULL,
+	etag       TEXT NOT NULL,
+	updated_at TEXT,
+	PRIMARY KEY (repo_owner, repo_name)
+);
+
+CREATE TABLE IF NOT EXISTS settings (
+	key TEXT PRIMARY KEY,
+	value TEXT NOT NULL
+);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS llm_usage (
+	id BIGSERIAL PRIMARY KEY,
+	repo TEXT NOT NULL,
+	tag TEXT,
+	model TEXT NOT NULL,
+	prompt_tokens INTEGER NOT NULL DEFAULT 0,
+	completion_tokens INTEGER NOT NULL DEFAULT 0,
+	cost_usd DOUBLE PRECISION NOT NULL DEFAULT 0,
+	created_at TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_llm_usage_created_at ON llm_usage (created_at);
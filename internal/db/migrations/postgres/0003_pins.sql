@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS pins (
+	chat_id    BIGINT NOT NULL,
+	repo_owner TEXT NOT NULL,
+	repo_name  TEXT NOT NULL,
+	version    TEXT NOT NULL,
+	updated_at TEXT,
+	PRIMARY KEY (chat_id, repo_owner, repo_name)
+);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS prompts (
+	name        TEXT PRIMARY KEY,
+	system_tmpl TEXT NOT NULL,
+	user_tmpl   TEXT NOT NULL,
+	updated_at  TEXT
+);
+
+CREATE TABLE IF NOT EXISTS prompt_assignments (
+	scope       TEXT NOT NULL,
+	target      TEXT NOT NULL,
+	prompt_name TEXT NOT NULL,
+	PRIMARY KEY (scope, target)
+);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS repo_schedule (
+	repo_owner TEXT NOT NULL,
+	repo_name  TEXT NOT NULL,
+	next_check_at   TEXT,
+	last_checked_at TEXT,
+	last_release_at TEXT,
+	cadence_seconds BIGINT NOT NULL DEFAULT 0,
+	interval_override_seconds BIGINT NOT NULL DEFAULT 0,
+	PRIMARY KEY (repo_owner, repo_name)
+);
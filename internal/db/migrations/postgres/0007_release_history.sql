@@ -0,0 +1,7 @@
+ALTER TABLE processed_releases ADD COLUMN name TEXT;
+ALTER TABLE processed_releases ADD COLUMN body TEXT;
+ALTER TABLE processed_releases ADD COLUMN html_url TEXT;
+ALTER TABLE processed_releases ADD COLUMN prerelease INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE processed_releases ADD COLUMN message TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_processed_releases_published ON processed_releases (repo_owner, repo_name, published_at);
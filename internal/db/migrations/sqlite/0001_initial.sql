@@ -0,0 +1,36 @@
+CREATE TABLE IF NOT EXISTS repos (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	owner TEXT NOT NULL,
+	name TEXT NOT NULL,
+	track_prereleases INTEGER NOT NULL DEFAULT 0,
+	UNIQUE(owner, name)
+);
+
+CREATE TABLE IF NOT EXISTS chats (
+	id INTEGER PRIMARY KEY,
+	title TEXT,
+	language TEXT DEFAULT 'ru'
+);
+
+CREATE TABLE IF NOT EXISTS processed_releases (
+	repo_owner TEXT NOT NULL,
+	repo_name  TEXT NOT NULL,
+	release_id INTEGER NOT NULL,
+	tag_name   TEXT,
+	published_at TEXT,
+	created_at   TEXT DEFAULT (datetime('now')),
+	PRIMARY KEY (repo_owner, repo_name, release_id)
+);
+
+CREATE TABLE IF NOT EXISTS etags (
+	repo_owner TEXT NOT NULL,
+	repo_name  TEXT NOT NULL,
+	etag       TEXT NOT NULL,
+	updated_at TEXT DEFAULT (datetime('now')),
+	PRIMARY KEY (repo_owner, repo_name)
+);
+
+CREATE TABLE IF NOT EXISTS settings (
+	key TEXT PRIMARY KEY,
+	value TEXT NOT NULL
+);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS deliveries (
+	repo_owner  TEXT NOT NULL,
+	repo_name   TEXT NOT NULL,
+	release_id  INTEGER NOT NULL,
+	chat_id     INTEGER NOT NULL,
+	state       TEXT NOT NULL,
+	lease_until TEXT,
+	attempts    INTEGER NOT NULL DEFAULT 0,
+	last_error  TEXT,
+	updated_at  TEXT DEFAULT (datetime('now')),
+	PRIMARY KEY (repo_owner, repo_name, release_id, chat_id)
+);
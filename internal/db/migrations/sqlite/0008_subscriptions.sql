@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS subscriptions (
+	chat_id    INTEGER NOT NULL,
+	repo_owner TEXT NOT NULL,
+	repo_name  TEXT NOT NULL,
+	created_at TEXT DEFAULT (datetime('now')),
+	PRIMARY KEY (chat_id, repo_owner, repo_name)
+);
@@ -0,0 +1,146 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+// baselineSchema is the schema databases had before migrations were
+// versioned, with a row in every table
+const baselineSchema = `
+CREATE TABLE repos (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	owner TEXT NOT NULL,
+	name TEXT NOT NULL,
+	track_prereleases INTEGER NOT NULL DEFAULT 0,
+	UNIQUE(owner, name)
+);
+CREATE TABLE chats (
+	id INTEGER PRIMARY KEY,
+	title TEXT,
+	language TEXT DEFAULT 'ru'
+);
+CREATE TABLE processed_releases (
+	repo_owner TEXT NOT NULL,
+	repo_name  TEXT NOT NULL,
+	release_id INTEGER NOT NULL,
+	tag_name   TEXT,
+	published_at TEXT,
+	created_at   TEXT DEFAULT (datetime('now')),
+	PRIMARY KEY (repo_owner, repo_name, release_id)
+);
+CREATE TABLE etags (
+	repo_owner TEXT NOT NULL,
+	repo_name  TEXT NOT NULL,
+	etag       TEXT NOT NULL,
+	updated_at TEXT DEFAULT (datetime('now')),
+	PRIMARY KEY (repo_owner, repo_name)
+);
+CREATE TABLE settings (
+	key TEXT PRIMARY KEY,
+	value TEXT NOT NULL
+);
+
+INSERT INTO repos (owner, name, track_prereleases) VALUES ('golang', 'go', 1);
+INSERT INTO chats (id, title, language) VALUES (-100123, 'Ops', 'en');
+INSERT INTO processed_releases (repo_owner, repo_name, release_id, tag_name, published_at)
+	VALUES ('golang', 'go', 42, 'go1.22.0', '2024-02-06T00:00:00Z');
+INSERT INTO etags (repo_owner, repo_name, etag) VALUES ('golang', 'go', 'W/"abc"');
+INSERT INTO settings (key, value) VALUES ('language', 'en');
+`
+
+func TestMigrateBaselineDatabase(t *testing.T) {
+	ctx := context.Background()
+	db, err := OpenWithoutMigrations(filepath.Join(t.TempDir(), "bot.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.conn.ExecContext(ctx, baselineSchema); err != nil {
+		t.Fatalf("create baseline schema: %v", err)
+	}
+
+	migrations, err := loadMigrations(DialectSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	applied, err := db.Migrate(ctx)
+	if err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	if applied != len(migrations) {
+		t.Errorf("Migrate applied %d migrations, want %d", applied, len(migrations))
+	}
+
+	rows, err := db.query(ctx, `SELECT version, name FROM schema_migrations ORDER BY version`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var recorded []Migration
+	for rows.Next() {
+		var m Migration
+		if err := rows.Scan(&m.Version, &m.Name); err != nil {
+			t.Fatal(err)
+		}
+		recorded = append(recorded, m)
+	}
+	rows.Close()
+	if len(recorded) != len(migrations) {
+		t.Fatalf("schema_migrations has %d rows, want %d", len(recorded), len(migrations))
+	}
+	for i, m := range migrations {
+		if recorded[i].Version != m.Version || recorded[i].Name != m.Name {
+			t.Errorf("schema_migrations row %d = %d_%s, want %d_%s", i, recorded[i].Version, recorded[i].Name, m.Version, m.Name)
+		}
+	}
+
+	store := NewStore(db)
+	repos, err := store.ListRepositories(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repos) != 1 || repos[0].Owner != "golang" || repos[0].Name != "go" || !repos[0].TrackPrereleases {
+		t.Errorf("repositories after migration = %+v", repos)
+	}
+	chats, err := store.ListChats(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chats) != 1 || chats[0].ID != -100123 || chats[0].Language != "en" {
+		t.Errorf("chats after migration = %+v", chats)
+	}
+	if processed, err := store.IsProcessed(ctx, "golang", "go", 42); err != nil || !processed {
+		t.Errorf("processed release lost: processed=%v err=%v", processed, err)
+	}
+	history, err := store.ListReleaseHistory(ctx, "golang", "go", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 || history[0].TagName != "go1.22.0" || history[0].Message != "" {
+		t.Errorf("release history after migration = %+v", history)
+	}
+	if etag, err := store.GetETag(ctx, "golang", "go"); err != nil || etag != `W/"abc"` {
+		t.Errorf("ETag after migration = %q, %v", etag, err)
+	}
+	if lang, err := store.GetSetting(ctx, "language"); err != nil || lang != "en" {
+		t.Errorf("setting after migration = %q, %v", lang, err)
+	}
+
+	applied, err = db.Migrate(ctx)
+	if err != nil {
+		t.Fatalf("second Migrate: %v", err)
+	}
+	if applied != 0 {
+		t.Errorf("second Migrate applied %d migrations, want none", applied)
+	}
+	states, err := db.MigrationStatus(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, state := range states {
+		if !state.Applied || state.AppliedAt.IsZero() {
+			t.Errorf("migration %04d_%s is not recorded as applied", state.Version, state.Name)
+		}
+	}
+}
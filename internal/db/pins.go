@@ -0,0 +1,59 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// Pin is the version of a repository a chat actually runs
+type Pin struct {
+	ChatID    int64  `json:"chat_id"`
+	RepoOwner string `json:"repo_owner"`
+	RepoName  string `json:"repo_name"`
+	Version   string `json:"version"`
+}
+
+// SetPin records the version of a repository a chat runs
+func (s *Store) SetPin(ctx context.Context, chatID int64, repoOwner, repoName, version string) error {
+	query := `INSERT INTO pins (chat_id, repo_owner, repo_name, version, updated_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (chat_id, repo_owner, repo_name) DO UPDATE SET version = excluded.version, updated_at = excluded.updated_at`
+	_, err := s.db.exec(ctx, query, chatID, repoOwner, repoName, version, formatTime(time.Now()))
+	return err
+}
+
+// RemovePin removes a pinned version
+func (s *Store) RemovePin(ctx context.Context, chatID int64, repoOwner, repoName string) error {
+	query := `DELETE FROM pins WHERE chat_id = ? AND repo_owner = ? AND repo_name = ?`
+	_, err := s.db.exec(ctx, query, chatID, repoOwner, repoName)
+	return err
+}
+
+// ListPins returns all pins of a chat
+func (s *Store) ListPins(ctx context.Context, chatID int64) ([]Pin, error) {
+	query := `SELECT chat_id, repo_owner, repo_name, version FROM pins WHERE chat_id = ? ORDER BY repo_owner, repo_name`
+	return s.queryPins(ctx, query, chatID)
+}
+
+// ListRepoPins returns pins of a repository across all chats
+func (s *Store) ListRepoPins(ctx context.Context, repoOwner, repoName string) ([]Pin, error) {
+	query := `SELECT chat_id, repo_owner, repo_name, version FROM pins WHERE repo_owner = ? AND repo_name = ? ORDER BY chat_id`
+	return s.queryPins(ctx, query, repoOwner, repoName)
+}
+
+func (s *Store) queryPins(ctx context.Context, query string, args ...any) ([]Pin, error) {
+	rows, err := s.db.query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pins []Pin
+	for rows.Next() {
+		var p Pin
+		if err := rows.Scan(&p.ChatID, &p.RepoOwner, &p.RepoName, &p.Version); err != nil {
+			return nil, err
+		}
+		pins = append(pins, p)
+	}
+	return pins, rows.Err()
+}
@@ -0,0 +1,82 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// PromptTemplate is an advisor prompt stored in the database
+type PromptTemplate struct {
+	Name   string `json:"name"`
+	System string `json:"system"`
+	User   string `json:"user"`
+}
+
+// SavePrompt creates or updates a prompt template
+func (s *Store) SavePrompt(ctx context.Context, name, system, user string) error {
+	query := `INSERT INTO prompts (name, system_tmpl, user_tmpl, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (name) DO UPDATE SET system_tmpl = excluded.system_tmpl, user_tmpl = excluded.user_tmpl, updated_at = excluded.updated_at`
+	_, err := s.db.exec(ctx, query, name, system, user, formatTime(time.Now()))
+	return err
+}
+
+// GetPrompt returns a prompt template by name
+func (s *Store) GetPrompt(ctx context.Context, name string) (PromptTemplate, bool, error) {
+	query := `SELECT name, system_tmpl, user_tmpl FROM prompts WHERE name = ?`
+	var p PromptTemplate
+	err := s.db.queryRow(ctx, query, name).Scan(&p.Name, &p.System, &p.User)
+	if err == sql.ErrNoRows {
+		return PromptTemplate{}, false, nil
+	}
+	if err != nil {
+		return PromptTemplate{}, false, err
+	}
+	return p, true, nil
+}
+
+// ListPrompts returns all stored prompt templates
+func (s *Store) ListPrompts(ctx context.Context) ([]PromptTemplate, error) {
+	query := `SELECT name, system_tmpl, user_tmpl FROM prompts ORDER BY name`
+	rows, err := s.db.query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var prompts []PromptTemplate
+	for rows.Next() {
+		var p PromptTemplate
+		if err := rows.Scan(&p.Name, &p.System, &p.User); err != nil {
+			return nil, err
+		}
+		prompts = append(prompts, p)
+	}
+	return prompts, rows.Err()
+}
+
+// AssignPrompt assigns a prompt to a chat or repository
+func (s *Store) AssignPrompt(ctx context.Context, scope, target, name string) error {
+	query := `INSERT INTO prompt_assignments (scope, target, prompt_name) VALUES (?, ?, ?)
+		ON CONFLICT (scope, target) DO UPDATE SET prompt_name = excluded.prompt_name`
+	_, err := s.db.exec(ctx, query, scope, target, name)
+	return err
+}
+
+// UnassignPrompt removes a prompt assignment
+func (s *Store) UnassignPrompt(ctx context.Context, scope, target string) error {
+	query := `DELETE FROM prompt_assignments WHERE scope = ? AND target = ?`
+	_, err := s.db.exec(ctx, query, scope, target)
+	return err
+}
+
+// PromptAssignment returns the prompt name assigned to a chat or repository, or ""
+func (s *Store) PromptAssignment(ctx context.Context, scope, target string) (string, error) {
+	query := `SELECT prompt_name FROM prompt_assignments WHERE scope = ? AND target = ?`
+	var name string
+	err := s.db.queryRow(ctx, query, scope, target).Scan(&name)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return name, err
+}
@@ -3,6 +3,7 @@ package db
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"time"
 )
 
@@ -27,11 +28,16 @@ type ProcessedRelease struct {
 	RepoName    string    `json:"repo_name"`
 	ReleaseID   int64     `json:"release_id"`
 	TagName     string    `json:"tag_name"`
+	Name        string    `json:"name"`
+	Body        string    `json:"body"`
+	URL         string    `json:"url"`
+	Prerelease  bool      `json:"prerelease"`
+	Message     string    `json:"message"` // composed notification HTML
 	PublishedAt time.Time `json:"published_at"`
 	CreatedAt   time.Time `json:"created_at"`
 }
 
-// Store provides database operations
+// Store implements Storage with SQL shared by the SQLite and PostgreSQL backends
 type Store struct {
 	db *DB
 }
@@ -45,22 +51,43 @@ func NewStore(db *DB) *Store {
 
 // AddRepository adds a new repository to track
 func (s *Store) AddRepository(ctx context.Context, owner, name string, trackPrereleases bool) error {
-	query := `INSERT OR REPLACE INTO repos (owner, name, track_prereleases) VALUES (?, ?, ?)`
-	_, err := s.db.conn.ExecContext(ctx, query, owner, name, boolToInt(trackPrereleases))
+	query := `INSERT INTO repos (owner, name, track_prereleases) VALUES (?, ?, ?)
+		ON CONFLICT (owner, name) DO UPDATE SET track_prereleases = excluded.track_prereleases`
+	_, err := s.db.exec(ctx, query, owner, name, boolToInt(trackPrereleases))
 	return err
 }
 
-// RemoveRepository removes a repository from tracking
+// RemoveRepository removes a repository from tracking together with its
+// ETag, schedule, release history, deliveries, pins, subscriptions and
+// prompt assignment
 func (s *Store) RemoveRepository(ctx context.Context, owner, name string) error {
-	query := `DELETE FROM repos WHERE owner = ? AND name = ?`
-	_, err := s.db.conn.ExecContext(ctx, query, owner, name)
-	return err
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, s.db.rebind(`DELETE FROM repos WHERE owner = ? AND name = ?`), owner, name); err != nil {
+		return err
+	}
+	for _, table := range repoTables {
+		query := fmt.Sprintf(`DELETE FROM %s WHERE repo_owner = ? AND repo_name = ?`, table)
+		if _, err := tx.ExecContext(ctx, s.db.rebind(query), owner, name); err != nil {
+			return fmt.Errorf("failed to remove %s of %s/%s: %w", table, owner, name, err)
+		}
+	}
+	query := `DELETE FROM prompt_assignments WHERE scope = ? AND target = ?`
+	if _, err := tx.ExecContext(ctx, s.db.rebind(query), "repo", owner+"/"+name); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 // ListRepositories returns all tracked repositories
 func (s *Store) ListRepositories(ctx context.Context) ([]Repository, error) {
 	query := `SELECT id, owner, name, track_prereleases FROM repos ORDER BY owner, name`
-	rows, err := s.db.conn.QueryContext(ctx, query)
+	rows, err := s.db.query(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -83,22 +110,34 @@ func (s *Store) ListRepositories(ctx context.Context) ([]Repository, error) {
 
 // AddChat adds a new chat
 func (s *Store) AddChat(ctx context.Context, chatID int64, title, language string) error {
-	query := `INSERT OR REPLACE INTO chats (id, title, language) VALUES (?, ?, ?)`
-	_, err := s.db.conn.ExecContext(ctx, query, chatID, title, language)
+	query := `INSERT INTO chats (id, title, language) VALUES (?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET title = excluded.title, language = excluded.language`
+	_, err := s.db.exec(ctx, query, chatID, title, language)
 	return err
 }
 
-// RemoveChat removes a chat
+// RemoveChat removes a chat together with its subscriptions
 func (s *Store) RemoveChat(ctx context.Context, chatID int64) error {
-	query := `DELETE FROM chats WHERE id = ?`
-	_, err := s.db.conn.ExecContext(ctx, query, chatID)
-	return err
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, s.db.rebind(`DELETE FROM chats WHERE id = ?`), chatID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, s.db.rebind(`DELETE FROM subscriptions WHERE chat_id = ?`), chatID); err != nil {
+		return fmt.Errorf("failed to remove subscriptions of chat %d: %w", chatID, err)
+	}
+
+	return tx.Commit()
 }
 
 // ListChats returns all registered chats
 func (s *Store) ListChats(ctx context.Context) ([]Chat, error) {
 	query := `SELECT id, title, language FROM chats ORDER BY id`
-	rows, err := s.db.conn.QueryContext(ctx, query)
+	rows, err := s.db.query(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -119,16 +158,94 @@ func (s *Store) ListChats(ctx context.Context) ([]Chat, error) {
 
 // MarkProcessed marks a release as processed
 func (s *Store) MarkProcessed(ctx context.Context, repoOwner, repoName string, releaseID int64, tagName string, publishedAt time.Time) error {
-	query := `INSERT OR REPLACE INTO processed_releases (repo_owner, repo_name, release_id, tag_name, published_at) VALUES (?, ?, ?, ?, ?)`
-	_, err := s.db.conn.ExecContext(ctx, query, repoOwner, repoName, releaseID, tagName, publishedAt.Format(time.RFC3339))
+	query := `INSERT INTO processed_releases (repo_owner, repo_name, release_id, tag_name, published_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (repo_owner, repo_name, release_id) DO UPDATE SET tag_name = excluded.tag_name, published_at = excluded.published_at`
+	_, err := s.db.exec(ctx, query, repoOwner, repoName, releaseID, tagName, publishedAt.Format(time.RFC3339))
 	return err
 }
 
+// RecordRelease marks a release as processed and stores its details and
+// composed message for the release history
+func (s *Store) RecordRelease(ctx context.Context, r ProcessedRelease) error {
+	query := `INSERT INTO processed_releases (repo_owner, repo_name, release_id, tag_name, published_at, name, body, html_url, prerelease, message)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (repo_owner, repo_name, release_id) DO UPDATE SET
+			tag_name = excluded.tag_name,
+			published_at = excluded.published_at,
+			name = excluded.name,
+			body = excluded.body,
+			html_url = excluded.html_url,
+			prerelease = excluded.prerelease,
+			message = excluded.message`
+	_, err := s.db.exec(ctx, query, r.RepoOwner, r.RepoName, r.ReleaseID, r.TagName, formatTime(r.PublishedAt),
+		r.Name, r.Body, r.URL, boolToInt(r.Prerelease), r.Message)
+	return err
+}
+
+// ListReleaseHistory returns the latest processed releases of a repository, newest first
+func (s *Store) ListReleaseHistory(ctx context.Context, repoOwner, repoName string, limit int) ([]ProcessedRelease, error) {
+	query := releaseColumns + ` WHERE repo_owner = ? AND repo_name = ?
+		ORDER BY published_at DESC, release_id DESC LIMIT ?`
+	rows, err := s.db.query(ctx, query, repoOwner, repoName, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var releases []ProcessedRelease
+	for rows.Next() {
+		r, err := scanRelease(rows)
+		if err != nil {
+			return nil, err
+		}
+		releases = append(releases, r)
+	}
+	return releases, rows.Err()
+}
+
+// GetReleaseByTag returns a processed release of a repository by its tag
+func (s *Store) GetReleaseByTag(ctx context.Context, repoOwner, repoName, tag string) (ProcessedRelease, bool, error) {
+	query := releaseColumns + ` WHERE repo_owner = ? AND repo_name = ? AND tag_name = ?
+		ORDER BY release_id DESC LIMIT 1`
+	r, err := scanRelease(s.db.queryRow(ctx, query, repoOwner, repoName, tag))
+	if err == sql.ErrNoRows {
+		return ProcessedRelease{}, false, nil
+	}
+	if err != nil {
+		return ProcessedRelease{}, false, err
+	}
+	return r, true, nil
+}
+
+const releaseColumns = `SELECT repo_owner, repo_name, release_id, tag_name, published_at,
+	name, body, html_url, prerelease, message FROM processed_releases`
+
+// scanRelease scans a row selected with releaseColumns. Releases processed
+// before the history was stored have NULL details.
+func scanRelease(row interface{ Scan(...any) error }) (ProcessedRelease, error) {
+	var r ProcessedRelease
+	var tag, published, name, body, url, message sql.NullString
+	var prerelease int
+	err := row.Scan(&r.RepoOwner, &r.RepoName, &r.ReleaseID, &tag, &published,
+		&name, &body, &url, &prerelease, &message)
+	if err != nil {
+		return r, err
+	}
+	r.TagName = tag.String
+	r.PublishedAt = parseTime(published)
+	r.Name = name.String
+	r.Body = body.String
+	r.URL = url.String
+	r.Prerelease = prerelease == 1
+	r.Message = message.String
+	return r, nil
+}
+
 // IsProcessed checks if a release has been processed
 func (s *Store) IsProcessed(ctx context.Context, repoOwner, repoName string, releaseID int64) (bool, error) {
 	query := `SELECT 1 FROM processed_releases WHERE repo_owner = ? AND repo_name = ? AND release_id = ?`
 	var exists int
-	err := s.db.conn.QueryRowContext(ctx, query, repoOwner, repoName, releaseID).Scan(&exists)
+	err := s.db.queryRow(ctx, query, repoOwner, repoName, releaseID).Scan(&exists)
 	if err == sql.ErrNoRows {
 		return false, nil
 	}
@@ -144,7 +261,7 @@ func (s *Store) IsProcessed(ctx context.Context, repoOwner, repoName string, rel
 func (s *Store) GetETag(ctx context.Context, repoOwner, repoName string) (string, error) {
 	query := `SELECT etag FROM etags WHERE repo_owner = ? AND repo_name = ?`
 	var etag string
-	err := s.db.conn.QueryRowContext(ctx, query, repoOwner, repoName).Scan(&etag)
+	err := s.db.queryRow(ctx, query, repoOwner, repoName).Scan(&etag)
 	if err == sql.ErrNoRows {
 		return "", nil
 	}
@@ -156,8 +273,9 @@ func (s *Store) GetETag(ctx context.Context, repoOwner, repoName string) (string
 
 // PutETag stores an ETag for a repository
 func (s *Store) PutETag(ctx context.Context, repoOwner, repoName, etag string) error {
-	query := `INSERT OR REPLACE INTO etags (repo_owner, repo_name, etag, updated_at) VALUES (?, ?, ?, datetime('now'))`
-	_, err := s.db.conn.ExecContext(ctx, query, repoOwner, repoName, etag)
+	query := `INSERT INTO etags (repo_owner, repo_name, etag, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (repo_owner, repo_name) DO UPDATE SET etag = excluded.etag, updated_at = excluded.updated_at`
+	_, err := s.db.exec(ctx, query, repoOwner, repoName, etag, formatTime(time.Now()))
 	return err
 }
 
@@ -167,7 +285,7 @@ func (s *Store) PutETag(ctx context.Context, repoOwner, repoName, etag string) e
 func (s *Store) GetSetting(ctx context.Context, key string) (string, error) {
 	query := `SELECT value FROM settings WHERE key = ?`
 	var value string
-	err := s.db.conn.QueryRowContext(ctx, query, key).Scan(&value)
+	err := s.db.queryRow(ctx, query, key).Scan(&value)
 	if err == sql.ErrNoRows {
 		return "", nil
 	}
@@ -179,8 +297,35 @@ func (s *Store) GetSetting(ctx context.Context, key string) (string, error) {
 
 // SetSetting stores a setting value
 func (s *Store) SetSetting(ctx context.Context, key, value string) error {
-	query := `INSERT OR REPLACE INTO settings (key, value) VALUES (?, ?)`
-	_, err := s.db.conn.ExecContext(ctx, query, key, value)
+	query := `INSERT INTO settings (key, value) VALUES (?, ?)
+		ON CONFLICT (key) DO UPDATE SET value = excluded.value`
+	_, err := s.db.exec(ctx, query, key, value)
+	return err
+}
+
+// ListSettings returns all settings by key
+func (s *Store) ListSettings(ctx context.Context) (map[string]string, error) {
+	rows, err := s.db.query(ctx, `SELECT key, value FROM settings`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	settings := make(map[string]string)
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, err
+		}
+		settings[key] = value
+	}
+	return settings, rows.Err()
+}
+
+// DeleteSetting removes a setting
+func (s *Store) DeleteSetting(ctx context.Context, key string) error {
+	query := `DELETE FROM settings WHERE key = ?`
+	_, err := s.db.exec(ctx, query, key)
 	return err
 }
 
@@ -0,0 +1,133 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// RepoSchedule holds adaptive polling state of a repository
+type RepoSchedule struct {
+	RepoOwner        string        `json:"repo_owner"`
+	RepoName         string        `json:"repo_name"`
+	NextCheckAt      time.Time     `json:"next_check_at"`
+	LastCheckedAt    time.Time     `json:"last_checked_at"`
+	LastReleaseAt    time.Time     `json:"last_release_at"`
+	Cadence          time.Duration `json:"cadence"`
+	IntervalOverride time.Duration `json:"interval_override"`
+}
+
+// GetRepoSchedule returns the polling state of a repository; a zero schedule if none is stored
+func (s *Store) GetRepoSchedule(ctx context.Context, repoOwner, repoName string) (RepoSchedule, error) {
+	query := `SELECT next_check_at, last_checked_at, last_release_at, cadence_seconds, interval_override_seconds
+		FROM repo_schedule WHERE repo_owner = ? AND repo_name = ?`
+	sch := RepoSchedule{RepoOwner: repoOwner, RepoName: repoName}
+	var next, checked, release sql.NullString
+	var cadence, override int64
+	err := s.db.queryRow(ctx, query, repoOwner, repoName).Scan(&next, &checked, &release, &cadence, &override)
+	if err == sql.ErrNoRows {
+		return sch, nil
+	}
+	if err != nil {
+		return sch, err
+	}
+
+	sch.NextCheckAt = parseTime(next)
+	sch.LastCheckedAt = parseTime(checked)
+	sch.LastReleaseAt = parseTime(release)
+	sch.Cadence = time.Duration(cadence) * time.Second
+	sch.IntervalOverride = time.Duration(override) * time.Second
+	return sch, nil
+}
+
+// SaveRepoSchedule stores the computed polling state of a repository, keeping its manual override
+func (s *Store) SaveRepoSchedule(ctx context.Context, sch RepoSchedule) error {
+	query := `INSERT INTO repo_schedule (repo_owner, repo_name, next_check_at, last_checked_at, last_release_at, cadence_seconds)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (repo_owner, repo_name) DO UPDATE SET
+			next_check_at = excluded.next_check_at,
+			last_checked_at = excluded.last_checked_at,
+			last_release_at = excluded.last_release_at,
+			cadence_seconds = excluded.cadence_seconds`
+	_, err := s.db.exec(ctx, query, sch.RepoOwner, sch.RepoName,
+		formatTime(sch.NextCheckAt), formatTime(sch.LastCheckedAt), formatTime(sch.LastReleaseAt),
+		int64(sch.Cadence/time.Second))
+	return err
+}
+
+// SetRepoInterval sets a manual polling interval for a repository; 0 restores adaptive polling.
+// The next check is moved to now so the new interval applies immediately.
+func (s *Store) SetRepoInterval(ctx context.Context, repoOwner, repoName string, interval time.Duration) error {
+	query := `INSERT INTO repo_schedule (repo_owner, repo_name, next_check_at, interval_override_seconds)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (repo_owner, repo_name) DO UPDATE SET
+			next_check_at = excluded.next_check_at,
+			interval_override_seconds = excluded.interval_override_seconds`
+	_, err := s.db.exec(ctx, query, repoOwner, repoName, formatTime(time.Now()), int64(interval/time.Second))
+	return err
+}
+
+// ListDueRepositories returns repositories whose next check is due at now,
+// including repositories that have never been checked
+func (s *Store) ListDueRepositories(ctx context.Context, now time.Time) ([]Repository, error) {
+	query := `SELECT r.id, r.owner, r.name, r.track_prereleases FROM repos r
+		LEFT JOIN repo_schedule s ON s.repo_owner = r.owner AND s.repo_name = r.name
+		WHERE s.next_check_at IS NULL OR s.next_check_at <= ?
+		ORDER BY r.owner, r.name`
+	rows, err := s.db.query(ctx, query, formatTime(now))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var repos []Repository
+	for rows.Next() {
+		var r Repository
+		var trackPrereleases int
+		if err := rows.Scan(&r.ID, &r.Owner, &r.Name, &trackPrereleases); err != nil {
+			return nil, err
+		}
+		r.TrackPrereleases = trackPrereleases == 1
+		repos = append(repos, r)
+	}
+	return repos, rows.Err()
+}
+
+// NextDueAt returns the earliest next check across all repositories.
+// A repository that has never been checked is due now; zero means no repositories.
+func (s *Store) NextDueAt(ctx context.Context) (time.Time, error) {
+	query := `SELECT COUNT(*), MIN(COALESCE(s.next_check_at, '')) FROM repos r
+		LEFT JOIN repo_schedule s ON s.repo_owner = r.owner AND s.repo_name = r.name`
+	var count int
+	var next sql.NullString
+	if err := s.db.queryRow(ctx, query).Scan(&count, &next); err != nil {
+		return time.Time{}, err
+	}
+	if count == 0 {
+		return time.Time{}, nil
+	}
+	if t := parseTime(next); !t.IsZero() {
+		return t, nil
+	}
+	return time.Now(), nil
+}
+
+// formatTime formats a timestamp for storage; zero times are stored as NULL
+func formatTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// parseTime parses a stored timestamp; NULL or invalid values become zero
+func parseTime(s sql.NullString) time.Time {
+	if !s.Valid {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339, s.String)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
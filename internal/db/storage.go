@@ -0,0 +1,82 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// Storage is the persistence API used by the bot. Store implements it on top
+// of SQLite or PostgreSQL, selected by the DSN passed to Open.
+type Storage interface {
+	// Repositories
+	AddRepository(ctx context.Context, owner, name string, trackPrereleases bool) error
+	RemoveRepository(ctx context.Context, owner, name string) error
+	ListRepositories(ctx context.Context) ([]Repository, error)
+
+	// Chats
+	AddChat(ctx context.Context, chatID int64, title, language string) error
+	RemoveChat(ctx context.Context, chatID int64) error
+	ListChats(ctx context.Context) ([]Chat, error)
+
+	// Processed releases and deliveries
+	MarkProcessed(ctx context.Context, repoOwner, repoName string, releaseID int64, tagName string, publishedAt time.Time) error
+	IsProcessed(ctx context.Context, repoOwner, repoName string, releaseID int64) (bool, error)
+	RecordRelease(ctx context.Context, r ProcessedRelease) error
+	ListReleaseHistory(ctx context.Context, repoOwner, repoName string, limit int) ([]ProcessedRelease, error)
+	GetReleaseByTag(ctx context.Context, repoOwner, repoName, tag string) (ProcessedRelease, bool, error)
+	ClaimDelivery(ctx context.Context, repoOwner, repoName string, releaseID, chatID int64, lease time.Duration, maxAttempts int) (int, error)
+	GetDelivery(ctx context.Context, repoOwner, repoName string, releaseID, chatID int64) (Delivery, bool, error)
+	MarkDelivered(ctx context.Context, repoOwner, repoName string, releaseID, chatID int64, attempt int) error
+	MarkDeliveryFailed(ctx context.Context, repoOwner, repoName string, releaseID, chatID int64, attempt int, sendErr error) error
+	ListRecentDeliveries(ctx context.Context, limit int) ([]Delivery, error)
+
+	// ETags
+	GetETag(ctx context.Context, repoOwner, repoName string) (string, error)
+	PutETag(ctx context.Context, repoOwner, repoName, etag string) error
+
+	// Settings
+	GetSetting(ctx context.Context, key string) (string, error)
+	SetSetting(ctx context.Context, key, value string) error
+	DeleteSetting(ctx context.Context, key string) error
+	ListSettings(ctx context.Context) (map[string]string, error)
+
+	// Polling schedule
+	GetRepoSchedule(ctx context.Context, repoOwner, repoName string) (RepoSchedule, error)
+	SaveRepoSchedule(ctx context.Context, sch RepoSchedule) error
+	SetRepoInterval(ctx context.Context, repoOwner, repoName string, interval time.Duration) error
+	ListDueRepositories(ctx context.Context, now time.Time) ([]Repository, error)
+	NextDueAt(ctx context.Context) (time.Time, error)
+
+	// Pins
+	SetPin(ctx context.Context, chatID int64, repoOwner, repoName, version string) error
+	RemovePin(ctx context.Context, chatID int64, repoOwner, repoName string) error
+	ListPins(ctx context.Context, chatID int64) ([]Pin, error)
+	ListRepoPins(ctx context.Context, repoOwner, repoName string) ([]Pin, error)
+
+	// Subscriptions
+	AddSubscription(ctx context.Context, chatID int64, repoOwner, repoName string) error
+	RemoveSubscription(ctx context.Context, chatID int64, repoOwner, repoName string) error
+	ListSubscriptions(ctx context.Context, chatID int64) ([]Subscription, error)
+
+	// Advisor prompts
+	SavePrompt(ctx context.Context, name, system, user string) error
+	GetPrompt(ctx context.Context, name string) (PromptTemplate, bool, error)
+	ListPrompts(ctx context.Context) ([]PromptTemplate, error)
+	AssignPrompt(ctx context.Context, scope, target, name string) error
+	UnassignPrompt(ctx context.Context, scope, target string) error
+	PromptAssignment(ctx context.Context, scope, target string) (string, error)
+
+	// Advisor usage
+	RecordLLMUsage(ctx context.Context, u LLMUsage) error
+	LLMCostSince(ctx context.Context, since time.Time) (float64, error)
+	LLMSpendByRepo(ctx context.Context, since time.Time) ([]LLMSpend, error)
+	LLMSpendByModel(ctx context.Context, since time.Time) ([]LLMSpend, error)
+
+	// Maintenance
+	Prune(ctx context.Context, before time.Time, keepPerRepo int) (PruneStats, error)
+	Optimize(ctx context.Context) (OptimizeStats, error)
+	BackupInto(ctx context.Context, path string) error
+	Ping(ctx context.Context) error
+}
+
+var _ Storage = (*Store)(nil)
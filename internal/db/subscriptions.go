@@ -0,0 +1,48 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// Subscription limits a chat to the releases of the repositories it is subscribed to
+type Subscription struct {
+	ChatID    int64  `json:"chat_id"`
+	RepoOwner string `json:"repo_owner"`
+	RepoName  string `json:"repo_name"`
+}
+
+// AddSubscription subscribes a chat to a repository
+func (s *Store) AddSubscription(ctx context.Context, chatID int64, repoOwner, repoName string) error {
+	query := `INSERT INTO subscriptions (chat_id, repo_owner, repo_name, created_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (chat_id, repo_owner, repo_name) DO NOTHING`
+	_, err := s.db.exec(ctx, query, chatID, repoOwner, repoName, formatTime(time.Now()))
+	return err
+}
+
+// RemoveSubscription unsubscribes a chat from a repository
+func (s *Store) RemoveSubscription(ctx context.Context, chatID int64, repoOwner, repoName string) error {
+	query := `DELETE FROM subscriptions WHERE chat_id = ? AND repo_owner = ? AND repo_name = ?`
+	_, err := s.db.exec(ctx, query, chatID, repoOwner, repoName)
+	return err
+}
+
+// ListSubscriptions returns the subscriptions of a chat
+func (s *Store) ListSubscriptions(ctx context.Context, chatID int64) ([]Subscription, error) {
+	query := `SELECT chat_id, repo_owner, repo_name FROM subscriptions WHERE chat_id = ? ORDER BY repo_owner, repo_name`
+	rows, err := s.db.query(ctx, query, chatID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subs []Subscription
+	for rows.Next() {
+		var sub Subscription
+		if err := rows.Scan(&sub.ChatID, &sub.RepoOwner, &sub.RepoName); err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+	return subs, rows.Err()
+}
@@ -4,22 +4,40 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
 	"io"
 	"net/http"
 	"sort"
+	"strconv"
 	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/yourorg/tg-release-bot/internal/tracing"
 )
 
+var tracer = otel.Tracer("github.com/yourorg/tg-release-bot/internal/github")
+
 // Client provides GitHub API functionality
 type Client struct {
 	http      *http.Client
 	baseURL   string
 	token     string
 	userAgent string
+	limiter   *rateLimiter
+}
+
+// Options configures the GitHub client
+type Options struct {
+	RequestsPerSecond float64 // shared request rate across all callers, 0 disables spacing
 }
 
 // New creates a new GitHub client
-func New(token string) *Client {
+func New(token string, opt Options) *Client {
 	return &Client{
 		http: &http.Client{
 			Timeout: 10 * time.Second,
@@ -27,23 +45,74 @@ func New(token string) *Client {
 		baseURL:   "https://api.github.com",
 		token:     token,
 		userAgent: "tg-release-bot/1.0",
+		limiter:   newRateLimiter(opt.RequestsPerSecond),
 	}
 }
 
+// RateLimit returns the last rate limit reported by GitHub.
+// Remaining is -1 until the first response is received.
+func (c *Client) RateLimit() (remaining int, reset time.Time) {
+	return c.limiter.State()
+}
+
 // ListReleases fetches releases for a repository with ETag support
 func (c *Client) ListReleases(ctx context.Context, owner, repo, etag string) (*ReleasesResponse, error) {
 	url := fmt.Sprintf("%s/repos/%s/%s/releases?per_page=5", c.baseURL, owner, repo)
+
+	var releases []Release
+	response, err := c.getConditional(ctx, url, etag, &releases)
+	if err != nil {
+		return nil, err
+	}
+	response.Releases = releases
+	return response, nil
+}
+
+// ListTagReleases fetches the latest tags of a repository with ETag support,
+// for repositories that tag versions without publishing GitHub releases. Each
+// tag becomes a release dated by its commit, with an ID derived from the tag name.
+func (c *Client) ListTagReleases(ctx context.Context, owner, repo, etag string) (*ReleasesResponse, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/tags?per_page=5", c.baseURL, owner, repo)
+
+	var tags []Tag
+	response, err := c.getConditional(ctx, url, etag, &tags)
+	if err != nil || response.StatusCode == http.StatusNotModified {
+		return response, err
+	}
+
+	for _, tag := range tags {
+		var commit Commit
+		commitURL := fmt.Sprintf("%s/repos/%s/%s/commits/%s", c.baseURL, owner, repo, tag.Commit.SHA)
+		if err := c.getJSON(ctx, commitURL, &commit); err != nil {
+			return nil, fmt.Errorf("failed to get commit of tag %s: %w", tag.Name, err)
+		}
+		response.Releases = append(response.Releases, Release{
+			ID:          tagReleaseID(tag.Name),
+			TagName:     tag.Name,
+			Name:        tag.Name,
+			HTMLURL:     fmt.Sprintf("https://github.com/%s/%s/releases/tag/%s", owner, repo, tag.Name),
+			PublishedAt: commit.Commit.Committer.Date,
+		})
+	}
+	return response, nil
+}
+
+// tagReleaseID derives a stable positive release ID from a tag name
+func tagReleaseID(tag string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(tag))
+	return int64(h.Sum64() >> 1)
+}
+
+// getConditional performs a GET request with If-None-Match and decodes a JSON
+// response into v unless GitHub answers 304 Not Modified
+func (c *Client) getConditional(ctx context.Context, url, etag string, v any) (*ReleasesResponse, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	// Set headers
-	req.Header.Set("Accept", "application/vnd.github+json")
-	req.Header.Set("User-Agent", c.userAgent)
-	if c.token != "" {
-		req.Header.Set("Authorization", "Bearer "+c.token)
-	}
+	c.setHeaders(req)
 	if etag != "" {
 		req.Header.Set("If-None-Match", etag)
 	}
@@ -72,15 +141,76 @@ func (c *Client) ListReleases(ctx context.Context, owner, repo, etag string) (*R
 	}
 
 	// Decode response
-	var releases []Release
-	if err := json.NewDecoder(resp.Body).Decode(&releases); err != nil {
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
-
-	response.Releases = releases
 	return response, nil
 }
 
+// ListRecentReleases fetches up to perPage (max 100) latest releases without ETag caching
+func (c *Client) ListRecentReleases(ctx context.Context, owner, repo string, perPage int) ([]Release, error) {
+	var releases []Release
+	url := fmt.Sprintf("%s/repos/%s/%s/releases?per_page=%d", c.baseURL, owner, repo, perPage)
+	if err := c.getJSON(ctx, url, &releases); err != nil {
+		return nil, err
+	}
+	return releases, nil
+}
+
+// GetRepository fetches repository metadata
+func (c *Client) GetRepository(ctx context.Context, owner, repo string) (*Repository, error) {
+	var result Repository
+	url := fmt.Sprintf("%s/repos/%s/%s", c.baseURL, owner, repo)
+	if err := c.getJSON(ctx, url, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// CompareTags fetches commits and changed files between two tags
+func (c *Client) CompareTags(ctx context.Context, owner, repo, base, head string) (*Comparison, error) {
+	var result Comparison
+	url := fmt.Sprintf("%s/repos/%s/%s/compare/%s...%s", c.baseURL, owner, repo, base, head)
+	if err := c.getJSON(ctx, url, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// getJSON performs a GET request and decodes a JSON response into v
+func (c *Client) getJSON(ctx context.Context, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	c.setHeaders(req)
+
+	resp, err := c.doWithRetry(req, 3)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("github api error: %d %s", resp.StatusCode, string(body))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// setHeaders sets headers common to all GitHub API requests
+func (c *Client) setHeaders(req *http.Request) {
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", c.userAgent)
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+}
+
 // FilterAndSortReleases filters drafts and sorts releases by published date
 func (c *Client) FilterAndSortReleases(releases []Release, trackPrereleases bool) []Release {
 	var filtered []Release
@@ -112,20 +242,65 @@ func (c *Client) FilterAndSortReleases(releases []Release, trackPrereleases bool
 	return filtered
 }
 
-// doWithRetry performs HTTP request with retry logic for 429 and 5xx errors
-func (c *Client) doWithRetry(req *http.Request, maxRetries int) (*http.Response, error) {
+// doWithRetry performs HTTP request with retry logic for 429 and 5xx errors.
+// Every attempt waits for the shared rate limiter; backoffs stop on context cancellation.
+// One span covers all attempts, including the time spent waiting for the limiter.
+func (c *Client) doWithRetry(req *http.Request, maxRetries int) (result *http.Response, resultErr error) {
+	endpoint := endpointOf(req.URL.Path)
+	ctx, span := tracer.Start(req.Context(), "github "+endpoint,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(req.Method),
+			semconv.URLPath(req.URL.Path),
+			attribute.String("github.endpoint", endpoint),
+		))
+	req = req.WithContext(ctx)
+	attempts := 0
+	defer func() {
+		span.SetAttributes(attribute.Int("github.attempts", attempts))
+		if result != nil {
+			span.SetAttributes(semconv.HTTPResponseStatusCode(result.StatusCode))
+			if result.StatusCode >= 400 {
+				span.SetStatus(codes.Error, result.Status)
+			}
+		}
+		if remaining, _ := c.limiter.State(); remaining >= 0 {
+			span.SetAttributes(attribute.Int("github.rate_limit_remaining", remaining))
+		}
+		tracing.End(span, resultErr)
+	}()
+
 	var lastErr error
 
 	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+		attempts++
+
+		start := time.Now()
 		resp, err := c.http.Do(req)
+		requestDuration.WithLabelValues(endpoint).Observe(time.Since(start).Seconds())
 		if err != nil {
+			requestsTotal.WithLabelValues(endpoint, "error").Inc()
 			lastErr = err
+			if ctx.Err() != nil {
+				return nil, err
+			}
 			if attempt < maxRetries {
-				time.Sleep(time.Duration(attempt+1) * time.Second)
+				span.AddEvent("retry", trace.WithAttributes(attribute.String("error", err.Error())))
+				if err := sleepCtx(ctx, time.Duration(attempt+1)*time.Second); err != nil {
+					return nil, err
+				}
 				continue
 			}
 			break
 		}
+		requestsTotal.WithLabelValues(endpoint, strconv.Itoa(resp.StatusCode)).Inc()
+		c.limiter.Update(resp.Header)
+		if remaining, _ := c.limiter.State(); remaining >= 0 {
+			rateLimitRemaining.Set(float64(remaining))
+		}
 
 		// Success or client error (don't retry)
 		if resp.StatusCode < 500 && resp.StatusCode != 429 {
@@ -134,16 +309,31 @@ func (c *Client) doWithRetry(req *http.Request, maxRetries int) (*http.Response,
 
 		// Server error or rate limit - retry
 		resp.Body.Close()
+		lastErr = fmt.Errorf("server error: %d", resp.StatusCode)
 		if attempt < maxRetries {
+			span.AddEvent("retry", trace.WithAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode)))
 			backoff := time.Duration(attempt+1) * time.Second
 			if resp.StatusCode == 429 {
 				// For rate limiting, use longer backoff
 				backoff = time.Duration(attempt+1) * 2 * time.Second
 			}
-			time.Sleep(backoff)
+			if err := sleepCtx(ctx, backoff); err != nil {
+				return nil, err
+			}
 		}
-		lastErr = fmt.Errorf("server error: %d", resp.StatusCode)
 	}
 
 	return nil, lastErr
 }
+
+// sleepCtx sleeps for d or until ctx is done
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,76 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/yourorg/tg-release-bot/internal/tracing"
+)
+
+func TestRequestSpan(t *testing.T) {
+	var spans bytes.Buffer
+	shutdown, err := tracing.Setup(context.Background(), tracing.Options{Exporter: tracing.ExporterStdout, SampleRatio: 1, Writer: &spans})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer shutdown(context.Background())
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "4999")
+		w.Header().Set("X-RateLimit-Reset", fmt.Sprint(time.Now().Add(time.Hour).Unix()))
+		w.Write([]byte(`{"description": "The Go programming language"}`))
+	}))
+	defer srv.Close()
+	c := New("", Options{})
+	c.baseURL = srv.URL
+
+	if _, err := c.GetRepository(context.Background(), "golang", "go"); err != nil {
+		t.Fatal(err)
+	}
+
+	var span struct {
+		Name       string
+		Attributes []struct {
+			Key   string
+			Value struct{ Value any }
+		}
+		Status struct{ Code string }
+	}
+	if err := json.NewDecoder(&spans).Decode(&span); err != nil {
+		if err == io.EOF {
+			t.Fatal("no span exported")
+		}
+		t.Fatal(err)
+	}
+	if span.Name != "github repo" {
+		t.Errorf("span name = %q, want %q", span.Name, "github repo")
+	}
+	if span.Status.Code == "Error" {
+		t.Error("successful request has an error status")
+	}
+
+	attrs := make(map[string]any)
+	for _, attr := range span.Attributes {
+		attrs[attr.Key] = attr.Value.Value
+	}
+	want := map[string]any{
+		"http.request.method":         "GET",
+		"url.path":                    "/repos/golang/go",
+		"github.endpoint":             "repo",
+		"github.attempts":             float64(1),
+		"http.response.status_code":   float64(200),
+		"github.rate_limit_remaining": float64(4999),
+	}
+	for key, value := range want {
+		if attrs[key] != value {
+			t.Errorf("attribute %s = %v, want %v", key, attrs[key], value)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package github
+
+import (
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	requestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "release_bot",
+		Subsystem: "github",
+		Name:      "requests_total",
+		Help:      "GitHub API requests by endpoint and HTTP status (\"error\" for transport failures).",
+	}, []string{"endpoint", "status"})
+
+	requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "release_bot",
+		Subsystem: "github",
+		Name:      "request_duration_seconds",
+		Help:      "GitHub API request latency by endpoint.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"endpoint"})
+
+	rateLimitRemaining = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "release_bot",
+		Subsystem: "github",
+		Name:      "rate_limit_remaining",
+		Help:      "Remaining GitHub API requests reported by the last response.",
+	})
+)
+
+// endpointOf names the API endpoint of a request path for metric labels,
+// e.g. /repos/o/r/releases is "releases" and /repos/o/r is "repo"
+func endpointOf(path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) < 3 || parts[0] != "repos" {
+		return "other"
+	}
+	if len(parts) == 3 {
+		return "repo"
+	}
+	return parts[3]
+}
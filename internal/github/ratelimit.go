@@ -0,0 +1,80 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// rateLimiter spaces requests evenly and pauses all callers once GitHub
+// reports the rate limit as exhausted. It is shared by all workers using the client.
+type rateLimiter struct {
+	mu        sync.Mutex
+	interval  time.Duration
+	next      time.Time
+	remaining int
+	reset     time.Time
+}
+
+func newRateLimiter(requestsPerSecond float64) *rateLimiter {
+	l := &rateLimiter{remaining: -1}
+	if requestsPerSecond > 0 {
+		l.interval = time.Duration(float64(time.Second) / requestsPerSecond)
+	}
+	return l
+}
+
+// Wait blocks until the next request may be made or ctx is done
+func (l *rateLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+	if l.remaining == 0 && l.reset.After(at) {
+		at = l.reset
+	}
+	l.next = at.Add(l.interval)
+	l.mu.Unlock()
+
+	wait := time.Until(at)
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Update records the rate limit state reported in response headers
+func (l *rateLimiter) Update(h http.Header) {
+	remaining, err := strconv.Atoi(h.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	reset, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.remaining = remaining
+	l.reset = time.Unix(reset, 0)
+}
+
+// State returns the last reported remaining requests (-1 if unknown) and reset time
+func (l *rateLimiter) State() (int, time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.remaining, l.reset
+}
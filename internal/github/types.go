@@ -1,6 +1,9 @@
 package github
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // Release represents a GitHub release
 type Release struct {
@@ -20,3 +23,59 @@ type ReleasesResponse struct {
 	ETag       string
 	Releases   []Release
 }
+
+// Repository represents GitHub repository metadata
+type Repository struct {
+	FullName    string `json:"full_name"`
+	Description string `json:"description"`
+}
+
+// Comparison represents the result of comparing two refs
+type Comparison struct {
+	TotalCommits int `json:"total_commits"`
+	Commits      []struct {
+		Commit struct {
+			Message string `json:"message"`
+		} `json:"commit"`
+	} `json:"commits"`
+	Files []struct {
+		Filename string `json:"filename"`
+	} `json:"files"`
+}
+
+// CommitTitles returns the first line of each commit message
+func (c *Comparison) CommitTitles() []string {
+	titles := make([]string, 0, len(c.Commits))
+	for _, commit := range c.Commits {
+		title, _, _ := strings.Cut(commit.Commit.Message, "\n")
+		titles = append(titles, title)
+	}
+	return titles
+}
+
+// FileNames returns the names of changed files
+func (c *Comparison) FileNames() []string {
+	names := make([]string, 0, len(c.Files))
+	for _, f := range c.Files {
+		names = append(names, f.Filename)
+	}
+	return names
+}
+
+// Tag represents a git tag of a repository
+type Tag struct {
+	Name   string `json:"name"`
+	Commit struct {
+		SHA string `json:"sha"`
+	} `json:"commit"`
+}
+
+// Commit represents a commit with its committer date
+type Commit struct {
+	SHA    string `json:"sha"`
+	Commit struct {
+		Committer struct {
+			Date time.Time `json:"date"`
+		} `json:"committer"`
+	} `json:"commit"`
+}
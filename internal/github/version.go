@@ -0,0 +1,120 @@
+package github
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var versionRe = regexp.MustCompile(`(\d+(?:\.\d+)*)(?:[-.]?([0-9A-Za-z][0-9A-Za-z.-]*))?`)
+
+// CompareVersions compares two release tags such as "v1.12.3" or
+// "cert-manager-v1.15.0-rc.1". It returns -1, 0 or 1. Numeric parts are
+// compared one by one, missing parts count as zero. A version with a
+// prerelease suffix sorts before the same version without one, and
+// prerelease identifiers are ordered as in SemVer. Build metadata after
+// "+" is ignored.
+func CompareVersions(a, b string) int {
+	va, oka := parseVersion(a)
+	vb, okb := parseVersion(b)
+	if !oka || !okb {
+		return strings.Compare(a, b)
+	}
+
+	for i := 0; i < max(len(va.parts), len(vb.parts)); i++ {
+		if c := cmpInt(partAt(va.parts, i), partAt(vb.parts, i)); c != 0 {
+			return c
+		}
+	}
+
+	switch {
+	case va.pre == vb.pre:
+		return 0
+	case va.pre == "":
+		return 1
+	case vb.pre == "":
+		return -1
+	default:
+		return comparePrerelease(va.pre, vb.pre)
+	}
+}
+
+// comparePrerelease orders dot-separated prerelease identifiers: numeric
+// identifiers numerically and before alphanumeric ones, alphanumeric ones
+// lexically, and a shorter list before a longer one it is a prefix of
+func comparePrerelease(a, b string) int {
+	ia, ib := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < min(len(ia), len(ib)); i++ {
+		na, errA := strconv.Atoi(ia[i])
+		nb, errB := strconv.Atoi(ib[i])
+		var c int
+		switch {
+		case errA == nil && errB == nil:
+			c = cmpInt(na, nb)
+		case errA == nil:
+			c = -1
+		case errB == nil:
+			c = 1
+		default:
+			c = strings.Compare(ia[i], ib[i])
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return cmpInt(len(ia), len(ib))
+}
+
+func cmpInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func partAt(parts []int, i int) int {
+	if i < len(parts) {
+		return parts[i]
+	}
+	return 0
+}
+
+type version struct {
+	parts []int
+	pre   string
+}
+
+func parseVersion(tag string) (version, bool) {
+	tag, _, _ = strings.Cut(tag, "+")
+	m := versionRe.FindStringSubmatch(tag)
+	if m == nil {
+		return version{}, false
+	}
+
+	var v version
+	for _, part := range strings.Split(m[1], ".") {
+		n, _ := strconv.Atoi(part)
+		v.parts = append(v.parts, n)
+	}
+	v.pre = m[2]
+	return v, true
+}
+
+// ReleasesBetween returns releases newer than from and not newer than to, oldest first
+func ReleasesBetween(releases []Release, from, to string) []Release {
+	var result []Release
+	for _, r := range releases {
+		if CompareVersions(r.TagName, from) > 0 && CompareVersions(r.TagName, to) <= 0 {
+			result = append(result, r)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return CompareVersions(result[i].TagName, result[j].TagName) < 0
+	})
+	return result
+}
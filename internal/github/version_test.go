@@ -0,0 +1,70 @@
+package github
+
+import "testing"
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"v1.2.3", "v1.2.3", 0},
+		{"v1.2.3", "1.2.3", 0},
+		{"v1.2.3", "v1.2.4", -1},
+		{"v1.10.0", "v1.9.9", 1},
+		{"v2", "v1.99.99", 1},
+		{"v1.2", "v1.2.0", 0},
+
+		// prerelease sorts before the release
+		{"v1.0.0-rc.1", "v1.0.0", -1},
+		{"v1.0.0", "v1.0.0-rc.1", 1},
+		{"cert-manager-v1.15.0-rc.1", "cert-manager-v1.15.0", -1},
+
+		// numeric prerelease identifiers compare numerically
+		{"v1.0.0-rc.10", "v1.0.0-rc.2", 1},
+		{"v1.0.0-rc.2", "v1.0.0-rc.10", -1},
+		{"v1.0.0-alpha", "v1.0.0-beta", -1},
+		{"v1.0.0-alpha", "v1.0.0-alpha.1", -1},
+		{"v1.0.0-alpha.1", "v1.0.0-alpha.beta", -1},
+		{"v1.0.0-1", "v1.0.0-alpha", -1},
+
+		// a fourth numeric part is a version part, not a prerelease
+		{"v1.2.3.4", "v1.2.3", 1},
+		{"v1.2.3.4", "v1.2.3.10", -1},
+		{"v1.2.3.0", "v1.2.3", 0},
+		{"v1.2.3.4", "v1.2.4", -1},
+
+		// build metadata is ignored
+		{"v1.2.3+build.5", "v1.2.3", 0},
+		{"v1.2.3+build.5", "v1.2.3+build.9", 0},
+		{"v1.2.3-rc.1+build", "v1.2.3", -1},
+
+		// tags without a version fall back to string order
+		{"latest", "nightly", -1},
+	}
+	for _, tt := range tests {
+		if got := CompareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestReleasesBetween(t *testing.T) {
+	releases := []Release{
+		{TagName: "v1.0.0-rc.10"},
+		{TagName: "v1.0.0"},
+		{TagName: "v0.9.0"},
+		{TagName: "v1.0.0-rc.2"},
+		{TagName: "v1.1.0"},
+	}
+
+	got := ReleasesBetween(releases, "v0.9.0", "v1.0.0")
+	want := []string{"v1.0.0-rc.2", "v1.0.0-rc.10", "v1.0.0"}
+	if len(got) != len(want) {
+		t.Fatalf("ReleasesBetween returned %d releases, want %d", len(got), len(want))
+	}
+	for i, r := range got {
+		if r.TagName != want[i] {
+			t.Errorf("release %d = %s, want %s", i, r.TagName, want[i])
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package scheduler
+
+import (
+	"sort"
+	"time"
+)
+
+// PollBounds limits adaptive polling intervals
+type PollBounds struct {
+	Min time.Duration // interval near the expected release time
+	Max time.Duration // interval for dormant repositories
+}
+
+// EstimateCadence returns the median gap between release dates, or 0 when
+// fewer than two releases are known
+func EstimateCadence(published []time.Time) time.Duration {
+	if len(published) < 2 {
+		return 0
+	}
+
+	dates := append([]time.Time(nil), published...)
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+
+	gaps := make([]time.Duration, 0, len(dates)-1)
+	for i := 1; i < len(dates); i++ {
+		if gap := dates[i].Sub(dates[i-1]); gap > 0 {
+			gaps = append(gaps, gap)
+		}
+	}
+	if len(gaps) == 0 {
+		return 0
+	}
+
+	sort.Slice(gaps, func(i, j int) bool { return gaps[i] < gaps[j] })
+	return gaps[len(gaps)/2]
+}
+
+// NextCheck computes when a repository should be polled next. A manual
+// override wins; otherwise polling converges on the expected release time
+// (last release + cadence), stays at the minimum interval around it, and
+// backs off proportionally to the time since the last release once the
+// repository is overdue.
+func NextCheck(now, lastRelease time.Time, cadence, override time.Duration, b PollBounds) time.Time {
+	if override > 0 {
+		return now.Add(override)
+	}
+	if cadence <= 0 || lastRelease.IsZero() {
+		return now.Add(b.Min)
+	}
+
+	expected := lastRelease.Add(cadence)
+	window := cadence / 4
+
+	var interval time.Duration
+	switch {
+	case now.Before(expected.Add(-window)):
+		interval = expected.Add(-window).Sub(now) / 2
+	case now.Before(expected.Add(window)):
+		interval = b.Min
+	default:
+		interval = now.Sub(lastRelease) / 10
+	}
+
+	if interval < b.Min {
+		interval = b.Min
+	}
+	if b.Max > 0 && interval > b.Max {
+		interval = b.Max
+	}
+	return now.Add(interval)
+}
@@ -0,0 +1,134 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func mustLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("time zone %s is not available: %v", name, err)
+	}
+	return loc
+}
+
+func TestParseCronErrors(t *testing.T) {
+	for _, expr := range []string{
+		"* * * *",           // too few fields
+		"* * * * * *",       // too many fields
+		"60 * * * *",        // minute out of range
+		"* 24 * * *",        // hour out of range
+		"* * 0 * *",         // day of month starts at 1
+		"* * * 13 *",        // month out of range
+		"* * * * 8",         // day of week out of range
+		"*/0 * * * *",       // zero step
+		"5-1 * * * *",       // reversed range
+		"* * * FOO *",       // unknown name
+		"0 0 30 FEB *",      // never matches
+		"0 0 31 APR,JUN * ", // never matches
+	} {
+		if _, err := ParseCron(expr, time.UTC); err == nil {
+			t.Errorf("ParseCron(%q) succeeded, want an error", expr)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	// 2026-01-05 is a Monday
+	from := time.Date(2026, 1, 5, 10, 7, 30, 0, time.UTC)
+	tests := []struct {
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{"* * * * *", from, time.Date(2026, 1, 5, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", from, time.Date(2026, 1, 5, 10, 15, 0, 0, time.UTC)},
+		{"0 9-17/2 * * *", from, time.Date(2026, 1, 5, 11, 0, 0, 0, time.UTC)},
+		{"0,30 8 * * *", from, time.Date(2026, 1, 6, 8, 0, 0, 0, time.UTC)},
+		{"0 9 * * MON-FRI", time.Date(2026, 1, 9, 12, 0, 0, 0, time.UTC), time.Date(2026, 1, 12, 9, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", from, time.Date(2026, 1, 11, 0, 0, 0, 0, time.UTC)}, // 7 is Sunday
+		{"0 0 * * sun", from, time.Date(2026, 1, 11, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", from, time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 JAN *", from, time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 FEB *", from, time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 31 * *", time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 5, 31, 0, 0, 0, 0, time.UTC)},
+
+		// Next is strictly after the given time
+		{"8 10 * * *", time.Date(2026, 1, 5, 10, 8, 0, 0, time.UTC), time.Date(2026, 1, 6, 10, 8, 0, 0, time.UTC)},
+
+		// both day fields restricted: either one matches. The 13th of
+		// January 2026 is a Tuesday, the first Friday after from is the 9th.
+		{"0 0 13 * FRI", from, time.Date(2026, 1, 9, 0, 0, 0, 0, time.UTC)},
+		{"0 0 13 * FRI", time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC), time.Date(2026, 1, 13, 0, 0, 0, 0, time.UTC)},
+		// one day field restricted: only that one counts
+		{"0 0 13 * *", from, time.Date(2026, 1, 13, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * FRI", from, time.Date(2026, 1, 9, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		c, err := ParseCron(tt.expr, time.UTC)
+		if err != nil {
+			t.Fatalf("ParseCron(%q): %v", tt.expr, err)
+		}
+		if got := c.Next(tt.from); !got.Equal(tt.want) {
+			t.Errorf("%q.Next(%s) = %s, want %s", tt.expr, tt.from, got, tt.want)
+		}
+	}
+}
+
+func TestCronNextTimeZone(t *testing.T) {
+	tokyo := mustLocation(t, "Asia/Tokyo")
+	c, err := ParseCron("0 9 * * *", tokyo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 09:00 in Tokyo is 00:00 UTC
+	got := c.Next(time.Date(2026, 1, 5, 1, 0, 0, 0, time.UTC))
+	if want := time.Date(2026, 1, 6, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Next = %s, want %s", got.UTC(), want)
+	}
+	if got.Location() != tokyo {
+		t.Errorf("Next is in %s, want the schedule's time zone", got.Location())
+	}
+}
+
+func TestCronNextDST(t *testing.T) {
+	berlin := mustLocation(t, "Europe/Berlin")
+
+	// On 2026-03-29 clocks jump from 02:00 to 03:00: 02:30 does not exist
+	// that day and is skipped
+	c, err := ParseCron("30 2 * * *", berlin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := c.Next(time.Date(2026, 3, 29, 0, 0, 0, 0, berlin))
+	if want := time.Date(2026, 3, 30, 2, 30, 0, 0, berlin); !got.Equal(want) {
+		t.Errorf("Next across the spring gap = %s, want %s", got, want)
+	}
+
+	// Hourly schedules keep running across the gap, on real hours
+	c, err = ParseCron("0 * * * *", berlin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = c.Next(time.Date(2026, 3, 29, 1, 30, 0, 0, berlin))
+	if want := time.Date(2026, 3, 29, 3, 0, 0, 0, berlin); !got.Equal(want) {
+		t.Errorf("Next hourly across the spring gap = %s, want %s", got, want)
+	}
+
+	// On 2026-10-25 clocks go back from 03:00 to 02:00: 02:30 happens
+	// twice but the schedule runs only once that day
+	c, err = ParseCron("30 2 * * *", berlin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := c.Next(time.Date(2026, 10, 25, 0, 0, 0, 0, berlin))
+	if first.Day() != 25 || first.Hour() != 2 || first.Minute() != 30 {
+		t.Errorf("Next across the autumn overlap = %s, want 02:30 on the 25th", first)
+	}
+	if got, want := c.Next(first), time.Date(2026, 10, 26, 2, 30, 0, 0, berlin); !got.Equal(want) {
+		t.Errorf("Next after that = %s, want %s", got, want)
+	}
+}
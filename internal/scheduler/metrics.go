@@ -0,0 +1,30 @@
+package scheduler
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	jobDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "release_bot",
+		Subsystem: "scheduler",
+		Name:      "job_duration_seconds",
+		Help:      "Job run duration by job and result (ok, error, cancelled or panic).",
+		Buckets:   []float64{1, 5, 15, 30, 60, 120, 300, 600, 1800},
+	}, []string{"job", "result"})
+
+	jobSkipped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "release_bot",
+		Subsystem: "scheduler",
+		Name:      "job_skipped_total",
+		Help:      "Job runs skipped because the previous run was still going.",
+	}, []string{"job"})
+
+	jobLastRun = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "release_bot",
+		Subsystem: "scheduler",
+		Name:      "job_last_run_timestamp_seconds",
+		Help:      "Unix time the last run of a job finished.",
+	}, []string{"job"})
+)
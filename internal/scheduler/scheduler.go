@@ -2,45 +2,138 @@ package scheduler
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+	"math/rand/v2"
+	"sync"
 	"time"
 )
 
 // Job represents a function to be executed by the scheduler
-type Job func(ctx context.Context)
+type Job func(ctx context.Context, run Run) error
 
-// Scheduler manages periodic execution of jobs
+// Run describes a single job execution
+type Run struct {
+	Manual bool // triggered by TriggerCheck rather than by the schedule
+}
+
+// OverlapPolicy decides what happens when a run is requested while the job is still running
+type OverlapPolicy string
+
+const (
+	// OverlapSkip drops runs requested while the job is running
+	OverlapSkip OverlapPolicy = "skip"
+	// OverlapQueue remembers one run requested while the job is running and starts it afterwards
+	OverlapQueue OverlapPolicy = "queue"
+)
+
+// Options configures the scheduler
+type Options struct {
+	// Name labels the job in metrics, "job" when empty
+	Name    string
+	Overlap OverlapPolicy
+
+	// NextDue reports when work is next due (e.g. the earliest per-repository
+	// check). When set, the scheduler wakes up at that time instead of on a
+	// fixed ticker, but never sooner than MinWait nor later than the interval.
+	NextDue func(ctx context.Context) time.Time
+	MinWait time.Duration
+
+	// Cron restricts scheduled runs to matching times (e.g. working hours);
+	// manual triggers are not affected
+	Cron *CronSchedule
+	// Jitter adds a random delay in [0, Jitter) to every scheduled run so
+	// several instances sharing a GitHub token do not poll in lockstep
+	Jitter time.Duration
+	// SkipInitialRun waits for the first scheduled run instead of running
+	// the job on Start
+	SkipInitialRun bool
+	// Clock defaults to the system clock
+	Clock Clock
+}
+
+// Status describes the scheduler's runs
+type Status struct {
+	Running      bool
+	Pending      bool
+	LastStart    time.Time
+	LastDuration time.Duration
+	LastResult   string
+	LastError    string
+	NextRun      time.Time
+	Runs         int
+	Skipped      int
+}
+
+// Scheduler manages periodic execution of jobs. At most one job execution
+// runs at a time.
 type Scheduler struct {
-	logger     *slog.Logger
-	interval   time.Duration
-	job        Job
-	ticker     *time.Ticker
-	done       chan struct{}
-	triggerCh  chan struct{}
+	name      string
+	logger    *slog.Logger
+	interval  time.Duration
+	job       Job
+	overlap   OverlapPolicy
+	nextDue   func(ctx context.Context) time.Time
+	minWait   time.Duration
+	cron      *CronSchedule
+	jitter    time.Duration
+	skipFirst bool
+	clock     Clock
+	done      chan struct{}
+	triggerCh chan struct{}
+	resetCh   chan struct{}
+
+	mu      sync.Mutex
+	status  Status
+	pending Run // the queued run while status.Pending is set
 }
 
 // New creates a new scheduler instance
-func New(logger *slog.Logger, interval time.Duration, job Job) *Scheduler {
+func New(logger *slog.Logger, interval time.Duration, job Job, opt Options) *Scheduler {
+	if opt.Name == "" {
+		opt.Name = "job"
+	}
+	if opt.Overlap == "" {
+		opt.Overlap = OverlapQueue
+	}
+	if opt.MinWait <= 0 {
+		opt.MinWait = time.Minute
+	}
+	if opt.Clock == nil {
+		opt.Clock = SystemClock{}
+	}
+
 	return &Scheduler{
+		name:      opt.Name,
 		logger:    logger,
 		interval:  interval,
 		job:       job,
+		overlap:   opt.Overlap,
+		nextDue:   opt.NextDue,
+		minWait:   opt.MinWait,
+		cron:      opt.Cron,
+		jitter:    opt.Jitter,
+		skipFirst: opt.SkipInitialRun,
+		clock:     opt.Clock,
 		done:      make(chan struct{}),
 		triggerCh: make(chan struct{}, 1),
+		resetCh:   make(chan struct{}, 1),
 	}
 }
 
 // Start begins the scheduler execution
 func (s *Scheduler) Start(ctx context.Context) {
-	s.logger.Info("Starting scheduler", "interval", s.interval)
-
-	s.ticker = time.NewTicker(s.interval)
+	if s.cron != nil {
+		s.logger.Info("Starting scheduler", "cron", s.cron.String(), "jitter", s.jitter, "overlap", s.overlap)
+	} else {
+		s.logger.Info("Starting scheduler", "interval", s.interval, "jitter", s.jitter, "overlap", s.overlap)
+	}
 
 	// Run job immediately on start
-	go func() {
+	if !s.skipFirst {
 		s.logger.Info("Running initial job execution")
-		s.executeJob(ctx)
-	}()
+		s.dispatch(ctx, Run{})
+	}
 
 	// Start periodic execution
 	go s.run(ctx)
@@ -49,11 +142,6 @@ func (s *Scheduler) Start(ctx context.Context) {
 // Stop stops the scheduler
 func (s *Scheduler) Stop() {
 	s.logger.Info("Stopping scheduler")
-
-	if s.ticker != nil {
-		s.ticker.Stop()
-	}
-
 	close(s.done)
 }
 
@@ -69,46 +157,207 @@ func (s *Scheduler) TriggerCheck(ctx context.Context) error {
 	}
 }
 
+// Reschedule replaces the interval, cron schedule and jitter. A running job
+// is not affected; the next run is planned again with the new settings.
+func (s *Scheduler) Reschedule(interval time.Duration, cron *CronSchedule, jitter time.Duration) {
+	s.mu.Lock()
+	s.interval = interval
+	s.cron = cron
+	s.jitter = jitter
+	s.mu.Unlock()
+
+	if cron != nil {
+		s.logger.Info("Scheduler rescheduled", "cron", cron.String(), "jitter", jitter)
+	} else {
+		s.logger.Info("Scheduler rescheduled", "interval", interval, "jitter", jitter)
+	}
+
+	select {
+	case s.resetCh <- struct{}{}:
+	default:
+	}
+}
+
+// Status returns a snapshot of the scheduler's run status
+func (s *Scheduler) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status
+}
+
 // run is the main scheduler loop
 func (s *Scheduler) run(ctx context.Context) {
 	for {
+		next := s.nextRun(ctx)
+		s.setNextRun(next)
+		timer := s.clock.NewTimer(next.Sub(s.clock.Now()))
+
 		select {
 		case <-ctx.Done():
+			timer.Stop()
 			s.logger.Info("Scheduler stopped due to context cancellation")
 			return
 		case <-s.done:
+			timer.Stop()
 			s.logger.Info("Scheduler stopped")
 			return
-		case <-s.ticker.C:
+		case <-timer.C():
 			s.logger.Debug("Scheduler tick - executing job")
-			go s.executeJob(ctx)
+			s.dispatch(ctx, Run{})
 		case <-s.triggerCh:
+			timer.Stop()
 			s.logger.Info("Manual trigger - executing job")
-			go s.executeJob(ctx)
+			s.dispatch(ctx, Run{Manual: true})
+		case <-s.resetCh:
+			timer.Stop()
 		}
 	}
 }
 
+// dispatch starts the job unless it is already running, in which case the
+// overlap policy decides whether the run is skipped or queued
+func (s *Scheduler) dispatch(ctx context.Context, run Run) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.status.Running {
+		if s.overlap == OverlapQueue && !s.status.Pending {
+			s.status.Pending = true
+			s.pending = run
+			s.logger.Info("Job is still running - queued the next execution", "manual", run.Manual)
+			return
+		}
+		// A manual trigger upgrades a queued scheduled run instead of being lost
+		if s.overlap == OverlapQueue && run.Manual && !s.pending.Manual {
+			s.pending.Manual = true
+			s.logger.Info("Job is still running - queued execution is now manual")
+			return
+		}
+		s.status.Skipped++
+		jobSkipped.WithLabelValues(s.name).Inc()
+		s.logger.Warn("Job is still running - skipped execution", "overlap", s.overlap)
+		return
+	}
+
+	s.status.Running = true
+	go s.loop(ctx, run)
+}
+
+// loop executes the job, then any run queued meanwhile
+func (s *Scheduler) loop(ctx context.Context, run Run) {
+	for {
+		s.executeJob(ctx, run)
+
+		s.mu.Lock()
+		if s.status.Pending && ctx.Err() == nil {
+			run = s.pending
+			s.status.Pending = false
+			s.pending = Run{}
+			s.mu.Unlock()
+			s.logger.Info("Running queued job execution", "manual", run.Manual)
+			continue
+		}
+		s.status.Running = false
+		s.status.Pending = false
+		s.pending = Run{}
+		s.mu.Unlock()
+		return
+	}
+}
+
 // executeJob runs the job with error handling and logging
-func (s *Scheduler) executeJob(ctx context.Context) {
-	start := time.Now()
+func (s *Scheduler) executeJob(ctx context.Context, run Run) {
+	start := s.clock.Now()
+	s.mu.Lock()
+	s.status.LastStart = start
+	s.status.Runs++
+	s.mu.Unlock()
 
+	result, errText := "ok", ""
 	defer func() {
 		if r := recover(); r != nil {
-			s.logger.Error("Job panicked", "panic", r, "duration", time.Since(start))
+			s.logger.Error("Job panicked", "panic", r, "duration", s.clock.Now().Sub(start))
+			result, errText = "panic", fmt.Sprint(r)
 		}
+
+		end := s.clock.Now()
+		s.mu.Lock()
+		s.status.LastDuration = end.Sub(start)
+		s.status.LastResult = result
+		s.status.LastError = errText
+		s.mu.Unlock()
+
+		jobDuration.WithLabelValues(s.name, result).Observe(end.Sub(start).Seconds())
+		jobLastRun.WithLabelValues(s.name).Set(float64(end.Unix()))
 	}()
 
 	s.logger.Debug("Job execution started")
-	s.job(ctx)
+	if err := s.job(ctx, run); err != nil {
+		result, errText = "error", err.Error()
+		if ctx.Err() != nil {
+			result = "cancelled"
+		}
+		s.logger.Error("Job execution failed", "error", err, "duration", s.clock.Now().Sub(start))
+		return
+	}
 
-	duration := time.Since(start)
+	duration := s.clock.Now().Sub(start)
 	s.logger.Debug("Job execution completed", "duration", duration)
 }
 
+// nextRun returns when the scheduler should wake up next: the next due time
+// bounded by the interval, moved to the next matching cron time, plus jitter
+func (s *Scheduler) nextRun(ctx context.Context) time.Time {
+	s.mu.Lock()
+	interval, cron, jitter := s.interval, s.cron, s.jitter
+	s.mu.Unlock()
+
+	next := s.plannedRun(ctx, interval, cron)
+	if jitter > 0 {
+		next = next.Add(rand.N(jitter))
+	}
+	return next
+}
+
+func (s *Scheduler) plannedRun(ctx context.Context, interval time.Duration, cron *CronSchedule) time.Time {
+	now := s.clock.Now()
+
+	if cron != nil {
+		next := cron.Next(now)
+		if s.nextDue != nil {
+			if due := s.nextDue(ctx); due.After(next) {
+				// Nothing is due before then: wait for the first cron time after it
+				next = cron.Next(due.Add(-time.Minute))
+			}
+		}
+		return next
+	}
+
+	latest := now.Add(interval)
+	if s.nextDue == nil {
+		return latest
+	}
+
+	due := s.nextDue(ctx)
+	switch {
+	case due.IsZero() || due.After(latest):
+		return latest
+	case due.Before(now.Add(s.minWait)):
+		return now.Add(s.minWait)
+	default:
+		return due
+	}
+}
+
+func (s *Scheduler) setNextRun(t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status.NextRun = t
+}
+
 // Legacy function for backward compatibility
 func Start(ctx context.Context, log *slog.Logger, interval time.Duration, job Job) {
-	scheduler := New(log, interval, job)
+	scheduler := New(log, interval, job, Options{})
 	scheduler.Start(ctx)
 
 	// Wait for context cancellation
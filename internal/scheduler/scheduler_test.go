@@ -0,0 +1,267 @@
+package scheduler
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// blockingJob reports each run on started and waits for release before returning
+type blockingJob struct {
+	started chan Run
+	release chan struct{}
+}
+
+func newBlockingJob() *blockingJob {
+	return &blockingJob{started: make(chan Run, 10), release: make(chan struct{})}
+}
+
+func (j *blockingJob) run(ctx context.Context, run Run) error {
+	j.started <- run
+	<-j.release
+	return nil
+}
+
+func (j *blockingJob) next(t *testing.T) Run {
+	t.Helper()
+	select {
+	case run := <-j.started:
+		return run
+	case <-time.After(time.Second):
+		t.Fatal("job did not start")
+		return Run{}
+	}
+}
+
+func (j *blockingJob) idle(t *testing.T) {
+	t.Helper()
+	select {
+	case run := <-j.started:
+		t.Fatalf("unexpected run %+v", run)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestQueuedRunKeepsManual(t *testing.T) {
+	tests := []struct {
+		name     string
+		queued   []Run
+		wantNext Run
+	}{
+		{"manual", []Run{{Manual: true}}, Run{Manual: true}},
+		{"scheduled", []Run{{}}, Run{}},
+		{"scheduled then manual", []Run{{}, {Manual: true}}, Run{Manual: true}},
+		{"manual then scheduled", []Run{{Manual: true}, {}}, Run{Manual: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := newBlockingJob()
+			s := New(discard, time.Hour, job.run, Options{Overlap: OverlapQueue, Clock: NewFakeClock(time.Now())})
+			ctx := context.Background()
+
+			s.dispatch(ctx, Run{})
+			job.next(t)
+			for _, run := range tt.queued {
+				s.dispatch(ctx, run)
+			}
+			if !s.Status().Pending {
+				t.Fatal("run was not queued")
+			}
+
+			job.release <- struct{}{}
+			if got := job.next(t); got != tt.wantNext {
+				t.Errorf("queued run = %+v, want %+v", got, tt.wantNext)
+			}
+			close(job.release)
+			job.idle(t)
+		})
+	}
+}
+
+func TestOverlapSkip(t *testing.T) {
+	job := newBlockingJob()
+	s := New(discard, time.Hour, job.run, Options{Overlap: OverlapSkip, Clock: NewFakeClock(time.Now())})
+	ctx := context.Background()
+
+	s.dispatch(ctx, Run{})
+	job.next(t)
+	s.dispatch(ctx, Run{})
+	s.dispatch(ctx, Run{Manual: true})
+
+	st := s.Status()
+	if st.Pending || st.Skipped != 2 {
+		t.Errorf("pending = %v, skipped = %d; want false, 2", st.Pending, st.Skipped)
+	}
+	close(job.release)
+	job.idle(t)
+}
+
+// waitTimer waits until the scheduler loop has set its next timer
+func waitTimer(t *testing.T, clock *FakeClock) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for clock.Timers() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("scheduler did not set a timer")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSchedulerRunsOnClock(t *testing.T) {
+	start := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	job := newBlockingJob()
+	close(job.release)
+	s := New(discard, 10*time.Minute, job.run, Options{Clock: clock})
+
+	s.Start(context.Background())
+	defer s.Stop()
+	job.next(t) // initial run
+
+	waitTimer(t, clock)
+	if got, want := s.Status().NextRun, start.Add(10*time.Minute); !got.Equal(want) {
+		t.Errorf("NextRun = %s, want %s", got, want)
+	}
+	clock.Advance(10*time.Minute - time.Second)
+	job.idle(t)
+	clock.Advance(time.Second)
+	if got := job.next(t); got.Manual {
+		t.Error("scheduled run is marked manual")
+	}
+
+	waitTimer(t, clock)
+	if got, want := s.Status().NextRun, start.Add(20*time.Minute); !got.Equal(want) {
+		t.Errorf("NextRun after a tick = %s, want %s", got, want)
+	}
+}
+
+func TestSchedulerRunsOnCron(t *testing.T) {
+	start := time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC)
+	cron, err := ParseCron("0 9 * * *", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := NewFakeClock(start)
+	job := newBlockingJob()
+	close(job.release)
+	s := New(discard, time.Hour, job.run, Options{Cron: cron, Clock: clock})
+
+	s.Start(context.Background())
+	defer s.Stop()
+	job.next(t)
+
+	waitTimer(t, clock)
+	if got, want := s.Status().NextRun, start.Add(time.Hour); !got.Equal(want) {
+		t.Errorf("NextRun = %s, want %s", got, want)
+	}
+	clock.Advance(59 * time.Minute)
+	job.idle(t)
+	clock.Advance(time.Minute)
+	job.next(t)
+
+	waitTimer(t, clock)
+	if got, want := s.Status().NextRun, start.Add(25*time.Hour); !got.Equal(want) {
+		t.Errorf("NextRun after a run = %s, want %s", got, want)
+	}
+}
+
+func TestSchedulerQueuesTickWhileRunning(t *testing.T) {
+	clock := NewFakeClock(time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC))
+	job := newBlockingJob()
+	s := New(discard, time.Minute, job.run, Options{Overlap: OverlapQueue, Clock: clock})
+
+	s.Start(context.Background())
+	defer s.Stop()
+	job.next(t)
+
+	// two ticks while the initial run is still going: one is queued, one skipped
+	for i := 0; i < 2; i++ {
+		waitTimer(t, clock)
+		clock.Advance(time.Minute)
+	}
+	deadline := time.Now().Add(time.Second)
+	for st := s.Status(); !st.Pending || st.Skipped != 1; st = s.Status() {
+		if time.Now().After(deadline) {
+			t.Fatalf("pending = %v, skipped = %d; want true, 1", st.Pending, st.Skipped)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	job.release <- struct{}{}
+	job.next(t) // the queued run
+	close(job.release)
+	job.idle(t)
+}
+
+func TestSchedulerJitter(t *testing.T) {
+	now := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+	const jitter = 10 * time.Minute
+	s := New(discard, time.Hour, nil, Options{Jitter: jitter, Clock: NewFakeClock(now)})
+
+	planned := now.Add(time.Hour)
+	seen := map[time.Time]bool{}
+	for i := 0; i < 100; i++ {
+		next := s.nextRun(context.Background())
+		if next.Before(planned) || !next.Before(planned.Add(jitter)) {
+			t.Fatalf("nextRun = %s, want within [%s, %s)", next, planned, planned.Add(jitter))
+		}
+		seen[next] = true
+	}
+	if len(seen) < 2 {
+		t.Error("jitter did not vary the next run")
+	}
+}
+
+func TestSchedulerNextDue(t *testing.T) {
+	now := time.Date(2026, 1, 5, 10, 7, 0, 0, time.UTC)
+	hourly, err := ParseCron("0 * * * *", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		cron *CronSchedule
+		due  time.Time
+		want time.Time
+	}{
+		{"nothing due", nil, time.Time{}, now.Add(time.Hour)},
+		{"due soon waits MinWait", nil, now.Add(10 * time.Second), now.Add(time.Minute)},
+		{"due within the interval", nil, now.Add(30 * time.Minute), now.Add(30 * time.Minute)},
+		{"due after the interval", nil, now.Add(2 * time.Hour), now.Add(time.Hour)},
+		{"cron before due", hourly, now.Add(10 * time.Minute), time.Date(2026, 1, 5, 11, 0, 0, 0, time.UTC)},
+		{"due after the cron time", hourly, now.Add(2 * time.Hour), time.Date(2026, 1, 5, 13, 0, 0, 0, time.UTC)},
+		{"due on a cron time", hourly, time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC), time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(discard, time.Hour, nil, Options{
+				Cron:    tt.cron,
+				NextDue: func(context.Context) time.Time { return tt.due },
+				Clock:   NewFakeClock(now),
+			})
+			if got := s.nextRun(context.Background()); !got.Equal(tt.want) {
+				t.Errorf("nextRun = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchedulerSkipInitialRun(t *testing.T) {
+	clock := NewFakeClock(time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC))
+	job := newBlockingJob()
+	close(job.release)
+	s := New(discard, time.Hour, job.run, Options{SkipInitialRun: true, Clock: clock})
+
+	s.Start(context.Background())
+	defer s.Stop()
+	waitTimer(t, clock)
+	job.idle(t)
+
+	clock.Advance(time.Hour)
+	job.next(t)
+}
@@ -3,14 +3,20 @@ package telegram
 import (
 	"context"
 	"fmt"
+	"html"
 	"log/slog"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/yourorg/tg-release-bot/internal/scheduler"
 )
 
-// Store interface for bot commands  
+// Store interface for bot commands
 type Store interface {
 	AddRepository(ctx context.Context, owner, name string, trackPrereleases bool) error
 	RemoveRepository(ctx context.Context, owner, name string) error
@@ -18,11 +24,20 @@ type Store interface {
 	AddChat(ctx context.Context, chatID int64, title, language string) error
 	RemoveChat(ctx context.Context, chatID int64) error
 	ListChats(ctx context.Context) ([]Chat, error)
+	LLMSpendByRepo(ctx context.Context, since time.Time) ([]LLMSpend, error)
+	LLMSpendByModel(ctx context.Context, since time.Time) ([]LLMSpend, error)
+	SetRepoInterval(ctx context.Context, owner, name string, interval time.Duration) error
+	SetPin(ctx context.Context, chatID int64, owner, name, version string) error
+	RemovePin(ctx context.Context, chatID int64, owner, name string) error
+	ListPins(ctx context.Context, chatID int64) ([]Pin, error)
+	ListReleaseHistory(ctx context.Context, owner, name string, limit int) ([]ReleaseRecord, error)
+	GetRelease(ctx context.Context, owner, name, tag string) (ReleaseRecord, bool, error)
 }
 
 // JobRunner interface for triggering release checks
 type JobRunner interface {
 	TriggerCheck(ctx context.Context) error
+	Status() scheduler.Status
 }
 
 // LLMAdvisor interface for testing LLM
@@ -30,6 +45,47 @@ type LLMAdvisor interface {
 	Advise(ctx context.Context, repo, tag string, bullets []string) (string, error)
 }
 
+// AdvisorSource returns the current advisor, or nil when it is disabled. It
+// is called per command so configuration reloads take effect.
+type AdvisorSource func() LLMAdvisor
+
+// PromptManager interface for advisor prompt templates
+type PromptManager interface {
+	ListPrompts(ctx context.Context) ([]PromptInfo, error)
+	GetPrompt(ctx context.Context, name string) (PromptInfo, bool, error)
+	SavePrompt(ctx context.Context, name, system, user string) (PromptInfo, error)
+	AssignPrompt(ctx context.Context, scope, target, name string) error
+	ResolvePrompt(ctx context.Context, chatID int64, repo string) (PromptInfo, error)
+}
+
+// Backups interface for database snapshots
+type Backups interface {
+	Snapshot(ctx context.Context) (path string, err error)
+}
+
+// Configuration interface for showing and re-reading configuration at runtime
+type Configuration interface {
+	// Reload applies the current configuration and returns the changed settings
+	Reload(ctx context.Context) ([]string, error)
+	// Settings returns the effective configuration with secrets redacted
+	Settings() []ConfigSetting
+}
+
+// ConfigSetting represents a configuration value for bot operations
+type ConfigSetting struct {
+	Name  string
+	Value string
+}
+
+// PromptInfo represents an advisor prompt for bot operations
+type PromptInfo struct {
+	Name    string
+	Version string
+	Source  string
+	System  string
+	User    string
+}
+
 // Repository represents a repository for bot operations
 type Repository struct {
 	Owner            string
@@ -44,36 +100,84 @@ type Chat struct {
 	Language string
 }
 
+// Pin represents a pinned repository version for bot operations
+type Pin struct {
+	Owner   string
+	Name    string
+	Version string
+}
+
+// ReleaseRecord represents a processed release from the history for bot operations
+type ReleaseRecord struct {
+	Tag         string
+	Name        string
+	URL         string
+	Prerelease  bool
+	PublishedAt time.Time
+	Message     string
+}
+
+// LLMSpend represents aggregated advisor usage for bot operations
+type LLMSpend struct {
+	Key              string
+	Calls            int
+	PromptTokens     int
+	CompletionTokens int
+	CostUSD          float64
+}
+
 // Bot handles Telegram bot commands
 type Bot struct {
-	api          *tgbotapi.BotAPI
-	store        Store
-	jobRunner    JobRunner
-	llmAdvisor   LLMAdvisor
+	api        *tgbotapi.BotAPI
+	store      Store
+	jobRunner  JobRunner
+	llmAdvisor AdvisorSource
+	prompts    PromptManager
+	backups    Backups
+	config     Configuration
+	logger     *slog.Logger
+
+	mu           sync.RWMutex
 	allowedUsers map[int64]bool
-	logger       *slog.Logger
 }
 
 // NewBot creates a new bot instance
-func NewBot(token string, store Store, jobRunner JobRunner, llmAdvisor LLMAdvisor, allowedUserIDs []int64, logger *slog.Logger) (*Bot, error) {
+func NewBot(token string, store Store, jobRunner JobRunner, llmAdvisor AdvisorSource, prompts PromptManager, backups Backups, config Configuration, allowedUserIDs []int64, logger *slog.Logger) (*Bot, error) {
 	api, err := tgbotapi.NewBotAPI(token)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create bot API: %w", err)
 	}
 
+	b := &Bot{
+		api:        api,
+		store:      store,
+		jobRunner:  jobRunner,
+		llmAdvisor: llmAdvisor,
+		prompts:    prompts,
+		backups:    backups,
+		config:     config,
+		logger:     logger,
+	}
+	b.SetAllowedUsers(allowedUserIDs)
+	return b, nil
+}
+
+// SetAllowedUsers replaces the users allowed to run commands
+func (b *Bot) SetAllowedUsers(allowedUserIDs []int64) {
 	allowedUsers := make(map[int64]bool)
 	for _, id := range allowedUserIDs {
 		allowedUsers[id] = true
 	}
 
-	return &Bot{
-		api:          api,
-		store:        store,
-		jobRunner:    jobRunner,
-		llmAdvisor:   llmAdvisor,
-		allowedUsers: allowedUsers,
-		logger:       logger,
-	}, nil
+	b.mu.Lock()
+	b.allowedUsers = allowedUsers
+	b.mu.Unlock()
+}
+
+func (b *Bot) isAllowed(userID int64) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.allowedUsers[userID]
 }
 
 // StartPolling starts polling for updates
@@ -99,7 +203,7 @@ func (b *Bot) StartPolling(ctx context.Context) {
 // handleMessage processes incoming messages
 func (b *Bot) handleMessage(ctx context.Context, message *tgbotapi.Message) {
 	// Check if user is allowed to use commands
-	if !b.allowedUsers[message.From.ID] {
+	if !b.isAllowed(message.From.ID) {
 		return
 	}
 
@@ -128,12 +232,36 @@ func (b *Bot) handleMessage(ctx context.Context, message *tgbotapi.Message) {
 		response, err = b.handleList(ctx)
 	case "setchat":
 		response, err = b.handleSetChat(ctx, message.Chat.ID, args)
+	case "interval":
+		response, err = b.handleInterval(ctx, args)
+	case "pin":
+		response, err = b.handlePin(ctx, message.Chat.ID, args)
+	case "unpin":
+		response, err = b.handleUnpin(ctx, message.Chat.ID, args)
+	case "pins":
+		response, err = b.handlePins(ctx, message.Chat.ID)
+	case "history":
+		response, err = b.handleHistory(ctx, args)
+	case "release":
+		response, err = b.handleRelease(ctx, args)
+	case "prompt":
+		response, err = b.handlePrompt(ctx, message.Chat.ID, args)
+	case "llmstats":
+		response, err = b.handleLLMStats(ctx)
 	case "test":
 		response = "✅ Bot is working!"
 	case "help":
 		response = b.getHelpText()
 	case "forcecheck":
 		response, err = b.handleForceCheck(ctx)
+	case "status":
+		response = b.handleStatus()
+	case "backup":
+		response, err = b.handleBackup(ctx, message.From.ID)
+	case "reload":
+		response, err = b.handleReload(ctx)
+	case "config":
+		response = b.handleConfig()
 	case "addtestrepo":
 		response, err = b.handleAddTestRepo(ctx)
 	case "testnotify":
@@ -270,7 +398,7 @@ func (b *Bot) handleForceCheck(ctx context.Context) (string, error) {
 	}
 
 	b.logger.Info("Manual release check triggered")
-	
+
 	go func() {
 		if err := b.jobRunner.TriggerCheck(ctx); err != nil {
 			b.logger.Error("Manual release check failed", "error", err)
@@ -280,6 +408,433 @@ func (b *Bot) handleForceCheck(ctx context.Context) (string, error) {
 	return "🔄 Manual release check started...", nil
 }
 
+// handleInterval handles /interval command
+func (b *Bot) handleInterval(ctx context.Context, args string) (string, error) {
+	parts := strings.Fields(args)
+	if len(parts) != 2 {
+		return "Usage: /interval owner/repo minutes|auto", nil
+	}
+
+	repoParts := strings.Split(parts[0], "/")
+	if len(repoParts) != 2 {
+		return "Invalid format. Use: owner/repo", nil
+	}
+
+	var interval time.Duration
+	if parts[1] != "auto" {
+		minutes, err := strconv.Atoi(parts[1])
+		if err != nil || minutes <= 0 {
+			return "Interval must be a positive number of minutes or auto", nil
+		}
+		interval = time.Duration(minutes) * time.Minute
+	}
+
+	if err := b.store.SetRepoInterval(ctx, repoParts[0], repoParts[1], interval); err != nil {
+		return "", err
+	}
+
+	if interval == 0 {
+		return fmt.Sprintf("✅ <b>%s</b> is polled adaptively based on its release cadence", html.EscapeString(parts[0])), nil
+	}
+	return fmt.Sprintf("✅ <b>%s</b> is polled every %s", html.EscapeString(parts[0]), interval), nil
+}
+
+// handlePin handles /pin command
+func (b *Bot) handlePin(ctx context.Context, chatID int64, args string) (string, error) {
+	parts := strings.Fields(args)
+	if len(parts) != 2 {
+		return "Usage: /pin owner/repo version", nil
+	}
+
+	repoParts := strings.Split(parts[0], "/")
+	if len(repoParts) != 2 {
+		return "Invalid format. Use: owner/repo", nil
+	}
+
+	if err := b.store.SetPin(ctx, chatID, repoParts[0], repoParts[1], parts[1]); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("📌 This chat runs <b>%s/%s</b> <code>%s</code>. New releases will be summarised since this version.",
+		html.EscapeString(repoParts[0]), html.EscapeString(repoParts[1]), html.EscapeString(parts[1])), nil
+}
+
+// handleUnpin handles /unpin command
+func (b *Bot) handleUnpin(ctx context.Context, chatID int64, args string) (string, error) {
+	repoParts := strings.Split(strings.TrimSpace(args), "/")
+	if len(repoParts) != 2 {
+		return "Usage: /unpin owner/repo", nil
+	}
+
+	if err := b.store.RemovePin(ctx, chatID, repoParts[0], repoParts[1]); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("✅ Removed pin for <b>%s/%s</b>", html.EscapeString(repoParts[0]), html.EscapeString(repoParts[1])), nil
+}
+
+// handlePins handles /pins command
+func (b *Bot) handlePins(ctx context.Context, chatID int64) (string, error) {
+	pins, err := b.store.ListPins(ctx, chatID)
+	if err != nil {
+		return "", err
+	}
+
+	if len(pins) == 0 {
+		return "No versions pinned in this chat.", nil
+	}
+
+	var response strings.Builder
+	response.WriteString("<b>Pinned versions:</b>\n\n")
+	for _, pin := range pins {
+		response.WriteString(fmt.Sprintf("• <b>%s/%s</b> <code>%s</code>\n",
+			html.EscapeString(pin.Owner), html.EscapeString(pin.Name), html.EscapeString(pin.Version)))
+	}
+
+	return response.String(), nil
+}
+
+// handleHistory handles /history command
+func (b *Bot) handleHistory(ctx context.Context, args string) (string, error) {
+	parts := strings.Fields(args)
+	if len(parts) < 1 || len(parts) > 2 {
+		return "Usage: /history owner/repo [n]", nil
+	}
+
+	repoParts := strings.Split(parts[0], "/")
+	if len(repoParts) != 2 {
+		return "Invalid format. Use: owner/repo", nil
+	}
+
+	limit := 10
+	if len(parts) == 2 {
+		n, err := strconv.Atoi(parts[1])
+		if err != nil || n <= 0 {
+			return "Usage: /history owner/repo [n], where n is a positive number", nil
+		}
+		limit = min(n, 50)
+	}
+
+	releases, err := b.store.ListReleaseHistory(ctx, repoParts[0], repoParts[1], limit)
+	if err != nil {
+		return "", err
+	}
+
+	repo := html.EscapeString(parts[0])
+	if len(releases) == 0 {
+		return fmt.Sprintf("No releases of <b>%s</b> in the history.", repo), nil
+	}
+
+	var response strings.Builder
+	response.WriteString(fmt.Sprintf("<b>Release history of %s:</b>\n\n", repo))
+	for _, r := range releases {
+		tag := html.EscapeString(r.Tag)
+		if r.URL != "" {
+			tag = fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(r.URL), tag)
+		}
+		response.WriteString("• " + tag)
+		if !r.PublishedAt.IsZero() {
+			response.WriteString(" · " + r.PublishedAt.Format("2006-01-02"))
+		}
+		if r.Name != "" && r.Name != r.Tag {
+			response.WriteString(" — " + html.EscapeString(r.Name))
+		}
+		if r.Prerelease {
+			response.WriteString(" <i>(pre-release)</i>")
+		}
+		response.WriteString("\n")
+	}
+	response.WriteString(fmt.Sprintf("\nShow a release: /release %s tag", repo))
+
+	return response.String(), nil
+}
+
+// handleRelease handles /release command
+func (b *Bot) handleRelease(ctx context.Context, args string) (string, error) {
+	parts := strings.Fields(args)
+	if len(parts) != 2 {
+		return "Usage: /release owner/repo tag", nil
+	}
+
+	repoParts := strings.Split(parts[0], "/")
+	if len(repoParts) != 2 {
+		return "Invalid format. Use: owner/repo", nil
+	}
+
+	release, ok, err := b.store.GetRelease(ctx, repoParts[0], repoParts[1], parts[1])
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return fmt.Sprintf("Release <code>%s</code> of <b>%s</b> is not in the history. Use /history %s to list known releases.",
+			html.EscapeString(parts[1]), html.EscapeString(parts[0]), html.EscapeString(parts[0])), nil
+	}
+	if release.Message == "" {
+		return fmt.Sprintf("Release <code>%s</code> of <b>%s</b> was processed before release details were stored.",
+			html.EscapeString(parts[1]), html.EscapeString(parts[0])), nil
+	}
+
+	return release.Message, nil
+}
+
+// handlePrompt handles /prompt command
+func (b *Bot) handlePrompt(ctx context.Context, chatID int64, args string) (string, error) {
+	if b.prompts == nil {
+		return "❌ Prompt templates not available", nil
+	}
+
+	// The first line holds the subcommand, the rest is template text for "save"
+	firstLine, text, _ := strings.Cut(args, "\n")
+	parts := strings.Fields(firstLine)
+	if len(parts) == 0 {
+		return b.promptOverview(ctx, chatID)
+	}
+
+	switch {
+	case parts[0] == "list":
+		return b.promptOverview(ctx, chatID)
+
+	case parts[0] == "show" && len(parts) == 2:
+		p, ok, err := b.prompts.GetPrompt(ctx, parts[1])
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			return fmt.Sprintf("Unknown prompt <b>%s</b>", html.EscapeString(parts[1])), nil
+		}
+		return fmt.Sprintf("<b>%s</b> <code>%s</code> (%s)\n\n<b>System:</b>\n<pre>%s</pre>\n<b>User:</b>\n<pre>%s</pre>",
+			html.EscapeString(p.Name), p.Version, p.Source, html.EscapeString(p.System), html.EscapeString(p.User)), nil
+
+	case parts[0] == "set" && len(parts) == 2:
+		if err := b.prompts.AssignPrompt(ctx, "chat", strconv.FormatInt(chatID, 10), parts[1]); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("✅ Advisor prompt for this chat set to <b>%s</b>", html.EscapeString(parts[1])), nil
+
+	case parts[0] == "repo" && len(parts) == 3:
+		if len(strings.Split(parts[1], "/")) != 2 {
+			return "Invalid format. Use: owner/repo", nil
+		}
+		if err := b.prompts.AssignPrompt(ctx, "repo", parts[1], parts[2]); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("✅ Advisor prompt for <b>%s</b> set to <b>%s</b>", html.EscapeString(parts[1]), html.EscapeString(parts[2])), nil
+
+	case parts[0] == "save" && len(parts) == 2:
+		system, user, _ := strings.Cut(text, "\n---\n")
+		if strings.TrimSpace(system) == "" {
+			return "Usage: /prompt save name, then the system template on the next lines, optionally followed by a --- line and the user template", nil
+		}
+		p, err := b.prompts.SavePrompt(ctx, parts[1], strings.TrimSpace(system), strings.TrimSpace(user))
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("✅ Saved prompt <b>%s</b> version <code>%s</code>", html.EscapeString(p.Name), p.Version), nil
+	}
+
+	return `Usage:
+/prompt - Show the prompt of this chat and available prompts
+/prompt show name - Show prompt templates
+/prompt set name - Use prompt in this chat
+/prompt repo owner/repo name - Use prompt for a repository
+/prompt save name - Save prompt (templates on the following lines, system and user separated by ---)
+
+Template variables: {{.Repo}}, {{.Tag}}, {{.PreviousTag}}, {{.Description}}, {{.Body}}, {{.Bullets}}, {{.Commits}}, {{.ChangedFiles}}, {{.Language}}, {{.Context}}`, nil
+}
+
+// promptOverview shows the prompt used in a chat and all available prompts
+func (b *Bot) promptOverview(ctx context.Context, chatID int64) (string, error) {
+	current, err := b.prompts.ResolvePrompt(ctx, chatID, "")
+	if err != nil {
+		return "", err
+	}
+	prompts, err := b.prompts.ListPrompts(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var response strings.Builder
+	response.WriteString(fmt.Sprintf("Prompt of this chat: <b>%s</b> <code>%s</code>\n\n<b>Available prompts:</b>\n",
+		html.EscapeString(current.Name), current.Version))
+	for _, p := range prompts {
+		response.WriteString(fmt.Sprintf("• <b>%s</b> <code>%s</code> (%s)\n", html.EscapeString(p.Name), p.Version, p.Source))
+	}
+	return response.String(), nil
+}
+
+// handleLLMStats handles /llmstats command
+func (b *Bot) handleLLMStats(ctx context.Context) (string, error) {
+	now := time.Now().UTC()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	byModelToday, err := b.store.LLMSpendByModel(ctx, dayStart)
+	if err != nil {
+		return "", err
+	}
+	byModel, err := b.store.LLMSpendByModel(ctx, monthStart)
+	if err != nil {
+		return "", err
+	}
+	byRepo, err := b.store.LLMSpendByRepo(ctx, monthStart)
+	if err != nil {
+		return "", err
+	}
+
+	if len(byModel) == 0 {
+		return "No LLM usage recorded this month.", nil
+	}
+
+	today := totalSpend(byModelToday)
+	month := totalSpend(byModel)
+
+	var response strings.Builder
+	response.WriteString("<b>LLM usage</b>\n\n")
+	response.WriteString(fmt.Sprintf("Today: <b>$%.4f</b> (%d calls)\n", today.CostUSD, today.Calls))
+	response.WriteString(fmt.Sprintf("This month: <b>$%.4f</b> (%d calls, %d/%d tokens)\n",
+		month.CostUSD, month.Calls, month.PromptTokens, month.CompletionTokens))
+
+	response.WriteString("\n<b>By model:</b>\n")
+	for _, sp := range byModel {
+		response.WriteString(formatSpend(sp))
+	}
+
+	response.WriteString("\n<b>By repository:</b>\n")
+	for i, sp := range byRepo {
+		if i >= 15 {
+			response.WriteString(fmt.Sprintf("<i>... and %d more</i>\n", len(byRepo)-i))
+			break
+		}
+		response.WriteString(formatSpend(sp))
+	}
+
+	return response.String(), nil
+}
+
+// totalSpend sums aggregated usage rows
+func totalSpend(rows []LLMSpend) LLMSpend {
+	var total LLMSpend
+	for _, sp := range rows {
+		total.Calls += sp.Calls
+		total.PromptTokens += sp.PromptTokens
+		total.CompletionTokens += sp.CompletionTokens
+		total.CostUSD += sp.CostUSD
+	}
+	return total
+}
+
+// formatSpend formats a single usage row for /llmstats
+func formatSpend(sp LLMSpend) string {
+	return fmt.Sprintf("• <code>%s</code>: $%.4f, %d calls, %d/%d tokens\n",
+		html.EscapeString(sp.Key), sp.CostUSD, sp.Calls, sp.PromptTokens, sp.CompletionTokens)
+}
+
+// handleStatus handles /status command
+func (b *Bot) handleStatus() string {
+	if b.jobRunner == nil {
+		return "❌ Scheduler status not available"
+	}
+
+	st := b.jobRunner.Status()
+	if st.Runs == 0 {
+		return fmt.Sprintf("⏳ No release check has run yet\nNext run: %s", formatTime(st.NextRun))
+	}
+
+	state := "💤 idle"
+	if st.Running {
+		state = "🔄 running"
+		if st.Pending {
+			state += " (one more run queued)"
+		}
+	}
+
+	var response strings.Builder
+	response.WriteString("<b>Release check status</b>\n\n")
+	response.WriteString(fmt.Sprintf("State: %s\n", state))
+	response.WriteString(fmt.Sprintf("Last start: %s\n", formatTime(st.LastStart)))
+	if !st.Running || st.Runs > 1 {
+		response.WriteString(fmt.Sprintf("Last duration: %s\n", st.LastDuration.Round(time.Second)))
+		response.WriteString(fmt.Sprintf("Last result: <b>%s</b>\n", html.EscapeString(st.LastResult)))
+	}
+	if st.LastError != "" {
+		response.WriteString(fmt.Sprintf("Last error: <code>%s</code>\n", html.EscapeString(st.LastError)))
+	}
+	response.WriteString(fmt.Sprintf("Next run: %s\n", formatTime(st.NextRun)))
+	response.WriteString(fmt.Sprintf("Runs: %d, skipped: %d", st.Runs, st.Skipped))
+
+	return response.String()
+}
+
+// formatTime formats a status timestamp
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "—"
+	}
+	return t.Format("2006-01-02 15:04:05 MST")
+}
+
+// handleBackup handles /backup command. The snapshot holds every chat and
+// setting, so it is sent to the requesting admin privately rather than to the
+// chat the command came from.
+func (b *Bot) handleBackup(ctx context.Context, userID int64) (string, error) {
+	if b.backups == nil {
+		return "Backups are only available with SQLite storage.", nil
+	}
+
+	path, err := b.backups.Snapshot(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	doc := tgbotapi.NewDocument(userID, tgbotapi.FilePath(path))
+	doc.Caption = "Database backup " + filepath.Base(path)
+	if _, err := b.api.Send(doc); err != nil {
+		return "", fmt.Errorf("backup %s written, but sending it failed (start a private chat with the bot first): %w", filepath.Base(path), err)
+	}
+
+	return fmt.Sprintf("💾 Backup <code>%s</code> sent to you in a private message.", html.EscapeString(filepath.Base(path))), nil
+}
+
+// handleReload handles /reload command
+func (b *Bot) handleReload(ctx context.Context) (string, error) {
+	if b.config == nil {
+		return "Configuration reload is not available.", nil
+	}
+
+	changed, err := b.config.Reload(ctx)
+	if err != nil {
+		return "", err
+	}
+	if len(changed) == 0 {
+		return "🔄 Configuration reloaded, nothing changed.", nil
+	}
+	return fmt.Sprintf("🔄 Configuration reloaded, changed: <code>%s</code>", html.EscapeString(strings.Join(changed, ", "))), nil
+}
+
+// maxSettingChars limits each value shown by /config, keeping long
+// repository lists within a single message
+const maxSettingChars = 300
+
+// handleConfig handles /config command
+func (b *Bot) handleConfig() string {
+	if b.config == nil {
+		return "Configuration is not available."
+	}
+
+	var sb strings.Builder
+	sb.WriteString("⚙️ <b>Effective configuration</b> (secrets redacted)\n<pre>")
+	for _, s := range b.config.Settings() {
+		value := s.Value
+		if runes := []rune(value); len(runes) > maxSettingChars {
+			value = string(runes[:maxSettingChars]) + "…"
+		}
+		sb.WriteString(html.EscapeString(fmt.Sprintf("%s = %s", s.Name, value)))
+		sb.WriteString("\n")
+	}
+	sb.WriteString("</pre>")
+	return sb.String()
+}
+
 // handleAddTestRepo handles /addtestrepo command
 func (b *Bot) handleAddTestRepo(ctx context.Context) (string, error) {
 	// Добавляем репозиторий с частыми релизами для тестирования
@@ -291,7 +846,7 @@ func (b *Bot) handleAddTestRepo(ctx context.Context) (string, error) {
 		{"docker", "compose", "Docker Compose (стабильные релизы)"},
 		{"prometheus", "prometheus", "Prometheus (регулярные релизы)"},
 	}
-	
+
 	var results []string
 	for _, repo := range testRepos {
 		err := b.store.AddRepository(ctx, repo.owner, repo.name, false)
@@ -301,7 +856,7 @@ func (b *Bot) handleAddTestRepo(ctx context.Context) (string, error) {
 			results = append(results, fmt.Sprintf("✅ %s/%s: %s", repo.owner, repo.name, repo.description))
 		}
 	}
-	
+
 	return fmt.Sprintf("📦 <b>Тестовые репозитории добавлены:</b>\n\n%s\n\n💡 Используйте /forcecheck для проверки релизов", strings.Join(results, "\n")), nil
 }
 
@@ -331,7 +886,8 @@ func (b *Bot) handleTestNotify(ctx context.Context, chatID int64) (string, error
 // handleTestLLM handles /testllm command - tests LLM on a single real release
 func (b *Bot) handleTestLLM(ctx context.Context, chatID int64) (string, error) {
 	// Проверяем, есть ли LLM клиент
-	if !b.hasLLMClient() {
+	llmAdvisor := b.currentAdvisor()
+	if llmAdvisor == nil {
 		return "❌ LLM советник не настроен. Проверьте ADVISOR_ENABLED и OPENROUTER_API_KEY в конфигурации.", nil
 	}
 
@@ -348,7 +904,7 @@ func (b *Bot) handleTestLLM(ctx context.Context, chatID int64) (string, error) {
 	b.logger.Info("Testing LLM advisor", "repo", testRepo, "tag", testTag)
 
 	// Тестируем LLM
-	advice, err := b.testLLMAdvice(ctx, testRepo, testTag, testBullets)
+	advice, err := llmAdvisor.Advise(ctx, testRepo, testTag, testBullets)
 	if err != nil {
 		return fmt.Sprintf("❌ Ошибка LLM: %v", err), nil
 	}
@@ -376,22 +932,12 @@ func (b *Bot) handleTestLLM(ctx context.Context, chatID int64) (string, error) {
 	return "✅ Тест LLM завершен! ☝️ Результат отправлен выше.", nil
 }
 
-// hasLLMClient проверяет, настроен ли LLM клиент (через интерфейс)
-func (b *Bot) hasLLMClient() bool {
-	// Это простая проверка - в реальности мы бы проверили через интерфейс
-	// Пока просто возвращаем true, так как проверка происходит в advisor
-	return true
-}
-
-// testLLMAdvice тестирует LLM через тот же механизм, что и основной код
-func (b *Bot) testLLMAdvice(ctx context.Context, repo, tag string, bullets []string) (string, error) {
-	// Здесь мы должны вызвать тот же LLM клиент, что и в основном коде
-	// Для этого нужно получить доступ к advisor клиенту
-	// Пока сделаем заглушку, которая показывает формат
-	
-	// В реальной реализации здесь должен быть вызов advisor.Advise()
-	// Но для тестирования пока возвращаем тестовый ответ
-	return "This GitHub Actions Runner release brings significant improvements: Docker/Buildx updates enhance container performance, V2 deletion flow improves runner lifecycle management, and Node.js migration support ensures future compatibility. Migration risk is low - mostly infrastructure improvements. Engineers benefit from better CI/CD performance and smoother runner operations.", nil
+// currentAdvisor возвращает действующий LLM клиент или nil, если советник выключен
+func (b *Bot) currentAdvisor() LLMAdvisor {
+	if b.llmAdvisor == nil {
+		return nil
+	}
+	return b.llmAdvisor()
 }
 
 // formatBulletsForTest форматирует bullets для отображения в тесте
@@ -411,7 +957,7 @@ func (b *Bot) sendHTML(ctx context.Context, chatID int64, html string) error {
 	msg := tgbotapi.NewMessage(chatID, html)
 	msg.ParseMode = "HTML"
 	msg.DisableWebPagePreview = true
-	
+
 	_, err := b.api.Send(msg)
 	return err
 }
@@ -422,9 +968,21 @@ func (b *Bot) getHelpText() string {
 
 /addrepo owner/repo [--pre] - Add repository to track
 /delrepo owner/repo - Remove repository from tracking
+/interval owner/repo minutes|auto - Override polling interval of a repository
 /list - List all tracked repositories
 /setchat [chat_id] - Add current or specified chat for notifications
 /forcecheck - Manually trigger release check
+/status - Show release check status
+/pin owner/repo version - Set the version this chat runs
+/unpin owner/repo - Remove a pinned version
+/pins - List pinned versions of this chat
+/history owner/repo [n] - Show the last n processed releases
+/release owner/repo tag - Show a past release notification
+/prompt [list|show|set|repo|save] - Manage advisor prompts
+/llmstats - Show LLM token usage and spend
+/backup - Send a database backup to you privately
+/reload - Re-read and apply the configuration
+/config - Show the effective configuration
 /addtestrepo - Add test repositories with frequent releases
 /testnotify - Show example of release notification  
 /testllm - Test LLM advisor on a single release
@@ -436,5 +994,8 @@ func (b *Bot) getHelpText() string {
 /addrepo kubernetes/kubernetes --pre
 /delrepo golang/go
 /setchat -1001234567890
+/pin cert-manager/cert-manager v1.12.3
+/history golang/go 5
+/release golang/go go1.22.0
 /forcecheck`
 }
@@ -0,0 +1,401 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeStore is an in-memory Store
+type fakeStore struct {
+	pins     map[int64]map[string]string // chat -> owner/name -> version
+	releases map[string][]ReleaseRecord  // owner/name -> newest first
+	limit    int                         // limit of the last ListReleaseHistory call
+	err      error
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		pins:     map[int64]map[string]string{},
+		releases: map[string][]ReleaseRecord{},
+	}
+}
+
+func (f *fakeStore) AddRepository(ctx context.Context, owner, name string, trackPrereleases bool) error {
+	return f.err
+}
+
+func (f *fakeStore) RemoveRepository(ctx context.Context, owner, name string) error {
+	return f.err
+}
+
+func (f *fakeStore) ListRepositories(ctx context.Context) ([]Repository, error) {
+	return nil, f.err
+}
+
+func (f *fakeStore) AddChat(ctx context.Context, chatID int64, title, language string) error {
+	return f.err
+}
+
+func (f *fakeStore) RemoveChat(ctx context.Context, chatID int64) error {
+	return f.err
+}
+
+func (f *fakeStore) ListChats(ctx context.Context) ([]Chat, error) {
+	return nil, f.err
+}
+
+func (f *fakeStore) LLMSpendByRepo(ctx context.Context, since time.Time) ([]LLMSpend, error) {
+	return nil, f.err
+}
+
+func (f *fakeStore) LLMSpendByModel(ctx context.Context, since time.Time) ([]LLMSpend, error) {
+	return nil, f.err
+}
+
+func (f *fakeStore) SetRepoInterval(ctx context.Context, owner, name string, interval time.Duration) error {
+	return f.err
+}
+
+func (f *fakeStore) SetPin(ctx context.Context, chatID int64, owner, name, version string) error {
+	if f.err != nil {
+		return f.err
+	}
+	if f.pins[chatID] == nil {
+		f.pins[chatID] = map[string]string{}
+	}
+	f.pins[chatID][owner+"/"+name] = version
+	return nil
+}
+
+func (f *fakeStore) RemovePin(ctx context.Context, chatID int64, owner, name string) error {
+	if f.err != nil {
+		return f.err
+	}
+	delete(f.pins[chatID], owner+"/"+name)
+	return nil
+}
+
+func (f *fakeStore) ListPins(ctx context.Context, chatID int64) ([]Pin, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	var pins []Pin
+	for repo, version := range f.pins[chatID] {
+		owner, name, _ := strings.Cut(repo, "/")
+		pins = append(pins, Pin{Owner: owner, Name: name, Version: version})
+	}
+	sort.Slice(pins, func(i, j int) bool { return pins[i].Owner+"/"+pins[i].Name < pins[j].Owner+"/"+pins[j].Name })
+	return pins, nil
+}
+
+func (f *fakeStore) ListReleaseHistory(ctx context.Context, owner, name string, limit int) ([]ReleaseRecord, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.limit = limit
+	releases := f.releases[owner+"/"+name]
+	return releases[:min(limit, len(releases))], nil
+}
+
+func (f *fakeStore) GetRelease(ctx context.Context, owner, name, tag string) (ReleaseRecord, bool, error) {
+	if f.err != nil {
+		return ReleaseRecord{}, false, f.err
+	}
+	for _, r := range f.releases[owner+"/"+name] {
+		if r.Tag == tag {
+			return r, true, nil
+		}
+	}
+	return ReleaseRecord{}, false, nil
+}
+
+// fakePrompts is an in-memory PromptManager
+type fakePrompts struct {
+	prompts     map[string]PromptInfo
+	assignments map[string]string // scope:target -> name
+}
+
+func newFakePrompts() *fakePrompts {
+	return &fakePrompts{
+		prompts: map[string]PromptInfo{
+			"default": {Name: "default", Version: "aaaa", Source: "builtin", System: "You are <helpful>", User: "{{.Body}}"},
+		},
+		assignments: map[string]string{},
+	}
+}
+
+func (f *fakePrompts) ListPrompts(ctx context.Context) ([]PromptInfo, error) {
+	var prompts []PromptInfo
+	for _, p := range f.prompts {
+		prompts = append(prompts, p)
+	}
+	sort.Slice(prompts, func(i, j int) bool { return prompts[i].Name < prompts[j].Name })
+	return prompts, nil
+}
+
+func (f *fakePrompts) GetPrompt(ctx context.Context, name string) (PromptInfo, bool, error) {
+	p, ok := f.prompts[name]
+	return p, ok, nil
+}
+
+func (f *fakePrompts) SavePrompt(ctx context.Context, name, system, user string) (PromptInfo, error) {
+	p := PromptInfo{Name: name, Version: "bbbb", Source: "db", System: system, User: user}
+	f.prompts[name] = p
+	return p, nil
+}
+
+func (f *fakePrompts) AssignPrompt(ctx context.Context, scope, target, name string) error {
+	if _, ok := f.prompts[name]; !ok {
+		return errors.New("unknown prompt " + name)
+	}
+	f.assignments[scope+":"+target] = name
+	return nil
+}
+
+func (f *fakePrompts) ResolvePrompt(ctx context.Context, chatID int64, repo string) (PromptInfo, error) {
+	name, ok := f.assignments["chat:"+strconv.FormatInt(chatID, 10)]
+	if !ok {
+		name = "default"
+	}
+	return f.prompts[name], nil
+}
+
+func newTestBot(store *fakeStore, prompts *fakePrompts) *Bot {
+	b := &Bot{store: store}
+	if prompts != nil {
+		b.prompts = prompts
+	}
+	return b
+}
+
+const testChat = int64(-100123)
+
+func TestPinCommands(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	b := newTestBot(store, nil)
+
+	for _, args := range []string{"", "cert-manager/cert-manager", "cert-manager v1.15.0", "a/b/c v1"} {
+		got, err := b.handlePin(ctx, testChat, args)
+		if err != nil {
+			t.Fatalf("/pin %s: %v", args, err)
+		}
+		if !strings.Contains(got, "Usage") && !strings.Contains(got, "Invalid format") {
+			t.Errorf("/pin %s = %q, want a usage message", args, got)
+		}
+	}
+	if len(store.pins) != 0 {
+		t.Fatalf("invalid /pin commands stored pins: %v", store.pins)
+	}
+
+	if got, err := b.handlePins(ctx, testChat); err != nil || got != "No versions pinned in this chat." {
+		t.Errorf("/pins without pins = %q, %v", got, err)
+	}
+
+	got, err := b.handlePin(ctx, testChat, "cert-manager/cert-manager v1.15.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, "<code>v1.15.0</code>") {
+		t.Errorf("/pin reply = %q", got)
+	}
+	if _, err := b.handlePin(ctx, testChat, "golang/go <go1.22>"); err != nil {
+		t.Fatal(err)
+	}
+	if v := store.pins[testChat]["cert-manager/cert-manager"]; v != "v1.15.0" {
+		t.Errorf("pinned version = %q, want v1.15.0", v)
+	}
+
+	got, err = b.handlePins(ctx, testChat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "<b>Pinned versions:</b>\n\n" +
+		"• <b>cert-manager/cert-manager</b> <code>v1.15.0</code>\n" +
+		"• <b>golang/go</b> <code>&lt;go1.22&gt;</code>\n"
+	if got != want {
+		t.Errorf("/pins = %q, want %q", got, want)
+	}
+
+	// pins are per chat
+	if got, _ := b.handlePins(ctx, 42); got != "No versions pinned in this chat." {
+		t.Errorf("/pins in another chat = %q", got)
+	}
+
+	if got, _ := b.handleUnpin(ctx, testChat, "golang"); !strings.Contains(got, "Usage") {
+		t.Errorf("/unpin golang = %q, want a usage message", got)
+	}
+	if _, err := b.handleUnpin(ctx, testChat, " golang/go "); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.pins[testChat]["golang/go"]; ok {
+		t.Error("/unpin did not remove the pin")
+	}
+
+	store.err = errors.New("database is locked")
+	if _, err := b.handlePin(ctx, testChat, "golang/go go1.23"); err == nil {
+		t.Error("/pin hid a store error")
+	}
+	if _, err := b.handlePins(ctx, testChat); err == nil {
+		t.Error("/pins hid a store error")
+	}
+}
+
+func TestHistoryCommand(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	published := time.Date(2026, 2, 6, 12, 0, 0, 0, time.UTC)
+	store.releases["golang/go"] = []ReleaseRecord{
+		{Tag: "go1.23rc1", Name: "go1.23rc1", URL: "https://github.com/golang/go/releases/go1.23rc1", Prerelease: true, PublishedAt: published},
+		{Tag: "go1.22.0", Name: "Go 1.22 <final>"},
+	}
+	b := newTestBot(store, nil)
+
+	tests := []struct {
+		args      string
+		want      []string
+		wantLimit int
+	}{
+		{"", []string{"Usage: /history owner/repo [n]"}, 0},
+		{"golang", []string{"Invalid format"}, 0},
+		{"golang/go 0", []string{"positive number"}, 0},
+		{"golang/go x", []string{"positive number"}, 0},
+		{"golang/go 1 2", []string{"Usage"}, 0},
+		{"kubernetes/kubernetes", []string{"No releases of <b>kubernetes/kubernetes</b>"}, 10},
+		{"golang/go 500", []string{"Release history of golang/go"}, 50},
+		{"golang/go", []string{
+			`• <a href="https://github.com/golang/go/releases/go1.23rc1">go1.23rc1</a> · 2026-02-06 <i>(pre-release)</i>`,
+			"• go1.22.0 — Go 1.22 &lt;final&gt;\n",
+			"/release golang/go tag",
+		}, 10},
+	}
+	for _, tt := range tests {
+		store.limit = 0
+		got, err := b.handleHistory(ctx, tt.args)
+		if err != nil {
+			t.Fatalf("/history %s: %v", tt.args, err)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(got, want) {
+				t.Errorf("/history %s = %q, want it to contain %q", tt.args, got, want)
+			}
+		}
+		if store.limit != tt.wantLimit {
+			t.Errorf("/history %s read %d releases, want %d", tt.args, store.limit, tt.wantLimit)
+		}
+	}
+}
+
+func TestReleaseCommand(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	store.releases["golang/go"] = []ReleaseRecord{
+		{Tag: "go1.22.1", Message: "🚀 <b>golang/go</b> go1.22.1"},
+		{Tag: "go1.22.0"},
+	}
+	b := newTestBot(store, nil)
+
+	tests := []struct {
+		args string
+		want string
+	}{
+		{"golang/go", "Usage: /release owner/repo tag"},
+		{"golang go1.22.1", "Invalid format"},
+		{"golang/go go1.22.1", "🚀 <b>golang/go</b> go1.22.1"},
+		{"golang/go go1.22.0", "processed before release details were stored"},
+		{"golang/go go9", "is not in the history. Use /history golang/go"},
+	}
+	for _, tt := range tests {
+		got, err := b.handleRelease(ctx, tt.args)
+		if err != nil {
+			t.Fatalf("/release %s: %v", tt.args, err)
+		}
+		if !strings.Contains(got, tt.want) {
+			t.Errorf("/release %s = %q, want it to contain %q", tt.args, got, tt.want)
+		}
+	}
+
+	store.err = errors.New("database is locked")
+	if _, err := b.handleRelease(ctx, "golang/go go1.22.1"); err == nil {
+		t.Error("/release hid a store error")
+	}
+}
+
+func TestPromptCommand(t *testing.T) {
+	ctx := context.Background()
+
+	if got, _ := newTestBot(newFakeStore(), nil).handlePrompt(ctx, testChat, ""); !strings.Contains(got, "not available") {
+		t.Errorf("/prompt without prompt templates = %q", got)
+	}
+
+	prompts := newFakePrompts()
+	b := newTestBot(newFakeStore(), prompts)
+
+	got, err := b.handlePrompt(ctx, testChat, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, "Prompt of this chat: <b>default</b> <code>aaaa</code>") || !strings.Contains(got, "• <b>default</b> <code>aaaa</code> (builtin)") {
+		t.Errorf("/prompt = %q", got)
+	}
+	if list, _ := b.handlePrompt(ctx, testChat, "list"); list != got {
+		t.Errorf("/prompt list = %q, want the overview", list)
+	}
+
+	got, err = b.handlePrompt(ctx, testChat, "show default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, "<pre>You are &lt;helpful&gt;</pre>") || !strings.Contains(got, "<pre>{{.Body}}</pre>") {
+		t.Errorf("/prompt show default = %q", got)
+	}
+	if got, _ := b.handlePrompt(ctx, testChat, "show missing"); !strings.Contains(got, "Unknown prompt <b>missing</b>") {
+		t.Errorf("/prompt show missing = %q", got)
+	}
+
+	got, err = b.handlePrompt(ctx, testChat, "save k8s\nYou review Kubernetes releases\n---\nRelease {{.Tag}}\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, "Saved prompt <b>k8s</b> version <code>bbbb</code>") {
+		t.Errorf("/prompt save = %q", got)
+	}
+	if p := prompts.prompts["k8s"]; p.System != "You review Kubernetes releases" || p.User != "Release {{.Tag}}" {
+		t.Errorf("saved prompt = %+v", p)
+	}
+	if got, _ := b.handlePrompt(ctx, testChat, "save empty\n\n"); !strings.Contains(got, "Usage: /prompt save") {
+		t.Errorf("/prompt save without templates = %q", got)
+	}
+	if _, ok := prompts.prompts["empty"]; ok {
+		t.Error("/prompt save without templates saved a prompt")
+	}
+
+	if _, err := b.handlePrompt(ctx, testChat, "set k8s"); err != nil {
+		t.Fatal(err)
+	}
+	if name := prompts.assignments["chat:-100123"]; name != "k8s" {
+		t.Errorf("chat prompt assignment = %q, want k8s", name)
+	}
+	if _, err := b.handlePrompt(ctx, testChat, "set missing"); err == nil {
+		t.Error("/prompt set accepted an unknown prompt")
+	}
+
+	if got, _ := b.handlePrompt(ctx, testChat, "repo kubernetes k8s"); !strings.Contains(got, "Invalid format") {
+		t.Errorf("/prompt repo without owner = %q", got)
+	}
+	if _, err := b.handlePrompt(ctx, testChat, "repo kubernetes/kubernetes k8s"); err != nil {
+		t.Fatal(err)
+	}
+	if name := prompts.assignments["repo:kubernetes/kubernetes"]; name != "k8s" {
+		t.Errorf("repo prompt assignment = %q, want k8s", name)
+	}
+
+	if got, _ := b.handlePrompt(ctx, testChat, "delete k8s"); !strings.HasPrefix(got, "Usage:") {
+		t.Errorf("/prompt delete = %q, want the usage", got)
+	}
+}
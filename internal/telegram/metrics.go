@@ -0,0 +1,30 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var sendsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "release_bot",
+	Subsystem: "telegram",
+	Name:      "sends_total",
+	Help:      "Telegram messages sent by outcome: sent, permanent_error, failed or cancelled.",
+}, []string{"outcome"})
+
+// sendOutcome classifies the result of sending a message for metrics
+func sendOutcome(err error) string {
+	switch {
+	case err == nil:
+		return "sent"
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return "cancelled"
+	case isPermanentError(err):
+		return "permanent_error"
+	default:
+		return "failed"
+	}
+}
@@ -0,0 +1,64 @@
+package telegram
+
+import (
+	"context"
+
+	"github.com/yourorg/tg-release-bot/internal/advisor"
+)
+
+// PromptAdapter adapts advisor.PromptLibrary to telegram.PromptManager interface
+type PromptAdapter struct {
+	lib *advisor.PromptLibrary
+}
+
+// NewPromptAdapter creates a new prompt adapter
+func NewPromptAdapter(lib *advisor.PromptLibrary) PromptManager {
+	return &PromptAdapter{lib: lib}
+}
+
+// ListPrompts implements PromptManager.ListPrompts
+func (a *PromptAdapter) ListPrompts(ctx context.Context) ([]PromptInfo, error) {
+	prompts, err := a.lib.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var infos []PromptInfo
+	for _, p := range prompts {
+		infos = append(infos, promptInfo(p))
+	}
+	return infos, nil
+}
+
+// GetPrompt implements PromptManager.GetPrompt
+func (a *PromptAdapter) GetPrompt(ctx context.Context, name string) (PromptInfo, bool, error) {
+	p, ok, err := a.lib.Get(ctx, name)
+	return promptInfo(p), ok, err
+}
+
+// SavePrompt implements PromptManager.SavePrompt
+func (a *PromptAdapter) SavePrompt(ctx context.Context, name, system, user string) (PromptInfo, error) {
+	p, err := a.lib.Save(ctx, name, system, user)
+	return promptInfo(p), err
+}
+
+// AssignPrompt implements PromptManager.AssignPrompt
+func (a *PromptAdapter) AssignPrompt(ctx context.Context, scope, target, name string) error {
+	return a.lib.Assign(ctx, scope, target, name)
+}
+
+// ResolvePrompt implements PromptManager.ResolvePrompt
+func (a *PromptAdapter) ResolvePrompt(ctx context.Context, chatID int64, repo string) (PromptInfo, error) {
+	p, err := a.lib.Resolve(ctx, chatID, repo)
+	return promptInfo(p), err
+}
+
+func promptInfo(p advisor.Prompt) PromptInfo {
+	return PromptInfo{
+		Name:    p.Name,
+		Version: p.Version(),
+		Source:  p.Source,
+		System:  p.System,
+		User:    p.User,
+	}
+}
@@ -7,8 +7,15 @@ import (
 	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/yourorg/tg-release-bot/internal/tracing"
 )
 
+var tracer = otel.Tracer("github.com/yourorg/tg-release-bot/internal/telegram")
+
 // Sender handles Telegram message sending
 type Sender struct {
 	bot *tgbotapi.BotAPI
@@ -24,14 +31,37 @@ func NewSender(token string) (*Sender, error) {
 	return &Sender{bot: bot}, nil
 }
 
+// SendOptions adjusts how a message is delivered
+type SendOptions struct {
+	Silent bool // deliver without a notification sound
+}
+
 // SendHTML sends an HTML message to a chat, splitting if necessary
 func (s *Sender) SendHTML(ctx context.Context, chatID int64, html string) error {
+	return s.SendHTMLWith(ctx, chatID, html, SendOptions{})
+}
+
+// SendHTMLWith sends an HTML message to a chat with delivery options
+func (s *Sender) SendHTMLWith(ctx context.Context, chatID int64, html string, opts SendOptions) (err error) {
 	chunks := chunkHTML(html, 4000)
 
+	ctx, span := tracer.Start(ctx, "telegram send",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.Int64("telegram.chat_id", chatID),
+			attribute.Int("telegram.chunks", len(chunks)),
+			attribute.Bool("telegram.silent", opts.Silent),
+		))
+	defer func() {
+		sendsTotal.WithLabelValues(sendOutcome(err)).Inc()
+		tracing.End(span, err)
+	}()
+
 	for _, chunk := range chunks {
 		msg := tgbotapi.NewMessage(chatID, chunk)
 		msg.ParseMode = "HTML"
 		msg.DisableWebPagePreview = true
+		msg.DisableNotification = opts.Silent
 
 		// Retry logic for sending messages
 		var lastErr error
@@ -42,7 +72,8 @@ func (s *Sender) SendHTML(ctx context.Context, chatID int64, html string) error
 			}
 
 			lastErr = err
-			
+			span.AddEvent("attempt failed", trace.WithAttributes(attribute.String("error", err.Error())))
+
 			// Check if error is permanent (don't retry these)
 			if isPermanentError(err) {
 				return fmt.Errorf("permanent telegram error: %w", err)
@@ -65,7 +96,11 @@ func (s *Sender) SendHTML(ctx context.Context, chatID int64, html string) error
 
 		// Small delay between chunks to avoid rate limiting
 		if len(chunks) > 1 {
-			time.Sleep(100 * time.Millisecond)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(100 * time.Millisecond):
+			}
 		}
 	}
 
@@ -142,9 +177,9 @@ func isPermanentError(err error) bool {
 	if err == nil {
 		return false
 	}
-	
+
 	errStr := strings.ToLower(err.Error())
-	
+
 	// These errors indicate permanent issues that won't be fixed by retrying
 	permanentErrors := []string{
 		"chat not found",
@@ -155,12 +190,20 @@ func isPermanentError(err error) bool {
 		"bad request: can't parse entities",
 		"forbidden",
 	}
-	
+
 	for _, permErr := range permanentErrors {
 		if strings.Contains(errStr, permErr) {
 			return true
 		}
 	}
-	
+
 	return false
 }
+
+// Ping checks that the bot token is accepted by calling getMe
+func (s *Sender) Ping() error {
+	if _, err := s.bot.GetMe(); err != nil {
+		return fmt.Errorf("getMe failed: %w", err)
+	}
+	return nil
+}
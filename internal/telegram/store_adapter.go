@@ -2,17 +2,18 @@ package telegram
 
 import (
 	"context"
+	"time"
 
 	"github.com/yourorg/tg-release-bot/internal/db"
 )
 
-// StoreAdapter adapts db.Store to telegram.Store interface
+// StoreAdapter adapts db.Storage to telegram.Store interface
 type StoreAdapter struct {
-	store *db.Store
+	store db.Storage
 }
 
 // NewStoreAdapter creates a new store adapter
-func NewStoreAdapter(store *db.Store) Store {
+func NewStoreAdapter(store db.Storage) Store {
 	return &StoreAdapter{store: store}
 }
 
@@ -71,3 +72,98 @@ func (a *StoreAdapter) ListChats(ctx context.Context) ([]Chat, error) {
 	}
 	return chats, nil
 }
+
+// SetRepoInterval implements Store.SetRepoInterval
+func (a *StoreAdapter) SetRepoInterval(ctx context.Context, owner, name string, interval time.Duration) error {
+	return a.store.SetRepoInterval(ctx, owner, name, interval)
+}
+
+// SetPin implements Store.SetPin
+func (a *StoreAdapter) SetPin(ctx context.Context, chatID int64, owner, name, version string) error {
+	return a.store.SetPin(ctx, chatID, owner, name, version)
+}
+
+// RemovePin implements Store.RemovePin
+func (a *StoreAdapter) RemovePin(ctx context.Context, chatID int64, owner, name string) error {
+	return a.store.RemovePin(ctx, chatID, owner, name)
+}
+
+// ListPins implements Store.ListPins
+func (a *StoreAdapter) ListPins(ctx context.Context, chatID int64) ([]Pin, error) {
+	dbPins, err := a.store.ListPins(ctx, chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	var pins []Pin
+	for _, p := range dbPins {
+		pins = append(pins, Pin{Owner: p.RepoOwner, Name: p.RepoName, Version: p.Version})
+	}
+	return pins, nil
+}
+
+// ListReleaseHistory implements Store.ListReleaseHistory
+func (a *StoreAdapter) ListReleaseHistory(ctx context.Context, owner, name string, limit int) ([]ReleaseRecord, error) {
+	dbReleases, err := a.store.ListReleaseHistory(ctx, owner, name, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	var releases []ReleaseRecord
+	for _, r := range dbReleases {
+		releases = append(releases, convertRelease(r))
+	}
+	return releases, nil
+}
+
+// GetRelease implements Store.GetRelease
+func (a *StoreAdapter) GetRelease(ctx context.Context, owner, name, tag string) (ReleaseRecord, bool, error) {
+	r, ok, err := a.store.GetReleaseByTag(ctx, owner, name, tag)
+	if err != nil || !ok {
+		return ReleaseRecord{}, ok, err
+	}
+	return convertRelease(r), true, nil
+}
+
+func convertRelease(r db.ProcessedRelease) ReleaseRecord {
+	return ReleaseRecord{
+		Tag:         r.TagName,
+		Name:        r.Name,
+		URL:         r.URL,
+		Prerelease:  r.Prerelease,
+		PublishedAt: r.PublishedAt,
+		Message:     r.Message,
+	}
+}
+
+// LLMSpendByRepo implements Store.LLMSpendByRepo
+func (a *StoreAdapter) LLMSpendByRepo(ctx context.Context, since time.Time) ([]LLMSpend, error) {
+	dbSpend, err := a.store.LLMSpendByRepo(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+	return convertSpend(dbSpend), nil
+}
+
+// LLMSpendByModel implements Store.LLMSpendByModel
+func (a *StoreAdapter) LLMSpendByModel(ctx context.Context, since time.Time) ([]LLMSpend, error) {
+	dbSpend, err := a.store.LLMSpendByModel(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+	return convertSpend(dbSpend), nil
+}
+
+func convertSpend(dbSpend []db.LLMSpend) []LLMSpend {
+	var spend []LLMSpend
+	for _, sp := range dbSpend {
+		spend = append(spend, LLMSpend{
+			Key:              sp.Key,
+			Calls:            sp.Calls,
+			PromptTokens:     sp.PromptTokens,
+			CompletionTokens: sp.CompletionTokens,
+			CostUSD:          sp.CostUSD,
+		})
+	}
+	return spend
+}
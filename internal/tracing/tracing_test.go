@@ -0,0 +1,121 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// exportedSpan is the part of a span written by the stdout exporter the tests look at
+type exportedSpan struct {
+	Name        string
+	SpanContext struct{ TraceID, SpanID string }
+	Parent      struct{ SpanID string }
+	Attributes  []struct {
+		Key   string
+		Value struct{ Value any }
+	}
+	Status   struct{ Code, Description string }
+	Resource []struct {
+		Key   string
+		Value struct{ Value any }
+	}
+}
+
+func decodeSpans(t *testing.T, r io.Reader) map[string]exportedSpan {
+	t.Helper()
+	spans := make(map[string]exportedSpan)
+	dec := json.NewDecoder(r)
+	for {
+		var span exportedSpan
+		if err := dec.Decode(&span); err == io.EOF {
+			return spans
+		} else if err != nil {
+			t.Fatalf("decode exported span: %v", err)
+		}
+		spans[span.Name] = span
+	}
+}
+
+func TestStdoutExporter(t *testing.T) {
+	var buf bytes.Buffer
+	shutdown, err := Setup(context.Background(), Options{Exporter: ExporterStdout, SampleRatio: 1, Writer: &buf})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tracer := otel.Tracer("test")
+	ctx, parent := tracer.Start(context.Background(), "release check")
+	_, child := tracer.Start(ctx, "process repository")
+	child.SetAttributes(attribute.String("repo", "golang/go"))
+	End(child, errors.New("rate limited"))
+	End(parent, nil)
+
+	var logs bytes.Buffer
+	Logger(ctx, slog.New(slog.NewTextHandler(&logs, nil))).Info("checked")
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := decodeSpans(t, &buf)
+	got, ok := spans["process repository"]
+	if !ok {
+		t.Fatalf("child span not exported, got %v", spans)
+	}
+	root := spans["release check"]
+	if got.Parent.SpanID != root.SpanContext.SpanID || got.SpanContext.TraceID != root.SpanContext.TraceID {
+		t.Error("child span is not part of the parent's trace")
+	}
+	if got.Status.Code != "Error" || got.Status.Description != "rate limited" {
+		t.Errorf("child status = %+v, want the error", got.Status)
+	}
+	if root.Status.Code == "Error" {
+		t.Error("span ended without an error has an error status")
+	}
+	if len(got.Attributes) != 1 || got.Attributes[0].Key != "repo" || got.Attributes[0].Value.Value != "golang/go" {
+		t.Errorf("child attributes = %+v", got.Attributes)
+	}
+
+	var service any
+	for _, attr := range root.Resource {
+		if attr.Key == "service.name" {
+			service = attr.Value.Value
+		}
+	}
+	if service != serviceName {
+		t.Errorf("service.name = %v, want %s", service, serviceName)
+	}
+
+	if !strings.Contains(logs.String(), "trace_id="+root.SpanContext.TraceID) {
+		t.Errorf("log record does not carry the trace ID: %s", logs.String())
+	}
+}
+
+func TestSetupWithoutExporter(t *testing.T) {
+	shutdown, err := Setup(context.Background(), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Setup(context.Background(), Options{Exporter: "zipkin"}); err == nil {
+		t.Error("Setup accepted an unknown exporter")
+	}
+}
+
+func TestLoggerWithoutSpan(t *testing.T) {
+	var logs bytes.Buffer
+	Logger(context.Background(), slog.New(slog.NewTextHandler(&logs, nil))).Info("checked")
+	if strings.Contains(logs.String(), "trace_id") {
+		t.Errorf("log record outside a span has a trace ID: %s", logs.String())
+	}
+}